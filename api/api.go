@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -18,25 +20,191 @@ type GetTimetableResponse struct {
 	DownloadURL string `json:"downloadURL" example:"https://example.com/.../GTFSSchedule.zip"`
 }
 
+type ScheduleVersion struct {
+	VersionID  string    `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	UploadedAt time.Time `json:"uploadedAt" example:"2026-01-13T09:30:00Z"`
+	Size       int64     `json:"size" example:"1048576"`
+	ETag       string    `json:"etag" example:"d41d8cd98f00b204e9800998ecf8427e"`
+}
+
+type GetScheduleVersionsResponse struct {
+	Code     int               `json:"code" example:"200"`
+	Versions []ScheduleVersion `json:"versions"`
+}
+
+// ValidationIssue is a problem found in a GTFS package, located as precisely as possible.
+type ValidationIssue struct {
+	File    string `json:"file,omitempty" example:"trips.txt"`
+	Line    int    `json:"line,omitempty" example:"5"`
+	Field   string `json:"field,omitempty" example:"route_id"`
+	Message string `json:"message" example:"route_id \"9\" does not exist in routes.txt"`
+}
+
 type PutTimetableResponse struct {
-	Code      int    `json:"code" example:"202"`
-	VersionID string `json:"versionID" example:"20231215-143022"`
+	Code      int               `json:"code" example:"202"`
+	VersionID string            `json:"versionID" example:"20231215-143022"`
+	Warnings  []ValidationIssue `json:"warnings,omitempty"`
+}
+
+type GTFSValidationErrorResponse struct {
+	Code         int               `json:"code" example:"400"`
+	Message      string            `json:"message" example:"GTFS schedule failed validation with 2 errors"`
+	Errors       []ValidationIssue `json:"errors"`
+	Warnings     []ValidationIssue `json:"warnings"`
+	ErrorCount   int               `json:"errorCount" example:"2"`
+	WarningCount int               `json:"warningCount" example:"0"`
+}
+
+type RollbackScheduleResponse struct {
+	Code            int    `json:"code" example:"202"`
+	VersionID       string `json:"versionID" example:"9b2f0c4e-1a7d-4f38-b6a2-3c1d5e7f9a10"`
+	SourceVersionID string `json:"sourceVersionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+}
+
+type Stop struct {
+	StopID             string  `json:"stopID" example:"1001"`
+	Code               string  `json:"code,omitempty" example:"1001"`
+	Name               string  `json:"name" example:"Douglas Bus Station"`
+	Lat                float64 `json:"lat" example:"54.1467"`
+	Lon                float64 `json:"lon" example:"-4.4794"`
+	LocationType       int     `json:"locationType" example:"0"`
+	ParentStationID    string  `json:"parentStationID,omitempty" example:"DGLS"`
+	PlatformCode       string  `json:"platformCode,omitempty" example:"A"`
+	WheelchairBoarding int     `json:"wheelchairBoarding" example:"1"`
+}
+
+type Route struct {
+	RouteID   string `json:"routeID" example:"3"`
+	Agency    string `json:"agency" example:"Bus Vannin"`
+	ShortName string `json:"shortName" example:"3"`
+	LongName  string `json:"longName" example:"Douglas - Ramsey"`
+	Type      int    `json:"type" example:"3"`
+	Color     string `json:"color,omitempty" example:"E30613"`
+	TextColor string `json:"textColor,omitempty" example:"FFFFFF"`
+}
+
+type RoutePattern struct {
+	DirectionID int    `json:"directionID" example:"0"`
+	Headsign    string `json:"headsign" example:"Ramsey"`
+	Stops       []Stop `json:"stops"`
+}
+
+type GetStopsResponse struct {
+	Code      int    `json:"code" example:"200"`
+	VersionID string `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	Stops     []Stop `json:"stops"`
+}
+
+type GetStopResponse struct {
+	Code      int     `json:"code" example:"200"`
+	VersionID string  `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	Stop      Stop    `json:"stop"`
+	Children  []Stop  `json:"children,omitempty"`
+	Routes    []Route `json:"routes"`
+}
+
+type GetRoutesResponse struct {
+	Code      int     `json:"code" example:"200"`
+	VersionID string  `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	Routes    []Route `json:"routes"`
+}
+
+type GetRouteResponse struct {
+	Code      int            `json:"code" example:"200"`
+	VersionID string         `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	Route     Route          `json:"route"`
+	Patterns  []RoutePattern `json:"patterns"`
+}
+
+type Departure struct {
+	TripID         string    `json:"tripID" example:"T3"`
+	RouteID        string    `json:"routeID" example:"3"`
+	RouteShortName string    `json:"routeShortName" example:"3"`
+	Headsign       string    `json:"headsign" example:"Ramsey"`
+	StopID         string    `json:"stopID" example:"1001"`
+	ServiceDate    string    `json:"serviceDate" example:"20260113"`
+	ScheduledTime  time.Time `json:"scheduledTime" example:"2026-01-13T09:00:00Z"`
+	// Status is "scheduled", "live" when a tracked bus is running the trip, or "noShow" when the
+	// trip should be running but no bus is tracked for it
+	Status        string    `json:"status" example:"live"`
+	EstimatedTime time.Time `json:"estimatedTime" example:"2026-01-13T09:03:00Z"`
+	DelaySeconds  *int      `json:"delaySeconds,omitempty" example:"180"`
+	BusID         string    `json:"busID,omitempty" example:"123"`
+}
+
+type GetDeparturesResponse struct {
+	Code       int         `json:"code" example:"200"`
+	VersionID  string      `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	Stop       Stop        `json:"stop"`
+	Departures []Departure `json:"departures"`
 }
 
 type GetMessagesResponse struct {
 	Code      int    `json:"code" example:"200"`
-	Messages  string `json:"messages" example:"{\"timestamp\": \"2026-1-1T00:00:00.000Z\", \"message\": \"Example message\"}"`
+	Messages  string `json:"messages" example:"{\"id\": \"0c6f8d2e-5b1a-4e3f-9a7d-2b8c4e6f1a3d\", \"timestamp\": \"2026-1-1T00:00:00.000Z\", \"message\": \"Example message\"}"`
 	VersionID string `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	// NextCursor selects the page of older messages, if there are any
+	NextCursor string `json:"next_cursor,omitempty" example:"MjAyNi0wMS0xM1QwODowMDowMFo"`
 }
 
 type PutMessageResponse struct {
 	Code      int    `json:"code" example:"202"`
 	VersionID string `json:"versionID" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	ID        string `json:"id" example:"0c6f8d2e-5b1a-4e3f-9a7d-2b8c4e6f1a3d"`
 }
 
 type GetBusLocationsResponse struct {
 	Code      int    `json:"code" example:"200"`
-	Locations string `json:"locations" example:"{\"bus_id\":\"123\",\"departure_time\":\"1212\",\"route_number\":\"12\",\"direction\":\"outbound\",\"latitude\":54.120918,\"longitude\":-4.580032,\"timestamp\":\"0001-01-01T00:00:00Z\"}"`
+	Locations string `json:"locations" example:"[{\"bus_id\":\"123\",\"departure_time\":\"1212\",\"route_number\":\"12\",\"direction\":\"outbound\",\"latitude\":54.120918,\"longitude\":-4.580032,\"route_id\":\"12\",\"trip_id\":\"12-1212\",\"match_confidence\":0.92,\"delay_seconds\":95}]"`
+}
+
+// BusLocation is the last reported position of a bus, along with the journey it reported running.
+type BusLocation struct {
+	BusID         string  `json:"bus_id" example:"123"`
+	DepartureTime string  `json:"departure_time" example:"1212"`
+	RouteNumber   string  `json:"route_number" example:"12"`
+	Direction     string  `json:"direction" example:"outbound"`
+	Latitude      float64 `json:"latitude" example:"54.120918"`
+	Longitude     float64 `json:"longitude" example:"-4.580032"`
+}
+
+// TrackedBus is a bus location along with the GTFS route and trip it was matched to. RouteID and
+// TripID are empty when the bus could not be matched, in which case MatchConfidence is 0 and
+// DelaySeconds is left out.
+type TrackedBus struct {
+	BusLocation
+	RouteID         string  `json:"route_id,omitempty" example:"12"`
+	TripID          string  `json:"trip_id,omitempty" example:"12-1212"`
+	MatchConfidence float64 `json:"match_confidence" example:"0.92"`
+	// DelaySeconds is how late the bus is running against its trip's schedule, negative when early
+	DelaySeconds *int `json:"delay_seconds,omitempty" example:"95"`
+}
+
+// LocationEvent is the data of an event on the bus location stream. It holds the latest location
+// of the bus, or its last location if it expired or was removed.
+type LocationEvent struct {
+	BusLocation
+	// LastSeen is when the tracker last reported the bus
+	LastSeen time.Time `json:"last_seen" example:"2026-01-13T12:12:30Z"`
+}
+
+// Position is where a bus was at a point in time, along with the trip it reported running. Its
+// fields are named like those of BusLocation.
+type Position struct {
+	Timestamp     time.Time `json:"timestamp" example:"2026-01-13T12:12:30Z"`
+	Latitude      float64   `json:"latitude" example:"54.120918"`
+	Longitude     float64   `json:"longitude" example:"-4.580032"`
+	RouteNumber   string    `json:"route_number,omitempty" example:"12"`
+	Direction     string    `json:"direction,omitempty" example:"outbound"`
+	DepartureTime string    `json:"departure_time,omitempty" example:"1212"`
+}
+
+type GetBusHistoryResponse struct {
+	Code      int        `json:"code" example:"200"`
+	BusID     string     `json:"busID" example:"123"`
+	From      time.Time  `json:"from" example:"2026-01-13T12:00:00Z"`
+	To        time.Time  `json:"to" example:"2026-01-13T13:00:00Z"`
+	Positions []Position `json:"positions"`
 }
 
 type PostReportBody struct {
@@ -70,6 +238,25 @@ var (
 	RequestErrorHandler = func(w http.ResponseWriter, err error) {
 		writeError(w, http.StatusBadRequest, err.Error())
 	}
+	NotFoundErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusNotFound, err.Error())
+	}
+	PreconditionFailedErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	}
+	GTFSValidationErrorHandler = func(w http.ResponseWriter, resp GTFSValidationErrorResponse) {
+		resp.Code = http.StatusBadRequest
+		resp.Message = fmt.Sprintf("GTFS schedule failed validation with %d errors", resp.ErrorCount)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.Code)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Errorf("Error writing response: %v", err)
+		}
+	}
+	ServiceUnavailableErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	}
 	UnauthorizedErrorHandler = func(w http.ResponseWriter, err error) {
 		writeError(w, http.StatusUnauthorized, err.Error())
 	}
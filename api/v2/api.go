@@ -0,0 +1,137 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Meta holds what a response says about its data. Fields that do not apply are left out.
+type Meta struct {
+	// VersionID is the version of the stored data the response was read from
+	VersionID string `json:"versionID,omitempty" example:"5e4b7d12-542f-4ecf-8d95-7fbec7f7e806"`
+	// NextCursor selects the next page of a list, if there is one
+	NextCursor string `json:"nextCursor,omitempty" example:"MjAyNi0wMS0xM1QwODowMDowMFo"`
+}
+
+// Message is a service message in its latest revision.
+type Message struct {
+	ID       string     `json:"id" example:"0c6f8d2e-5b1a-4e3f-9a7d-2b8c4e6f1a3d"`
+	PostedAt time.Time  `json:"postedAt" example:"2026-01-13T08:00:00Z"`
+	EditedAt *time.Time `json:"editedAt,omitempty" example:"2026-01-13T08:05:00Z"`
+	// RetractedAt is only set on the message returned when retracting it and on the tombstones
+	// returned to since queries
+	RetractedAt *time.Time `json:"retractedAt,omitempty" example:"2026-01-13T10:00:00Z"`
+	Message     string     `json:"message" example:"Route 3 is diverted away from Onchan Village"`
+	Severity    string     `json:"severity,omitempty" example:"WARNING"`
+	Cause       string     `json:"cause,omitempty" example:"CONSTRUCTION"`
+	Effect      string     `json:"effect,omitempty" example:"DETOUR"`
+	Category    string     `json:"category,omitempty" example:"diversion"`
+	RouteIDs    []string   `json:"routeIDs,omitempty" example:"3"`
+	StopIDs     []string   `json:"stopIDs,omitempty" example:"1002"`
+	ActiveFrom  *time.Time `json:"activeFrom,omitempty" example:"2026-01-13T08:00:00Z"`
+	ActiveUntil *time.Time `json:"activeUntil,omitempty" example:"2026-01-14T18:00:00Z"`
+}
+
+// MessageRequest is the body of a request posting or editing a message. Only the message is
+// required.
+type MessageRequest struct {
+	Message string `json:"message" example:"Route 3 is diverted away from Onchan Village"`
+	// Severity is INFO, WARNING or SEVERE
+	Severity string `json:"severity,omitempty" example:"WARNING"`
+	// Cause and Effect are named after the GTFS Realtime alert enums
+	Cause  string `json:"cause,omitempty" example:"CONSTRUCTION"`
+	Effect string `json:"effect,omitempty" example:"DETOUR"`
+	// Category is general, disruption, diversion, plannedWorks, event or weather
+	Category string   `json:"category,omitempty" example:"diversion"`
+	RouteIDs []string `json:"routeIDs,omitempty" example:"3"`
+	StopIDs  []string `json:"stopIDs,omitempty" example:"1002"`
+	// ActiveFrom and ActiveUntil are RFC 3339 timestamps bounding when the message applies
+	ActiveFrom  string `json:"activeFrom,omitempty" example:"2026-01-13T08:00:00Z"`
+	ActiveUntil string `json:"activeUntil,omitempty" example:"2026-01-14T18:00:00Z"`
+}
+
+type MessagesResponse struct {
+	Data []Message `json:"data"`
+	Meta Meta      `json:"meta"`
+}
+
+type MessageResponse struct {
+	Data Message `json:"data"`
+	Meta Meta    `json:"meta"`
+}
+
+// Vehicle is a tracked bus along with the GTFS route and trip it was matched to. RouteID and
+// TripID are left out when the bus could not be matched, in which case MatchConfidence is 0.
+type Vehicle struct {
+	BusID         string  `json:"busID" example:"123"`
+	RouteNumber   string  `json:"routeNumber" example:"12"`
+	Direction     string  `json:"direction" example:"outbound"`
+	DepartureTime string  `json:"departureTime" example:"1212"`
+	Lat           float64 `json:"lat" example:"54.120918"`
+	Lon           float64 `json:"lon" example:"-4.580032"`
+	// LastSeen is when the tracker last reported the bus
+	LastSeen        *time.Time `json:"lastSeen,omitempty" example:"2026-01-13T12:12:30Z"`
+	RouteID         string     `json:"routeID,omitempty" example:"12"`
+	TripID          string     `json:"tripID,omitempty" example:"12-1212"`
+	MatchConfidence float64    `json:"matchConfidence" example:"0.92"`
+	// DelaySeconds is how late the bus is running against its trip's schedule, negative when early
+	DelaySeconds *int `json:"delaySeconds,omitempty" example:"95"`
+}
+
+type VehiclesResponse struct {
+	Data []Vehicle `json:"data"`
+	Meta Meta      `json:"meta"`
+}
+
+// Error describes why a request failed. Code is a stable, machine readable name for the HTTP
+// status, and Message is meant for people.
+type Error struct {
+	Status  int    `json:"status" example:"404"`
+	Code    string `json:"code" example:"notFound"`
+	Message string `json:"message" example:"message 0c6f8d2e-5b1a-4e3f-9a7d-2b8c4e6f1a3d not found"`
+}
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Write writes a response with a status code.
+func Write(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Errorf("Error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	Write(w, status, ErrorResponse{Error: Error{Status: status, Code: code, Message: message}})
+}
+
+var (
+	RequestErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+	}
+	UnauthorizedErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusUnauthorized, "unauthorized", err.Error())
+	}
+	NotFoundErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusNotFound, "notFound", err.Error())
+	}
+	MethodNotAllowedErrorHandler = func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, "methodNotAllowed", r.Method+" is not allowed on "+r.URL.Path)
+	}
+	TooManyRequestsErrorHandler = func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusTooManyRequests, "tooManyRequests", "Too many requests, retry later")
+	}
+	ServiceUnavailableErrorHandler = func(w http.ResponseWriter, err error) {
+		writeError(w, http.StatusServiceUnavailable, "serviceUnavailable", err.Error())
+	}
+	InternalErrorHandler = func(w http.ResponseWriter) {
+		writeError(w, http.StatusInternalServerError, "internal", "Internal Server Error")
+	}
+)
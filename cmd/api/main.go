@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,9 +18,18 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	log "github.com/sirupsen/logrus"
 	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/history"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
 	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
+// scheduleRefreshInterval is how often the latest GTFS schedule version is checked for changes.
+const scheduleRefreshInterval = time.Minute
+
+// historyFlushInterval is how often recorded bus positions are written to storage.
+const historyFlushInterval = time.Minute
+
 func init() {
 	err := godotenv.Load()
 	if err != nil {
@@ -50,20 +62,8 @@ func init() {
 func main() {
 	log.SetReportCaller(true)
 
-	// initialize minio
-	accessKey := os.Getenv("MINIO_ACCESS_KEY")
-	secretKey := os.Getenv("MINIO_SECRET_KEY")
-	endpoint := os.Getenv("MINIO_ENDPOINT")
-
-	minioClient, err := minio.New(endpoint, &minio.Options{
-		Secure: false,
-		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
-	})
-	if err != nil {
-		log.Fatalf("Failed to create MinIO client: %v", err)
-	}
-
-	storageClient := tools.NewMinIOClient(minioClient)
+	// initialize object storage
+	storageClient, storageHandler := newStorageClient()
 
 	ctx := context.Background()
 	storageManager := tools.NewMinIOStorageManager(storageClient, ctx)
@@ -72,20 +72,63 @@ func main() {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 
+	// load the latest GTFS schedule, and keep it up to date with uploads through other replicas
+	scheduleStore := schedule.NewStore(storageManager)
+	if err := scheduleStore.Load(); err != nil {
+		if errors.Is(err, tools.NoGTFSScheduleFound) {
+			log.Info("No GTFS schedule uploaded yet")
+		} else {
+			log.Errorf("Failed to load GTFS schedule: %v", err)
+		}
+	}
+	scheduleCtx, scheduleCancel := context.WithCancel(context.Background())
+	go scheduleStore.Watch(scheduleCtx, scheduleRefreshInterval)
+
 	// initialize linear graphql
 	tools.InitialiseLinearGraphqlConnection()
 
-	// initialize browser
-	browserCtx, browserCancel := context.WithCancel(context.Background())
-	go tools.InitializeBrowser(browserCtx)
+	// match tracked buses to trips as their locations arrive
+	locationStore := tools.NewLocationStore(tools.DefaultLocationExpiry)
+	tracker := realtime.NewTracker(scheduleStore)
+	locationStore.OnUpdate(tracker.Update)
+
+	// stream changes to the tracked buses to clients
+	locationFeed := tools.NewLocationFeed(tools.DefaultLocationFeedSize)
+	locationStore.OnChange(locationFeed.Publish)
+
+	// keep the history of where every bus was tracked
+	historyRecorder := history.NewRecorder(storageManager)
+	locationStore.OnUpdate(historyRecorder.Record)
+	historyCtx, historyCancel := context.WithCancel(context.Background())
+	historyDone := make(chan struct{})
+	go func() {
+		historyRecorder.Run(historyCtx, historyFlushInterval)
+		close(historyDone)
+	}()
+
+	// start receiving bus locations
+	recorder := newFrameRecorder()
+	sourcesCtx, sourcesCancel := context.WithCancel(context.Background())
+	sourcesDone := make(chan struct{})
+	go func() {
+		tools.RunLocationSources(sourcesCtx, locationStore, newLocationSources(recorder)...)
+		close(sourcesDone)
+	}()
+
+	// held open responses such as the location stream end when the server shuts down
+	serverCtx, serverCancel := context.WithCancel(context.Background())
 
 	r := chi.NewRouter()
-	handlers.Handler(r, storageManager)
+	handlers.Handler(serverCtx, r, storageManager, scheduleStore, tracker, locationStore, locationFeed)
+	if storageHandler != nil {
+		r.Mount(storageHandler.path, storageHandler.handler)
+	}
 
 	srv := &http.Server{
 		Addr:    ":8090",
 		Handler: r,
 	}
+	srv.RegisterOnShutdown(serverCancel)
 
 	go func() {
 		log.Info("Starting transit-IOMAPI service...")
@@ -109,9 +152,166 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown: ", err)
+		// carry on stopping, so the history is still flushed and the recording closed
+		log.Error("Server forced to shutdown: ", err)
+	}
+	sourcesCancel()
+	select {
+	case <-sourcesDone:
+	case <-time.After(5 * time.Second):
+		log.Warn("Location sources did not stop in time")
+	}
+	// store the positions received since the last flush
+	historyCancel()
+	<-historyDone
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			log.Errorf("Failed to close frame recording: %v", err)
+		}
 	}
-	browserCancel()
+	scheduleCancel()
 	time.Sleep(100 * time.Millisecond)
 	log.Info("Server exiting")
 }
+
+type mountedHandler struct {
+	path    string
+	handler http.Handler
+}
+
+// newStorageClient creates the object storage client selected by STORAGE_BACKEND.
+// The filesystem backend serves its own presigned URLs, so it also returns the handler
+// that needs mounting on the router.
+func newStorageClient() (tools.ObjectStorageClient, *mountedHandler) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "filesystem":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "data"
+		}
+
+		publicURLStr := os.Getenv("STORAGE_PUBLIC_URL")
+		if publicURLStr == "" {
+			publicURLStr = "http://localhost:8090/storage"
+		}
+		publicURL, err := url.Parse(publicURLStr)
+		if err != nil {
+			log.Fatalf("Invalid STORAGE_PUBLIC_URL '%s': %v", publicURLStr, err)
+		}
+		if publicURL.Path == "" || publicURL.Path == "/" {
+			log.Fatalf("STORAGE_PUBLIC_URL '%s' must include a path to serve objects from", publicURLStr)
+		}
+
+		fsClient, err := tools.NewFilesystemClient(root, publicURL, []byte(os.Getenv("STORAGE_SIGNING_KEY")))
+		if err != nil {
+			log.Fatalf("Failed to create filesystem storage client: %v", err)
+		}
+		log.Infof("Using filesystem storage at %s", root)
+		return fsClient, &mountedHandler{path: publicURL.Path, handler: fsClient}
+	case "", "minio":
+		accessKey := os.Getenv("MINIO_ACCESS_KEY")
+		secretKey := os.Getenv("MINIO_SECRET_KEY")
+		endpoint := os.Getenv("MINIO_ENDPOINT")
+
+		minioClient, err := minio.New(endpoint, &minio.Options{
+			Secure: false,
+			Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		})
+		if err != nil {
+			log.Fatalf("Failed to create MinIO client: %v", err)
+		}
+
+		return tools.NewMinIOClient(minioClient), nil
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND '%s', expected 'minio' or 'filesystem'", backend)
+		return nil, nil
+	}
+}
+
+// newFrameRecorder creates a recorder writing received frames to LOCATION_RECORDING_DIR, or
+// returns nil if it is not set.
+func newFrameRecorder() *tools.FileRecorder {
+	dir := os.Getenv("LOCATION_RECORDING_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	maxSize := int64(tools.DefaultRecordingMaxSize)
+	if maxSizeStr := os.Getenv("LOCATION_RECORDING_MAX_SIZE"); maxSizeStr != "" {
+		size, err := strconv.ParseInt(maxSizeStr, 10, 64)
+		if err != nil || size <= 0 {
+			log.Fatalf("Invalid LOCATION_RECORDING_MAX_SIZE '%s', expected a positive number of bytes", maxSizeStr)
+		}
+		maxSize = size
+	}
+
+	maxFiles := tools.DefaultRecordingMaxFiles
+	if maxFilesStr := os.Getenv("LOCATION_RECORDING_MAX_FILES"); maxFilesStr != "" {
+		files, err := strconv.Atoi(maxFilesStr)
+		if err != nil || files < 0 {
+			log.Fatalf("Invalid LOCATION_RECORDING_MAX_FILES '%s', expected a number of files, or 0 to keep every file", maxFilesStr)
+		}
+		maxFiles = files
+	}
+
+	recorder, err := tools.NewFileRecorder(dir, maxSize)
+	if err != nil {
+		log.Fatalf("Failed to create frame recorder: %v", err)
+	}
+	recorder.MaxFiles = maxFiles
+	log.Infof("Recording location frames to %s", dir)
+	return recorder
+}
+
+// newLocationSources creates the bus location sources listed in LOCATION_SOURCES, recording the
+// frames of live sources if there is a recorder.
+func newLocationSources(recorder *tools.FileRecorder) []tools.LocationSource {
+	names := os.Getenv("LOCATION_SOURCES")
+	if names == "" {
+		names = "browser"
+	}
+
+	var sources []tools.LocationSource
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "browser":
+			source := tools.NewBrowserSource(tools.FindMyBusURL)
+			if recorder != nil {
+				source.Recorder = recorder
+			}
+			sources = append(sources, source)
+		case "signalr":
+			hubURL := os.Getenv("SIGNALR_HUB_URL")
+			if hubURL == "" {
+				log.Fatal("SIGNALR_HUB_URL must be set to use the signalr location source")
+			}
+			var subscriptions []string
+			if subscribe := os.Getenv("SIGNALR_SUBSCRIBE"); subscribe != "" {
+				subscriptions = strings.Split(subscribe, ",")
+			}
+			source := tools.NewSignalRSource(hubURL, subscriptions...)
+			if recorder != nil {
+				source.Recorder = recorder
+			}
+			sources = append(sources, source)
+		case "replay":
+			path := os.Getenv("REPLAY_PATH")
+			if path == "" {
+				log.Fatal("REPLAY_PATH must be set to use the replay location source")
+			}
+			speed := 1.0
+			if speedStr := os.Getenv("REPLAY_SPEED"); speedStr != "" {
+				var err error
+				if speed, err = strconv.ParseFloat(speedStr, 64); err != nil {
+					log.Fatalf("Invalid REPLAY_SPEED '%s': %v", speedStr, err)
+				}
+			}
+			source := tools.NewReplaySource(path, speed)
+			source.Loop = os.Getenv("REPLAY_LOOP") == "true"
+			sources = append(sources, source)
+		default:
+			log.Fatalf("Unknown location source '%s', expected 'browser', 'signalr' or 'replay'", name)
+		}
+	}
+	return sources
+}
@@ -0,0 +1,178 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// record is a single row of a GTFS CSV file, with values looked up by column name.
+type record struct {
+	line   int
+	values []string
+	header map[string]int
+}
+
+// get returns the trimmed value of a column, or an empty string if the column is absent.
+func (r record) get(field string) string {
+	i, ok := r.header[field]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[i])
+}
+
+// table is an open GTFS CSV file.
+type table struct {
+	name    string
+	columns []string
+	header  map[string]int
+	reader  *csv.Reader
+	closer  io.Closer
+}
+
+// openTable opens a CSV file from a GTFS zip and reads its header row.
+func openTable(f *zip.File) (*table, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+
+	reader := csv.NewReader(&bomSkipper{r: rc})
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	columns, err := reader.Read()
+	if err != nil {
+		_ = rc.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s is empty", f.Name)
+		}
+		return nil, fmt.Errorf("failed to read %s header: %w", f.Name, err)
+	}
+
+	t := &table{
+		name:    f.Name,
+		columns: make([]string, len(columns)),
+		header:  make(map[string]int, len(columns)),
+		reader:  reader,
+		closer:  rc,
+	}
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		t.columns[i] = column
+		if _, duplicate := t.header[column]; !duplicate {
+			t.header[column] = i
+		}
+	}
+	return t, nil
+}
+
+// hasColumn reports whether the table has the given column.
+func (t *table) hasColumn(column string) bool {
+	_, ok := t.header[column]
+	return ok
+}
+
+// each calls fn for every data row of the table. Rows are reused between calls,
+// so fn must copy any values it keeps. Parsing stops at the first malformed row.
+func (t *table) each(fn func(r record)) error {
+	for {
+		values, err := t.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", t.name, err)
+		}
+
+		line, _ := t.reader.FieldPos(0)
+		// skip blank lines that only contain separators
+		if len(values) == 1 && strings.TrimSpace(values[0]) == "" {
+			continue
+		}
+		fn(record{line: line, values: values, header: t.header})
+	}
+}
+
+func (t *table) Close() error {
+	return t.closer.Close()
+}
+
+// bomSkipper strips a leading UTF-8 byte order mark, which spreadsheet exports commonly add.
+type bomSkipper struct {
+	r       io.Reader
+	checked bool
+}
+
+func (b *bomSkipper) Read(p []byte) (int, error) {
+	if b.checked {
+		return b.r.Read(p)
+	}
+	b.checked = true
+
+	head := make([]byte, len(utf8BOM))
+	n, err := io.ReadFull(b.r, head)
+	head = head[:n]
+	if bytes.Equal(head, utf8BOM) {
+		head = nil
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	b.r = io.MultiReader(bytes.NewReader(head), b.r)
+	return b.r.Read(p)
+}
+
+// ParseTime parses a GTFS time in HH:MM:SS format into seconds since the start of the
+// service day (noon minus 12 hours). Hours may exceed 23 for trips that run past midnight,
+// and a single digit hour is accepted.
+func ParseTime(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || len(parts[0]) < 1 || len(parts[1]) != 2 || len(parts[2]) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM:SS", s)
+	}
+
+	var values [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid time %q, expected HH:MM:SS", s)
+		}
+		values[i] = v
+	}
+	if values[1] > 59 || values[2] > 59 {
+		return 0, fmt.Errorf("invalid time %q, minutes and seconds must be below 60", s)
+	}
+
+	return values[0]*3600 + values[1]*60 + values[2], nil
+}
+
+// FormatTime formats seconds since the start of the service day as a GTFS HH:MM:SS time.
+func FormatTime(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// ParseDate parses a GTFS date in YYYYMMDD format.
+func ParseDate(s string) (time.Time, error) {
+	if len(s) != 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYYMMDD", s)
+	}
+	d, err := time.Parse("20060102", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYYMMDD", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,168 @@
+package gtfs
+
+import (
+	"time"
+)
+
+// Feed is a parsed GTFS schedule, indexed for lookups by ID and with the references
+// between entities resolved. A Feed is never modified after it is parsed, so it is safe
+// to share between goroutines.
+type Feed struct {
+	Agencies map[string]*Agency
+	Stops    map[string]*Stop
+	Routes   map[string]*Route
+	Trips    map[string]*Trip
+	Services map[string]*Service
+	Shapes   map[string]*Shape
+
+	// StopTimesByStop lists the stop times calling at each stop, ordered by departure time
+	StopTimesByStop map[string][]*StopTime
+}
+
+type Agency struct {
+	ID       string
+	Name     string
+	URL      string
+	Timezone string
+	Lang     string
+	Phone    string
+}
+
+// Location types of a stop, as defined by the GTFS reference.
+const (
+	LocationStop = iota
+	LocationStation
+	LocationEntrance
+	LocationGenericNode
+	LocationBoardingArea
+)
+
+type Stop struct {
+	ID                 string
+	Code               string
+	Name               string
+	Desc               string
+	Lat                float64
+	Lon                float64
+	LocationType       int
+	PlatformCode       string
+	WheelchairBoarding int
+
+	// Parent is the station the stop belongs to, if any
+	Parent *Stop
+	// Children are the stops and entrances of a station
+	Children []*Stop
+	// Routes are the routes calling at the stop, ordered by route ID
+	Routes []*Route
+}
+
+type Route struct {
+	ID        string
+	Agency    *Agency
+	ShortName string
+	LongName  string
+	Desc      string
+	Type      int
+	Color     string
+	TextColor string
+	SortOrder int
+
+	// Trips are the trips of the route, ordered by departure time from their first stop
+	Trips []*Trip
+	// Patterns are the main stop patterns of the route, one per direction
+	Patterns []*Pattern
+}
+
+// Name returns the short name of the route, or its long name if it has none.
+func (r *Route) Name() string {
+	if r.ShortName != "" {
+		return r.ShortName
+	}
+	return r.LongName
+}
+
+// Pattern is an ordered list of stops served by trips of a route in one direction.
+type Pattern struct {
+	DirectionID int
+	Headsign    string
+	Stops       []*Stop
+	// TripCount is the number of trips following the pattern exactly
+	TripCount int
+}
+
+type Trip struct {
+	ID          string
+	Route       *Route
+	Service     *Service
+	Shape       *Shape
+	Headsign    string
+	ShortName   string
+	DirectionID int
+	BlockID     string
+
+	// StopTimes are the calls of the trip, ordered by stop sequence
+	StopTimes []*StopTime
+}
+
+// Departure returns the departure time of the trip from its first stop, in seconds since the
+// start of the service day.
+func (t *Trip) Departure() int {
+	if len(t.StopTimes) == 0 {
+		return 0
+	}
+	return t.StopTimes[0].Departure
+}
+
+type StopTime struct {
+	Trip         *Trip
+	Stop         *Stop
+	Sequence     int
+	Headsign     string
+	PickupType   int
+	DropOffType  int
+	DistTraveled float64
+
+	// Arrival and Departure are seconds since the start of the service day, which may exceed
+	// 24 hours for trips running past midnight
+	Arrival   int
+	Departure int
+	// Interpolated is set when the feed gave no time for the stop and it was estimated
+	// from the surrounding timed stops
+	Interpolated bool
+}
+
+// Service is a set of dates on which trips run, combining calendar.txt and calendar_dates.txt.
+// Dates are kept as YYYYMMDD strings, which sort in date order.
+type Service struct {
+	ID        string
+	Weekdays  [7]bool // indexed by time.Weekday
+	StartDate string
+	EndDate   string
+	Added     map[string]bool
+	Removed   map[string]bool
+}
+
+// ActiveOn reports whether the service runs on the calendar date of t, in t's location.
+func (s *Service) ActiveOn(t time.Time) bool {
+	date := t.Format("20060102")
+	if s.Removed[date] {
+		return false
+	}
+	if s.Added[date] {
+		return true
+	}
+	return s.Weekdays[t.Weekday()] && s.StartDate <= date && date <= s.EndDate
+}
+
+type Shape struct {
+	ID     string
+	Points []ShapePoint
+}
+
+type ShapePoint struct {
+	Lat      float64
+	Lon      float64
+	Sequence int
+	// DistTraveled is the distance along the shape given by the feed, or zero if it has none
+	DistTraveled float64
+}
@@ -0,0 +1,493 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// parser builds a Feed from the files of a GTFS zip, stopping at the first error.
+type parser struct {
+	feed  *Feed
+	files map[string]*zip.File
+	err   error
+}
+
+// Parse reads a GTFS schedule zip into a Feed. Stop times without a time are interpolated
+// from the surrounding timed stops. Parse expects a feed that passes Validate, and fails on
+// the first malformed value or broken reference rather than reporting every problem.
+func Parse(r io.ReaderAt, size int64) (*Feed, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid zip archive: %w", err)
+	}
+
+	p := &parser{
+		feed: &Feed{
+			Agencies:        make(map[string]*Agency),
+			Stops:           make(map[string]*Stop),
+			Routes:          make(map[string]*Route),
+			Trips:           make(map[string]*Trip),
+			Services:        make(map[string]*Service),
+			Shapes:          make(map[string]*Shape),
+			StopTimesByStop: make(map[string][]*StopTime),
+		},
+		files: make(map[string]*zip.File),
+	}
+	for _, f := range archive.File {
+		p.files[f.Name] = f
+	}
+
+	for _, name := range []string{"agency.txt", "stops.txt", "routes.txt", "trips.txt", "stop_times.txt"} {
+		if p.files[name] == nil {
+			return nil, fmt.Errorf("%s is missing", name)
+		}
+	}
+
+	// files are parsed in dependency order, so references can be resolved as they are read
+	p.parse("agency.txt", p.parseAgency)
+	p.parse("stops.txt", p.parseStop)
+	p.linkParentStations()
+	p.parse("routes.txt", p.parseRoute)
+	p.parse("calendar.txt", p.parseCalendar)
+	p.parse("calendar_dates.txt", p.parseCalendarDate)
+	p.parse("shapes.txt", p.parseShapePoint)
+	p.parse("trips.txt", p.parseTrip)
+	p.parse("stop_times.txt", p.parseStopTime)
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	p.index()
+	return p.feed, nil
+}
+
+// parse calls fn for every row of a file, if it is present.
+func (p *parser) parse(name string, fn func(r record) error) {
+	f := p.files[name]
+	if p.err != nil || f == nil {
+		return
+	}
+
+	t, err := openTable(f)
+	if err != nil {
+		p.err = err
+		return
+	}
+	defer func() {
+		_ = t.Close()
+	}()
+
+	err = t.each(func(r record) {
+		if p.err != nil {
+			return
+		}
+		if err := fn(r); err != nil {
+			p.err = fmt.Errorf("%s:%d: %w", name, r.line, err)
+		}
+	})
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *parser) parseAgency(r record) error {
+	agency := &Agency{
+		ID:       r.get("agency_id"),
+		Name:     r.get("agency_name"),
+		URL:      r.get("agency_url"),
+		Timezone: r.get("agency_timezone"),
+		Lang:     r.get("agency_lang"),
+		Phone:    r.get("agency_phone"),
+	}
+	p.feed.Agencies[agency.ID] = agency
+	return nil
+}
+
+func (p *parser) parseStop(r record) error {
+	stop := &Stop{
+		ID:           r.get("stop_id"),
+		Code:         r.get("stop_code"),
+		Name:         r.get("stop_name"),
+		Desc:         r.get("stop_desc"),
+		PlatformCode: r.get("platform_code"),
+	}
+
+	var err error
+	if stop.LocationType, err = optionalInt(r, "location_type"); err != nil {
+		return err
+	}
+	if stop.WheelchairBoarding, err = optionalInt(r, "wheelchair_boarding"); err != nil {
+		return err
+	}
+	if stop.Lat, err = optionalFloat(r, "stop_lat"); err != nil {
+		return err
+	}
+	if stop.Lon, err = optionalFloat(r, "stop_lon"); err != nil {
+		return err
+	}
+
+	if parent := r.get("parent_station"); parent != "" {
+		// resolved once every stop has been read, as the parent may come later in the file
+		stop.Parent = &Stop{ID: parent}
+	}
+	p.feed.Stops[stop.ID] = stop
+	return nil
+}
+
+// linkParentStations replaces the placeholder parents set while parsing stops with the real ones.
+func (p *parser) linkParentStations() {
+	if p.err != nil {
+		return
+	}
+	for _, id := range slices.Sorted(maps.Keys(p.feed.Stops)) {
+		stop := p.feed.Stops[id]
+		if stop.Parent == nil {
+			continue
+		}
+		parent, ok := p.feed.Stops[stop.Parent.ID]
+		if !ok {
+			p.err = fmt.Errorf("stops.txt: parent_station %q of stop %q does not exist", stop.Parent.ID, stop.ID)
+			return
+		}
+		stop.Parent = parent
+		parent.Children = append(parent.Children, stop)
+	}
+}
+
+func (p *parser) parseRoute(r record) error {
+	route := &Route{
+		ID:        r.get("route_id"),
+		ShortName: r.get("route_short_name"),
+		LongName:  r.get("route_long_name"),
+		Desc:      r.get("route_desc"),
+		Color:     r.get("route_color"),
+		TextColor: r.get("route_text_color"),
+	}
+
+	var err error
+	if route.Type, err = strconv.Atoi(r.get("route_type")); err != nil {
+		return fmt.Errorf("invalid route_type %q", r.get("route_type"))
+	}
+	if route.SortOrder, err = optionalInt(r, "route_sort_order"); err != nil {
+		return err
+	}
+
+	agencyID := r.get("agency_id")
+	if agency, ok := p.feed.Agencies[agencyID]; ok {
+		route.Agency = agency
+	} else if agencyID == "" && len(p.feed.Agencies) == 1 {
+		// agency_id may be omitted when the feed has a single agency
+		for _, agency := range p.feed.Agencies {
+			route.Agency = agency
+		}
+	} else {
+		return fmt.Errorf("agency_id %q does not exist", agencyID)
+	}
+
+	p.feed.Routes[route.ID] = route
+	return nil
+}
+
+// service returns the service with the given ID, creating it if it was not seen yet.
+func (p *parser) service(id string) *Service {
+	service, ok := p.feed.Services[id]
+	if !ok {
+		service = &Service{ID: id, Added: make(map[string]bool), Removed: make(map[string]bool)}
+		p.feed.Services[id] = service
+	}
+	return service
+}
+
+func (p *parser) parseCalendar(r record) error {
+	service := p.service(r.get("service_id"))
+
+	days := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+	for i, day := range days {
+		service.Weekdays[i] = r.get(day) == "1"
+	}
+
+	for _, field := range []string{"start_date", "end_date"} {
+		if _, err := ParseDate(r.get(field)); err != nil {
+			return err
+		}
+	}
+	service.StartDate = r.get("start_date")
+	service.EndDate = r.get("end_date")
+	return nil
+}
+
+func (p *parser) parseCalendarDate(r record) error {
+	service := p.service(r.get("service_id"))
+
+	date := r.get("date")
+	if _, err := ParseDate(date); err != nil {
+		return err
+	}
+
+	switch exceptionType := r.get("exception_type"); exceptionType {
+	case "1":
+		service.Added[date] = true
+	case "2":
+		service.Removed[date] = true
+	default:
+		return fmt.Errorf("invalid exception_type %q", exceptionType)
+	}
+	return nil
+}
+
+func (p *parser) parseShapePoint(r record) error {
+	id := r.get("shape_id")
+	shape, ok := p.feed.Shapes[id]
+	if !ok {
+		shape = &Shape{ID: id}
+		p.feed.Shapes[id] = shape
+	}
+
+	var point ShapePoint
+	var err error
+	if point.Lat, err = optionalFloat(r, "shape_pt_lat"); err != nil {
+		return err
+	}
+	if point.Lon, err = optionalFloat(r, "shape_pt_lon"); err != nil {
+		return err
+	}
+	if point.Sequence, err = strconv.Atoi(r.get("shape_pt_sequence")); err != nil {
+		return fmt.Errorf("invalid shape_pt_sequence %q", r.get("shape_pt_sequence"))
+	}
+	if point.DistTraveled, err = optionalFloat(r, "shape_dist_traveled"); err != nil {
+		return err
+	}
+
+	shape.Points = append(shape.Points, point)
+	return nil
+}
+
+func (p *parser) parseTrip(r record) error {
+	trip := &Trip{
+		ID:        r.get("trip_id"),
+		Headsign:  r.get("trip_headsign"),
+		ShortName: r.get("trip_short_name"),
+		BlockID:   r.get("block_id"),
+	}
+
+	var err error
+	if trip.DirectionID, err = optionalInt(r, "direction_id"); err != nil {
+		return err
+	}
+
+	var ok bool
+	if trip.Route, ok = p.feed.Routes[r.get("route_id")]; !ok {
+		return fmt.Errorf("route_id %q does not exist", r.get("route_id"))
+	}
+	if trip.Service, ok = p.feed.Services[r.get("service_id")]; !ok {
+		return fmt.Errorf("service_id %q does not exist", r.get("service_id"))
+	}
+	if shapeID := r.get("shape_id"); shapeID != "" {
+		if trip.Shape, ok = p.feed.Shapes[shapeID]; !ok {
+			return fmt.Errorf("shape_id %q does not exist", shapeID)
+		}
+	}
+
+	p.feed.Trips[trip.ID] = trip
+	return nil
+}
+
+func (p *parser) parseStopTime(r record) error {
+	stopTime := &StopTime{Headsign: r.get("stop_headsign")}
+
+	var ok bool
+	if stopTime.Trip, ok = p.feed.Trips[r.get("trip_id")]; !ok {
+		return fmt.Errorf("trip_id %q does not exist", r.get("trip_id"))
+	}
+	if stopTime.Stop, ok = p.feed.Stops[r.get("stop_id")]; !ok {
+		return fmt.Errorf("stop_id %q does not exist", r.get("stop_id"))
+	}
+
+	var err error
+	if stopTime.Sequence, err = strconv.Atoi(r.get("stop_sequence")); err != nil {
+		return fmt.Errorf("invalid stop_sequence %q", r.get("stop_sequence"))
+	}
+	if stopTime.PickupType, err = optionalInt(r, "pickup_type"); err != nil {
+		return err
+	}
+	if stopTime.DropOffType, err = optionalInt(r, "drop_off_type"); err != nil {
+		return err
+	}
+	if stopTime.DistTraveled, err = optionalFloat(r, "shape_dist_traveled"); err != nil {
+		return err
+	}
+	if stopTime.Arrival, err = optionalTime(r, "arrival_time"); err != nil {
+		return err
+	}
+	if stopTime.Departure, err = optionalTime(r, "departure_time"); err != nil {
+		return err
+	}
+
+	// a stop with only one of its times set arrives and departs at the same time
+	if stopTime.Arrival < 0 {
+		stopTime.Arrival = stopTime.Departure
+	}
+	if stopTime.Departure < 0 {
+		stopTime.Departure = stopTime.Arrival
+	}
+
+	stopTime.Trip.StopTimes = append(stopTime.Trip.StopTimes, stopTime)
+	return nil
+}
+
+// index sorts the parsed entities and builds the lookup indexes of the feed.
+func (p *parser) index() {
+	for _, shape := range p.feed.Shapes {
+		slices.SortFunc(shape.Points, func(a, b ShapePoint) int { return cmp.Compare(a.Sequence, b.Sequence) })
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(p.feed.Trips)) {
+		trip := p.feed.Trips[id]
+		slices.SortFunc(trip.StopTimes, func(a, b *StopTime) int { return cmp.Compare(a.Sequence, b.Sequence) })
+		interpolate(trip.StopTimes)
+
+		trip.Route.Trips = append(trip.Route.Trips, trip)
+		for _, stopTime := range trip.StopTimes {
+			p.feed.StopTimesByStop[stopTime.Stop.ID] = append(p.feed.StopTimesByStop[stopTime.Stop.ID], stopTime)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(p.feed.Routes)) {
+		route := p.feed.Routes[id]
+		slices.SortStableFunc(route.Trips, func(a, b *Trip) int { return cmp.Compare(a.Departure(), b.Departure()) })
+		route.Patterns = patterns(route.Trips)
+
+		served := make(map[string]bool)
+		for _, trip := range route.Trips {
+			for _, stopTime := range trip.StopTimes {
+				if !served[stopTime.Stop.ID] {
+					served[stopTime.Stop.ID] = true
+					stopTime.Stop.Routes = append(stopTime.Stop.Routes, route)
+				}
+			}
+		}
+	}
+	for _, stopTimes := range p.feed.StopTimesByStop {
+		slices.SortStableFunc(stopTimes, func(a, b *StopTime) int { return cmp.Compare(a.Departure, b.Departure) })
+	}
+}
+
+// patterns picks the main stop pattern of each direction of a route: the one followed by the
+// most trips, preferring the one with more stops on a tie so short workings do not hide the full route.
+func patterns(trips []*Trip) []*Pattern {
+	type candidate struct {
+		pattern *Pattern
+		first   int
+	}
+	candidates := make(map[string]*candidate)
+	for i, trip := range trips {
+		key := strconv.Itoa(trip.DirectionID)
+		for _, stopTime := range trip.StopTimes {
+			key += "\x00" + stopTime.Stop.ID
+		}
+		c, ok := candidates[key]
+		if !ok {
+			stops := make([]*Stop, len(trip.StopTimes))
+			for j, stopTime := range trip.StopTimes {
+				stops[j] = stopTime.Stop
+			}
+			c = &candidate{pattern: &Pattern{DirectionID: trip.DirectionID, Headsign: trip.Headsign, Stops: stops}, first: i}
+			candidates[key] = c
+		}
+		c.pattern.TripCount++
+	}
+
+	best := make(map[int]*candidate)
+	for _, c := range candidates {
+		current, ok := best[c.pattern.DirectionID]
+		if !ok || cmp.Or(
+			cmp.Compare(c.pattern.TripCount, current.pattern.TripCount),
+			cmp.Compare(len(c.pattern.Stops), len(current.pattern.Stops)),
+			cmp.Compare(current.first, c.first),
+		) > 0 {
+			best[c.pattern.DirectionID] = c
+		}
+	}
+
+	result := make([]*Pattern, 0, len(best))
+	for _, directionID := range slices.Sorted(maps.Keys(best)) {
+		result = append(result, best[directionID].pattern)
+	}
+	return result
+}
+
+// interpolate fills in the times of untimed stops between two timed stops, in proportion to
+// the distance travelled when the feed provides it and evenly between the stops otherwise.
+// Untimed stops before the first or after the last timed stop take the nearest time.
+func interpolate(stopTimes []*StopTime) {
+	first, previous := -1, -1
+	for i, stopTime := range stopTimes {
+		if stopTime.Arrival < 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if previous >= 0 && i-previous > 1 {
+			from, to := stopTimes[previous], stopTime
+			distance := to.DistTraveled - from.DistTraveled
+			for j := previous + 1; j < i; j++ {
+				fraction := float64(j-previous) / float64(i-previous)
+				if distance > 0 && stopTimes[j].DistTraveled > 0 {
+					fraction = (stopTimes[j].DistTraveled - from.DistTraveled) / distance
+				}
+				seconds := from.Departure + int(fraction*float64(to.Arrival-from.Departure))
+				stopTimes[j].Arrival, stopTimes[j].Departure, stopTimes[j].Interpolated = seconds, seconds, true
+			}
+		}
+		previous = i
+	}
+
+	if previous < 0 {
+		return
+	}
+	for _, stopTime := range stopTimes[:first] {
+		stopTime.Arrival, stopTime.Departure, stopTime.Interpolated = stopTimes[first].Arrival, stopTimes[first].Arrival, true
+	}
+	for _, stopTime := range stopTimes[previous+1:] {
+		stopTime.Arrival, stopTime.Departure, stopTime.Interpolated = stopTimes[previous].Departure, stopTimes[previous].Departure, true
+	}
+}
+
+func optionalInt(r record, field string) (int, error) {
+	value := r.get(field)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return i, nil
+}
+
+func optionalFloat(r record, field string) (float64, error) {
+	value := r.get(field)
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return f, nil
+}
+
+// optionalTime parses a GTFS time field, returning -1 if it is empty.
+func optionalTime(r record, field string) (int, error) {
+	value := r.get(field)
+	if value == "" {
+		return -1, nil
+	}
+	return ParseTime(value)
+}
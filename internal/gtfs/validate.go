@@ -0,0 +1,581 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// maxReportedIssues caps how many errors and warnings are listed in a report,
+// so a badly broken feed does not produce a response of millions of entries.
+const maxReportedIssues = 100
+
+// Issue is a single problem found while validating a GTFS feed.
+type Issue struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	switch {
+	case i.File == "":
+		return i.Message
+	case i.Line == 0:
+		return fmt.Sprintf("%s: %s", i.File, i.Message)
+	default:
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	}
+}
+
+// ValidationReport lists the problems found in a GTFS feed.
+// Errors make the feed unusable, while warnings point at likely mistakes.
+// Only the first issues of each kind are listed, but all of them are counted.
+type ValidationReport struct {
+	Errors       []Issue `json:"errors"`
+	Warnings     []Issue `json:"warnings"`
+	ErrorCount   int     `json:"errorCount"`
+	WarningCount int     `json:"warningCount"`
+}
+
+// Valid reports whether the feed has no errors.
+func (v *ValidationReport) Valid() bool {
+	return v.ErrorCount == 0
+}
+
+func (v *ValidationReport) addError(issue Issue) {
+	v.ErrorCount++
+	if len(v.Errors) < maxReportedIssues {
+		v.Errors = append(v.Errors, issue)
+	}
+}
+
+func (v *ValidationReport) addWarning(issue Issue) {
+	v.WarningCount++
+	if len(v.Warnings) < maxReportedIssues {
+		v.Warnings = append(v.Warnings, issue)
+	}
+}
+
+// knownFiles are the files defined by the GTFS schedule reference that Mercury recognises.
+var knownFiles = map[string]bool{
+	"agency.txt": true, "stops.txt": true, "routes.txt": true, "trips.txt": true,
+	"stop_times.txt": true, "calendar.txt": true, "calendar_dates.txt": true,
+	"fare_attributes.txt": true, "fare_rules.txt": true, "timeframes.txt": true,
+	"fare_media.txt": true, "fare_products.txt": true, "fare_leg_rules.txt": true,
+	"fare_leg_join_rules.txt": true, "fare_transfer_rules.txt": true, "areas.txt": true,
+	"stop_areas.txt": true, "networks.txt": true, "route_networks.txt": true,
+	"shapes.txt": true, "frequencies.txt": true, "transfers.txt": true,
+	"pathways.txt": true, "levels.txt": true, "location_groups.txt": true,
+	"location_group_stops.txt": true, "locations.geojson": true, "booking_rules.txt": true,
+	"translations.txt": true, "feed_info.txt": true, "attributions.txt": true,
+}
+
+// requiredColumns lists the columns that must be present in the header of each validated file.
+var requiredColumns = map[string][]string{
+	"agency.txt":         {"agency_name", "agency_url", "agency_timezone"},
+	"stops.txt":          {"stop_id"},
+	"routes.txt":         {"route_id", "route_type"},
+	"trips.txt":          {"route_id", "service_id", "trip_id"},
+	"stop_times.txt":     {"trip_id", "stop_id", "stop_sequence"},
+	"calendar.txt":       {"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"},
+	"calendar_dates.txt": {"service_id", "date", "exception_type"},
+	"shapes.txt":         {"shape_id", "shape_pt_lat", "shape_pt_lon", "shape_pt_sequence"},
+}
+
+// validator holds the state shared between the checks of each file.
+type validator struct {
+	report *ValidationReport
+	files  map[string]*zip.File
+
+	agencyCount    int
+	agencyIDs      map[string]bool
+	blankAgencies  []int          // lines of agencies without an agency_id
+	stopIDs        map[string]int // stop ID to location type
+	routeIDs       map[string]bool
+	serviceIDs     map[string]bool
+	shapeIDs       map[string]bool
+	tripIDs        map[string]bool
+	usedStops      map[string]bool
+	usedRoutes     map[string]bool
+	tripsWithTimes map[string]bool
+	parentStations []reference
+}
+
+// reference is an ID referenced from a line of a file, to be resolved later.
+type reference struct {
+	line int
+	id   string
+}
+
+// Validate checks that a zip archive is a usable GTFS schedule feed. It checks that the
+// required files are present with their required columns and fields, that values such as
+// times, dates and coordinates are well formed, and that trips, routes, stops and services
+// reference each other correctly.
+func Validate(r io.ReaderAt, size int64) *ValidationReport {
+	report := &ValidationReport{Errors: []Issue{}, Warnings: []Issue{}}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		report.addError(Issue{Message: fmt.Sprintf("not a valid zip archive: %v", err)})
+		return report
+	}
+
+	v := &validator{
+		report:         report,
+		files:          make(map[string]*zip.File),
+		agencyIDs:      make(map[string]bool),
+		stopIDs:        make(map[string]int),
+		routeIDs:       make(map[string]bool),
+		serviceIDs:     make(map[string]bool),
+		shapeIDs:       make(map[string]bool),
+		tripIDs:        make(map[string]bool),
+		usedStops:      make(map[string]bool),
+		usedRoutes:     make(map[string]bool),
+		tripsWithTimes: make(map[string]bool),
+	}
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !knownFiles[f.Name] {
+			v.warn(f.Name, 0, "", "unrecognised file, files must be at the root of the archive")
+			continue
+		}
+		v.files[f.Name] = f
+	}
+
+	for _, name := range []string{"agency.txt", "stops.txt", "routes.txt", "trips.txt", "stop_times.txt"} {
+		if v.files[name] == nil {
+			v.fail(name, 0, "", "required file is missing")
+		}
+	}
+	if v.files["calendar.txt"] == nil && v.files["calendar_dates.txt"] == nil {
+		v.fail("calendar.txt", 0, "", "either calendar.txt or calendar_dates.txt is required")
+	}
+
+	// files are checked in dependency order, so references can be resolved as they are read
+	v.check("agency.txt", v.checkAgency)
+	v.checkAgencyIDs()
+	v.check("stops.txt", v.checkStop)
+	v.checkParentStations()
+	v.check("routes.txt", v.checkRoute)
+	v.check("calendar.txt", v.checkCalendar)
+	v.check("calendar_dates.txt", v.checkCalendarDate)
+	v.check("shapes.txt", v.checkShape)
+	v.check("trips.txt", v.checkTrip)
+	v.checkStopTimes()
+	v.checkUnused()
+
+	return report
+}
+
+func (v *validator) fail(file string, line int, field string, format string, args ...any) {
+	v.report.addError(Issue{File: file, Line: line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warn(file string, line int, field string, format string, args ...any) {
+	v.report.addWarning(Issue{File: file, Line: line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// check opens a file if it is present, verifies its header and calls fn for every row.
+func (v *validator) check(name string, fn func(t *table, r record)) {
+	f := v.files[name]
+	if f == nil {
+		return
+	}
+
+	t, err := openTable(f)
+	if err != nil {
+		v.fail(name, 0, "", "%v", err)
+		return
+	}
+	defer func() {
+		_ = t.Close()
+	}()
+
+	missing := false
+	for _, column := range requiredColumns[name] {
+		if !t.hasColumn(column) {
+			v.fail(name, 1, column, "required column %s is missing", column)
+			missing = true
+		}
+	}
+	if missing {
+		return
+	}
+
+	err = t.each(func(r record) {
+		if len(r.values) != len(t.columns) {
+			v.fail(name, r.line, "", "row has %d values but the header has %d columns", len(r.values), len(t.columns))
+			return
+		}
+		fn(t, r)
+	})
+	if err != nil {
+		v.fail(name, 0, "", "%v", err)
+	}
+}
+
+// require reports an error if a field is empty and returns its value.
+func (v *validator) require(t *table, r record, field string) string {
+	value := r.get(field)
+	if value == "" {
+		v.fail(t.name, r.line, field, "required field %s is empty", field)
+	}
+	return value
+}
+
+// unique reports an error if id has been seen before in the same file.
+func (v *validator) unique(t *table, r record, field, id string, seen map[string]bool) {
+	if id == "" {
+		return
+	}
+	if seen[id] {
+		v.fail(t.name, r.line, field, "duplicate %s %q", field, id)
+	}
+}
+
+// enum reports an error if a non-empty field is not one of the allowed integer values.
+func (v *validator) enum(t *table, r record, field string, allowed ...int) {
+	value := r.get(field)
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err == nil {
+		for _, a := range allowed {
+			if n == a {
+				return
+			}
+		}
+	}
+	v.fail(t.name, r.line, field, "invalid %s %q", field, value)
+}
+
+func (v *validator) coordinate(t *table, r record, field string, limit float64) {
+	value := r.get(field)
+	if value == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < -limit || f > limit {
+		v.fail(t.name, r.line, field, "invalid coordinate %s %q", field, value)
+	}
+}
+
+func (v *validator) date(t *table, r record, field string) (time.Time, bool) {
+	value := v.require(t, r, field)
+	if value == "" {
+		return time.Time{}, false
+	}
+	d, err := ParseDate(value)
+	if err != nil {
+		v.fail(t.name, r.line, field, "%v", err)
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+func (v *validator) checkAgency(t *table, r record) {
+	v.agencyCount++
+	id := r.get("agency_id")
+	v.unique(t, r, "agency_id", id, v.agencyIDs)
+	if id == "" {
+		v.blankAgencies = append(v.blankAgencies, r.line)
+	} else {
+		v.agencyIDs[id] = true
+	}
+
+	v.require(t, r, "agency_name")
+	if agencyURL := v.require(t, r, "agency_url"); agencyURL != "" {
+		if u, err := url.Parse(agencyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			v.fail(t.name, r.line, "agency_url", "invalid URL %q", agencyURL)
+		}
+	}
+	if tz := v.require(t, r, "agency_timezone"); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			v.fail(t.name, r.line, "agency_timezone", "unknown timezone %q", tz)
+		}
+	}
+}
+
+// checkAgencyIDs verifies agency_id is set on every agency once they are all counted, as it may
+// only be left out when there is a single one.
+func (v *validator) checkAgencyIDs() {
+	if v.files["agency.txt"] == nil {
+		return
+	}
+	if v.agencyCount == 0 {
+		v.fail("agency.txt", 0, "", "at least one agency is required")
+	}
+	if v.agencyCount > 1 {
+		for _, line := range v.blankAgencies {
+			v.fail("agency.txt", line, "agency_id", "agency_id is required when there is more than one agency")
+		}
+	}
+}
+
+func (v *validator) checkStop(t *table, r record) {
+	id := v.require(t, r, "stop_id")
+	if _, seen := v.stopIDs[id]; seen && id != "" {
+		v.fail(t.name, r.line, "stop_id", "duplicate stop_id %q", id)
+	}
+
+	v.enum(t, r, "location_type", 0, 1, 2, 3, 4)
+	locationType, _ := strconv.Atoi(r.get("location_type"))
+	v.stopIDs[id] = locationType
+
+	// stops, stations and entrances must be locatable, generic nodes and boarding areas need not be
+	if locationType <= 2 {
+		v.require(t, r, "stop_name")
+		v.require(t, r, "stop_lat")
+		v.require(t, r, "stop_lon")
+	}
+	v.coordinate(t, r, "stop_lat", 90)
+	v.coordinate(t, r, "stop_lon", 180)
+	v.enum(t, r, "wheelchair_boarding", 0, 1, 2)
+
+	if parent := r.get("parent_station"); parent != "" {
+		v.parentStations = append(v.parentStations, reference{line: r.line, id: parent})
+	}
+}
+
+// checkParentStations verifies parent_station references once every stop is known,
+// as a stop may be listed before its parent.
+func (v *validator) checkParentStations() {
+	for _, ref := range v.parentStations {
+		if _, ok := v.stopIDs[ref.id]; !ok {
+			v.fail("stops.txt", ref.line, "parent_station", "parent_station %q does not exist in stops.txt", ref.id)
+		}
+	}
+}
+
+func (v *validator) checkRoute(t *table, r record) {
+	id := v.require(t, r, "route_id")
+	v.unique(t, r, "route_id", id, v.routeIDs)
+	v.routeIDs[id] = true
+
+	if r.get("route_short_name") == "" && r.get("route_long_name") == "" {
+		v.fail(t.name, r.line, "route_short_name", "either route_short_name or route_long_name is required")
+	}
+
+	// agency_id may only be omitted when the feed has a single agency
+	agencyID := r.get("agency_id")
+	switch {
+	case v.files["agency.txt"] == nil || v.agencyIDs[agencyID]:
+		// a missing agency.txt is reported on its own
+	case agencyID == "" && v.agencyCount > 1:
+		v.fail(t.name, r.line, "agency_id", "agency_id is required when there is more than one agency")
+	case agencyID != "" || v.agencyCount == 0:
+		v.fail(t.name, r.line, "agency_id", "agency_id %q does not exist in agency.txt", agencyID)
+	}
+
+	routeType := v.require(t, r, "route_type")
+	if n, err := strconv.Atoi(routeType); routeType != "" && (err != nil || !validRouteType(n)) {
+		v.fail(t.name, r.line, "route_type", "invalid route_type %q", routeType)
+	}
+}
+
+// validRouteType accepts the basic GTFS route types and the extended (Google) route types.
+func validRouteType(n int) bool {
+	return (n >= 0 && n <= 7) || n == 11 || n == 12 || (n >= 100 && n <= 1799)
+}
+
+func (v *validator) checkCalendar(t *table, r record) {
+	id := v.require(t, r, "service_id")
+	v.unique(t, r, "service_id", id, v.serviceIDs)
+	v.serviceIDs[id] = true
+
+	for _, day := range []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"} {
+		v.require(t, r, day)
+		v.enum(t, r, day, 0, 1)
+	}
+
+	start, startOK := v.date(t, r, "start_date")
+	end, endOK := v.date(t, r, "end_date")
+	if startOK && endOK && end.Before(start) {
+		v.fail(t.name, r.line, "end_date", "end_date is before start_date")
+	}
+}
+
+func (v *validator) checkCalendarDate(t *table, r record) {
+	id := v.require(t, r, "service_id")
+	v.serviceIDs[id] = true
+	v.date(t, r, "date")
+	v.require(t, r, "exception_type")
+	v.enum(t, r, "exception_type", 1, 2)
+}
+
+func (v *validator) checkShape(t *table, r record) {
+	id := v.require(t, r, "shape_id")
+	v.shapeIDs[id] = true
+	v.require(t, r, "shape_pt_lat")
+	v.require(t, r, "shape_pt_lon")
+	v.coordinate(t, r, "shape_pt_lat", 90)
+	v.coordinate(t, r, "shape_pt_lon", 180)
+	if seq := v.require(t, r, "shape_pt_sequence"); seq != "" {
+		if n, err := strconv.Atoi(seq); err != nil || n < 0 {
+			v.fail(t.name, r.line, "shape_pt_sequence", "invalid shape_pt_sequence %q", seq)
+		}
+	}
+}
+
+func (v *validator) checkTrip(t *table, r record) {
+	id := v.require(t, r, "trip_id")
+	v.unique(t, r, "trip_id", id, v.tripIDs)
+	v.tripIDs[id] = true
+
+	if routeID := v.require(t, r, "route_id"); routeID != "" {
+		v.usedRoutes[routeID] = true
+		if v.files["routes.txt"] != nil && !v.routeIDs[routeID] {
+			v.fail(t.name, r.line, "route_id", "route_id %q does not exist in routes.txt", routeID)
+		}
+	}
+	if serviceID := v.require(t, r, "service_id"); serviceID != "" && !v.serviceIDs[serviceID] {
+		v.fail(t.name, r.line, "service_id", "service_id %q does not exist in calendar.txt or calendar_dates.txt", serviceID)
+	}
+	if shapeID := r.get("shape_id"); shapeID != "" && !v.shapeIDs[shapeID] {
+		v.fail(t.name, r.line, "shape_id", "shape_id %q does not exist in shapes.txt", shapeID)
+	}
+	v.enum(t, r, "direction_id", 0, 1)
+	v.enum(t, r, "wheelchair_accessible", 0, 1, 2)
+	v.enum(t, r, "bikes_allowed", 0, 1, 2)
+}
+
+// stopTimeEntry is the part of a stop time needed to check a trip once all its stops are read,
+// as stop_times.txt does not have to be sorted.
+type stopTimeEntry struct {
+	line      int
+	sequence  int
+	arrival   int
+	departure int
+}
+
+func (v *validator) checkStopTimes() {
+	trips := make(map[string][]stopTimeEntry)
+
+	v.check("stop_times.txt", func(t *table, r record) {
+		tripID := v.require(t, r, "trip_id")
+		if tripID != "" && v.files["trips.txt"] != nil && !v.tripIDs[tripID] {
+			v.fail(t.name, r.line, "trip_id", "trip_id %q does not exist in trips.txt", tripID)
+		}
+
+		if stopID := v.require(t, r, "stop_id"); stopID != "" {
+			v.usedStops[stopID] = true
+			locationType, ok := v.stopIDs[stopID]
+			switch {
+			case !ok && v.files["stops.txt"] != nil:
+				v.fail(t.name, r.line, "stop_id", "stop_id %q does not exist in stops.txt", stopID)
+			case ok && locationType != 0:
+				v.fail(t.name, r.line, "stop_id", "stop_id %q is not a stop or platform (location_type %d)", stopID, locationType)
+			}
+		}
+
+		arrival := v.stopTime(t, r, "arrival_time")
+		departure := v.stopTime(t, r, "departure_time")
+		if arrival >= 0 && departure >= 0 && departure < arrival {
+			v.fail(t.name, r.line, "departure_time", "departure_time is before arrival_time")
+		}
+
+		v.enum(t, r, "pickup_type", 0, 1, 2, 3)
+		v.enum(t, r, "drop_off_type", 0, 1, 2, 3)
+		v.enum(t, r, "timepoint", 0, 1)
+
+		sequenceStr := v.require(t, r, "stop_sequence")
+		sequence, err := strconv.Atoi(sequenceStr)
+		if err != nil || sequence < 0 {
+			if sequenceStr != "" {
+				v.fail(t.name, r.line, "stop_sequence", "invalid stop_sequence %q", sequenceStr)
+			}
+			return
+		}
+
+		if tripID != "" {
+			v.tripsWithTimes[tripID] = true
+			trips[tripID] = append(trips[tripID], stopTimeEntry{line: r.line, sequence: sequence, arrival: arrival, departure: departure})
+		}
+	})
+
+	for _, tripID := range slices.Sorted(maps.Keys(trips)) {
+		v.checkTripStopTimes(tripID, trips[tripID])
+	}
+}
+
+// checkTripStopTimes checks the ordering and timing of all stops of a trip.
+func (v *validator) checkTripStopTimes(tripID string, entries []stopTimeEntry) {
+	slices.SortStableFunc(entries, func(a, b stopTimeEntry) int { return a.sequence - b.sequence })
+
+	first, last := entries[0], entries[len(entries)-1]
+	if first.arrival < 0 && first.departure < 0 {
+		v.fail("stop_times.txt", first.line, "arrival_time", "the first stop of trip %q must have an arrival or departure time", tripID)
+	}
+	if len(entries) > 1 && last.arrival < 0 && last.departure < 0 {
+		v.fail("stop_times.txt", last.line, "arrival_time", "the last stop of trip %q must have an arrival or departure time", tripID)
+	}
+
+	previousTime := -1
+	for i, e := range entries {
+		if i > 0 && e.sequence == entries[i-1].sequence {
+			v.fail("stop_times.txt", e.line, "stop_sequence", "duplicate stop_sequence %d in trip %q", e.sequence, tripID)
+		}
+
+		arrival, departure := e.arrival, e.departure
+		if arrival < 0 {
+			arrival = departure
+		}
+		if departure < 0 {
+			departure = arrival
+		}
+		if arrival < 0 {
+			continue
+		}
+		if arrival < previousTime {
+			v.fail("stop_times.txt", e.line, "arrival_time", "time travels backwards along trip %q", tripID)
+		}
+		previousTime = departure
+	}
+}
+
+// stopTime validates an optional time field, returning -1 if it is empty or invalid.
+func (v *validator) stopTime(t *table, r record, field string) int {
+	value := r.get(field)
+	if value == "" {
+		return -1
+	}
+	seconds, err := ParseTime(value)
+	if err != nil {
+		v.fail(t.name, r.line, field, "%v", err)
+		return -1
+	}
+	return seconds
+}
+
+// checkUnused warns about entities that are defined but never referenced.
+func (v *validator) checkUnused() {
+	if v.files["stop_times.txt"] == nil {
+		return
+	}
+	for _, tripID := range slices.Sorted(maps.Keys(v.tripIDs)) {
+		if tripID != "" && !v.tripsWithTimes[tripID] {
+			v.warn("trips.txt", 0, "trip_id", "trip %q has no stop times", tripID)
+		}
+	}
+	for _, routeID := range slices.Sorted(maps.Keys(v.routeIDs)) {
+		if routeID != "" && !v.usedRoutes[routeID] {
+			v.warn("routes.txt", 0, "route_id", "route %q has no trips", routeID)
+		}
+	}
+	for _, stopID := range slices.Sorted(maps.Keys(v.stopIDs)) {
+		if stopID != "" && v.stopIDs[stopID] == 0 && !v.usedStops[stopID] {
+			v.warn("stops.txt", 0, "stop_id", "stop %q is not served by any trip", stopID)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package gtfsrt
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+const (
+	// alertDuration is how long after it was posted a message is active as an alert
+	alertDuration = 24 * time.Hour
+
+	// maxHeaderLength is the length in characters beyond which the header of an alert is cut short
+	maxHeaderLength = 80
+
+	alertLanguage = "en"
+)
+
+var (
+	// routeMention matches routes named in a message, such as "route 3" or "services 1, 2 and X3"
+	routeMention       = regexp.MustCompile(`(?i)\b(?:routes?|services?|bus(?:es)?)\s+([a-z]?[0-9]\w*(?:\s*(?:,|/|&|\band\b|\bor\b)\s*[a-z]?[0-9]\w*)*)`)
+	routeListSeparator = regexp.MustCompile(`(?i)\s*(?:,|/|&|\band\b|\bor\b)\s*`)
+
+	// stopMention matches stops named by their code in a message, such as "stop 1002"
+	stopMention = regexp.MustCompile(`(?i)\bstops?\s+(?:code\s+)?([0-9]+)\b`)
+)
+
+// Alerts builds a feed with an alert for every message that is active at now or later. A message
+// without an active window is active for alertDuration after it was posted or, if it only has a
+// start, after that start.
+//
+// Messages scoped to routes or stops inform those. Otherwise the routes and stops a message names
+// in its text are looked up in the schedule, and an alert that names none informs every agency of
+// the schedule.
+func Alerts(messages []tools.MessageLog, sched *schedule.Schedule, now time.Time) *FeedMessage {
+	feed := newFeed(now)
+	for _, message := range messages {
+		posted := message.Time()
+		if posted.IsZero() || posted.After(now) {
+			continue
+		}
+		period := activePeriod(message, posted)
+		if period.End != 0 && period.End <= unixTime(now) {
+			continue
+		}
+
+		alert := &Alert{
+			ActivePeriod:    []TimeRange{period},
+			InformedEntity:  informedEntities(message, sched),
+			HeaderText:      translatedString(alertHeader(message.Message)),
+			DescriptionText: translatedString(strings.TrimSpace(message.Message)),
+		}
+		alert.Cause, _ = ParseCause(message.Cause)
+		alert.Effect, _ = ParseEffect(message.Effect)
+		alert.SeverityLevel, _ = ParseSeverityLevel(message.Severity)
+
+		feed.Entity = append(feed.Entity, FeedEntity{ID: message.MessageID(), Alert: alert})
+	}
+	return feed
+}
+
+func activePeriod(message tools.MessageLog, posted time.Time) TimeRange {
+	start := posted
+	if from, err := time.Parse(time.RFC3339, message.ActiveFrom); err == nil {
+		start = from
+	}
+	end := start.Add(alertDuration)
+	if until, err := time.Parse(time.RFC3339, message.ActiveUntil); err == nil {
+		end = until
+	}
+	return TimeRange{Start: unixTime(start), End: unixTime(end)}
+}
+
+func translatedString(text string) *TranslatedString {
+	return &TranslatedString{Translation: []Translation{{Text: text, Language: alertLanguage}}}
+}
+
+// alertHeader returns the first line of a message, cut short to its first sentence or, failing
+// that, to the last word that fits in maxHeaderLength.
+func alertHeader(message string) string {
+	header, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	header = strings.TrimSpace(header)
+	if utf8.RuneCountInString(header) <= maxHeaderLength {
+		return header
+	}
+
+	if end := strings.Index(header, ". "); end > 0 && utf8.RuneCountInString(header[:end+1]) <= maxHeaderLength {
+		return header[:end+1]
+	}
+
+	runes := []rune(header)[:maxHeaderLength-1]
+	if space := strings.LastIndexFunc(string(runes), unicode.IsSpace); space > 0 {
+		return strings.TrimSpace(string(runes)[:space]) + "…"
+	}
+	return string(runes) + "…"
+}
+
+// informedEntities returns the routes and stops a message is scoped to or, failing that, those
+// named in its text, or every agency of the schedule if it names none, or every route if the
+// agency has no ID. Without a schedule the text cannot be looked up, so only the scope of the
+// message is returned.
+func informedEntities(message tools.MessageLog, sched *schedule.Schedule) []EntitySelector {
+	var entities []EntitySelector
+	seen := make(map[EntitySelector]bool)
+	add := func(entity EntitySelector) {
+		if !seen[entity] {
+			seen[entity] = true
+			entities = append(entities, entity)
+		}
+	}
+
+	for _, routeID := range message.RouteIDs {
+		add(EntitySelector{RouteID: routeID})
+	}
+	for _, stopID := range message.StopIDs {
+		add(EntitySelector{StopID: stopID})
+	}
+	if len(entities) > 0 || sched == nil {
+		return entities
+	}
+
+	text := message.Message
+	for _, match := range routeMention.FindAllStringSubmatch(text, -1) {
+		for _, number := range routeListSeparator.Split(match[1], -1) {
+			for _, route := range sched.RoutesByNumber(number) {
+				add(EntitySelector{RouteID: route.ID})
+			}
+		}
+	}
+
+	codes := make(map[string]bool)
+	for _, match := range stopMention.FindAllStringSubmatch(text, -1) {
+		codes[match[1]] = true
+	}
+	for _, id := range slices.Sorted(maps.Keys(sched.Stops)) {
+		stop := sched.Stops[id]
+		if stop.LocationType != gtfs.LocationStop && stop.LocationType != gtfs.LocationStation {
+			continue
+		}
+		if (stop.Code != "" && codes[stop.Code]) || containsName(text, stop.Name) {
+			add(EntitySelector{StopID: stop.ID})
+		}
+	}
+
+	if len(entities) == 0 {
+		for _, id := range slices.Sorted(maps.Keys(sched.Agencies)) {
+			// the only agency of a feed may leave its ID out, and a selector has to set a field
+			if id != "" {
+				add(EntitySelector{AgencyID: id})
+			}
+		}
+	}
+	if len(entities) == 0 {
+		for _, id := range slices.Sorted(maps.Keys(sched.Routes)) {
+			add(EntitySelector{RouteID: id})
+		}
+	}
+	return entities
+}
+
+// containsName reports whether text contains a name as whole words, ignoring case. Names shorter
+// than four characters are too likely to match by chance and never match.
+func containsName(text, name string) bool {
+	if utf8.RuneCountInString(name) < 4 {
+		return false
+	}
+	text, name = strings.ToLower(text), strings.ToLower(name)
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], name)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(name)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,429 @@
+package gtfsrt
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Version is the GTFS Realtime specification version the feeds follow.
+const Version = "2.0"
+
+type Incrementality int32
+
+const (
+	FullDataset  Incrementality = 0
+	Differential Incrementality = 1
+)
+
+var incrementalityNames = []string{"FULL_DATASET", "DIFFERENTIAL"}
+
+func (i Incrementality) MarshalText() ([]byte, error) {
+	return marshalEnum(incrementalityNames, i)
+}
+
+func (i *Incrementality) UnmarshalText(text []byte) error {
+	return unmarshalEnum(incrementalityNames, text, i)
+}
+
+type ScheduleRelationship int32
+
+const (
+	Scheduled   ScheduleRelationship = 0
+	Added       ScheduleRelationship = 1
+	Unscheduled ScheduleRelationship = 2
+	Canceled    ScheduleRelationship = 3
+)
+
+var scheduleRelationshipNames = []string{"SCHEDULED", "ADDED", "UNSCHEDULED", "CANCELED"}
+
+func (s ScheduleRelationship) MarshalText() ([]byte, error) {
+	return marshalEnum(scheduleRelationshipNames, s)
+}
+
+func (s *ScheduleRelationship) UnmarshalText(text []byte) error {
+	return unmarshalEnum(scheduleRelationshipNames, text, s)
+}
+
+// Cause, Effect and SeverityLevel start at 1, so their zero value stands for unset and their
+// names are offset by one.
+type Cause int32
+
+var causeNames = []string{"", "UNKNOWN_CAUSE", "OTHER_CAUSE", "TECHNICAL_PROBLEM", "STRIKE", "DEMONSTRATION",
+	"ACCIDENT", "HOLIDAY", "WEATHER", "MAINTENANCE", "CONSTRUCTION", "POLICE_ACTIVITY", "MEDICAL_EMERGENCY"}
+
+// ParseCause returns the cause with a name, or false if there is none.
+func ParseCause(name string) (Cause, bool) {
+	return parseEnum[Cause](causeNames, name)
+}
+
+func (c Cause) MarshalText() ([]byte, error) {
+	return marshalEnum(causeNames, c)
+}
+
+func (c *Cause) UnmarshalText(text []byte) error {
+	return unmarshalEnum(causeNames, text, c)
+}
+
+type Effect int32
+
+var effectNames = []string{"", "NO_SERVICE", "REDUCED_SERVICE", "SIGNIFICANT_DELAYS", "DETOUR", "ADDITIONAL_SERVICE",
+	"MODIFIED_SERVICE", "OTHER_EFFECT", "UNKNOWN_EFFECT", "STOP_MOVED", "NO_EFFECT", "ACCESSIBILITY_ISSUE"}
+
+// ParseEffect returns the effect with a name, or false if there is none.
+func ParseEffect(name string) (Effect, bool) {
+	return parseEnum[Effect](effectNames, name)
+}
+
+func (e Effect) MarshalText() ([]byte, error) {
+	return marshalEnum(effectNames, e)
+}
+
+func (e *Effect) UnmarshalText(text []byte) error {
+	return unmarshalEnum(effectNames, text, e)
+}
+
+type SeverityLevel int32
+
+var severityLevelNames = []string{"", "UNKNOWN_SEVERITY", "INFO", "WARNING", "SEVERE"}
+
+// ParseSeverityLevel returns the severity level with a name, or false if there is none.
+func ParseSeverityLevel(name string) (SeverityLevel, bool) {
+	return parseEnum[SeverityLevel](severityLevelNames, name)
+}
+
+func (s SeverityLevel) MarshalText() ([]byte, error) {
+	return marshalEnum(severityLevelNames, s)
+}
+
+func (s *SeverityLevel) UnmarshalText(text []byte) error {
+	return unmarshalEnum(severityLevelNames, text, s)
+}
+
+// marshalEnum returns the name of an enum value, as the canonical JSON mapping of protocol buffers does.
+func marshalEnum[E ~int32](names []string, value E) ([]byte, error) {
+	if value < 0 || int(value) >= len(names) || names[value] == "" {
+		return nil, fmt.Errorf("invalid enum value %d", value)
+	}
+	return []byte(names[value]), nil
+}
+
+func unmarshalEnum[E ~int32](names []string, text []byte, value *E) error {
+	v, ok := parseEnum[E](names, string(text))
+	if !ok {
+		return fmt.Errorf("invalid enum name %q", text)
+	}
+	*value = v
+	return nil
+}
+
+func parseEnum[E ~int32](names []string, name string) (E, bool) {
+	i := slices.Index(names, name)
+	if i < 0 || name == "" {
+		return 0, false
+	}
+	return E(i), true
+}
+
+// FeedMessage is the root of a GTFS Realtime feed. The messages mirror the parts of
+// gtfs-realtime.proto that Mercury produces, with the JSON field names of its canonical mapping.
+type FeedMessage struct {
+	Header FeedHeader   `json:"header"`
+	Entity []FeedEntity `json:"entity"`
+}
+
+type FeedHeader struct {
+	GTFSRealtimeVersion string         `json:"gtfsRealtimeVersion"`
+	Incrementality      Incrementality `json:"incrementality"`
+	// Timestamp is when the feed was created, in seconds since the Unix epoch
+	Timestamp uint64 `json:"timestamp,omitempty"`
+}
+
+// FeedEntity holds exactly one of Vehicle, TripUpdate or Alert.
+type FeedEntity struct {
+	ID         string           `json:"id"`
+	IsDeleted  bool             `json:"isDeleted,omitempty"`
+	TripUpdate *TripUpdate      `json:"tripUpdate,omitempty"`
+	Vehicle    *VehiclePosition `json:"vehicle,omitempty"`
+	Alert      *Alert           `json:"alert,omitempty"`
+}
+
+type VehiclePosition struct {
+	Trip     *TripDescriptor    `json:"trip,omitempty"`
+	Vehicle  *VehicleDescriptor `json:"vehicle,omitempty"`
+	Position *Position          `json:"position,omitempty"`
+	// Timestamp is when the position was measured, in seconds since the Unix epoch
+	Timestamp uint64 `json:"timestamp,omitempty"`
+}
+
+// TripUpdate is the realtime progress of a trip, predicting when it calls at its remaining stops.
+type TripUpdate struct {
+	Trip           *TripDescriptor    `json:"trip"`
+	Vehicle        *VehicleDescriptor `json:"vehicle,omitempty"`
+	StopTimeUpdate []StopTimeUpdate   `json:"stopTimeUpdate,omitempty"`
+	// Timestamp is when the prediction was last updated, in seconds since the Unix epoch
+	Timestamp uint64 `json:"timestamp,omitempty"`
+	// Delay is how late in seconds the trip is currently running, negative when early
+	Delay *int32 `json:"delay,omitempty"`
+}
+
+// StopTimeUpdate predicts when a trip calls at a stop. Its schedule relationship is always left
+// as the default, SCHEDULED.
+type StopTimeUpdate struct {
+	StopSequence *uint32        `json:"stopSequence,omitempty"`
+	StopID       string         `json:"stopId,omitempty"`
+	Arrival      *StopTimeEvent `json:"arrival,omitempty"`
+	Departure    *StopTimeEvent `json:"departure,omitempty"`
+}
+
+// StopTimeEvent is a predicted arrival or departure, with its delay in seconds and its time in
+// seconds since the Unix epoch.
+type StopTimeEvent struct {
+	Delay *int32 `json:"delay,omitempty"`
+	Time  int64  `json:"time,omitempty"`
+}
+
+// Alert is a service alert, such as a diversion or cancellation, affecting the informed entities
+// during its active periods. Its cause, effect and severity are left out when unknown.
+type Alert struct {
+	ActivePeriod    []TimeRange       `json:"activePeriod,omitempty"`
+	InformedEntity  []EntitySelector  `json:"informedEntity,omitempty"`
+	Cause           Cause             `json:"cause,omitempty"`
+	Effect          Effect            `json:"effect,omitempty"`
+	HeaderText      *TranslatedString `json:"headerText,omitempty"`
+	DescriptionText *TranslatedString `json:"descriptionText,omitempty"`
+	SeverityLevel   SeverityLevel     `json:"severityLevel,omitempty"`
+}
+
+// TimeRange is an interval in seconds since the Unix epoch, open on either side when it is zero.
+type TimeRange struct {
+	Start uint64 `json:"start,omitempty"`
+	End   uint64 `json:"end,omitempty"`
+}
+
+// EntitySelector selects the agencies, routes or stops an alert applies to. All set fields must
+// match for it to apply.
+type EntitySelector struct {
+	AgencyID string `json:"agencyId,omitempty"`
+	RouteID  string `json:"routeId,omitempty"`
+	StopID   string `json:"stopId,omitempty"`
+}
+
+type TranslatedString struct {
+	Translation []Translation `json:"translation"`
+}
+
+type Translation struct {
+	Text     string `json:"text"`
+	Language string `json:"language,omitempty"`
+}
+
+// TripDescriptor identifies a trip, and the service date it runs on when it runs on several.
+type TripDescriptor struct {
+	TripID               string               `json:"tripId,omitempty"`
+	RouteID              string               `json:"routeId,omitempty"`
+	DirectionID          *uint32              `json:"directionId,omitempty"`
+	StartTime            string               `json:"startTime,omitempty"`
+	StartDate            string               `json:"startDate,omitempty"`
+	ScheduleRelationship ScheduleRelationship `json:"scheduleRelationship"`
+}
+
+type VehicleDescriptor struct {
+	ID    string `json:"id,omitempty"`
+	Label string `json:"label,omitempty"`
+}
+
+type Position struct {
+	Latitude  float32 `json:"latitude"`
+	Longitude float32 `json:"longitude"`
+}
+
+// Marshal encodes the feed in the protocol buffer wire format. Fields are encoded by hand with the
+// numbers from gtfs-realtime.proto, so the generated bindings are not needed.
+func (m *FeedMessage) Marshal() []byte {
+	var b []byte
+	b = appendMessage(b, 1, m.Header.appendTo(nil))
+	for _, entity := range m.Entity {
+		b = appendMessage(b, 2, entity.appendTo(nil))
+	}
+	return b
+}
+
+func (h *FeedHeader) appendTo(b []byte) []byte {
+	b = appendString(b, 1, h.GTFSRealtimeVersion)
+	b = appendVarint(b, 2, uint64(h.Incrementality))
+	b = appendOptionalVarint(b, 3, h.Timestamp)
+	return b
+}
+
+func (e *FeedEntity) appendTo(b []byte) []byte {
+	b = appendString(b, 1, e.ID)
+	if e.IsDeleted {
+		b = appendVarint(b, 2, 1)
+	}
+	if e.TripUpdate != nil {
+		b = appendMessage(b, 3, e.TripUpdate.appendTo(nil))
+	}
+	if e.Vehicle != nil {
+		b = appendMessage(b, 4, e.Vehicle.appendTo(nil))
+	}
+	if e.Alert != nil {
+		b = appendMessage(b, 5, e.Alert.appendTo(nil))
+	}
+	return b
+}
+
+func (a *Alert) appendTo(b []byte) []byte {
+	for _, period := range a.ActivePeriod {
+		b = appendMessage(b, 1, period.appendTo(nil))
+	}
+	for _, entity := range a.InformedEntity {
+		b = appendMessage(b, 5, entity.appendTo(nil))
+	}
+	b = appendOptionalVarint(b, 6, uint64(a.Cause))
+	b = appendOptionalVarint(b, 7, uint64(a.Effect))
+	if a.HeaderText != nil {
+		b = appendMessage(b, 10, a.HeaderText.appendTo(nil))
+	}
+	if a.DescriptionText != nil {
+		b = appendMessage(b, 11, a.DescriptionText.appendTo(nil))
+	}
+	b = appendOptionalVarint(b, 14, uint64(a.SeverityLevel))
+	return b
+}
+
+func (r *TimeRange) appendTo(b []byte) []byte {
+	b = appendOptionalVarint(b, 1, r.Start)
+	b = appendOptionalVarint(b, 2, r.End)
+	return b
+}
+
+func (s *EntitySelector) appendTo(b []byte) []byte {
+	b = appendOptionalString(b, 1, s.AgencyID)
+	b = appendOptionalString(b, 2, s.RouteID)
+	b = appendOptionalString(b, 5, s.StopID)
+	return b
+}
+
+func (s *TranslatedString) appendTo(b []byte) []byte {
+	for _, translation := range s.Translation {
+		var t []byte
+		t = appendString(t, 1, translation.Text)
+		t = appendOptionalString(t, 2, translation.Language)
+		b = appendMessage(b, 1, t)
+	}
+	return b
+}
+
+func (u *TripUpdate) appendTo(b []byte) []byte {
+	b = appendMessage(b, 1, u.Trip.appendTo(nil))
+	for _, update := range u.StopTimeUpdate {
+		b = appendMessage(b, 2, update.appendTo(nil))
+	}
+	if u.Vehicle != nil {
+		b = appendMessage(b, 3, u.Vehicle.appendTo(nil))
+	}
+	b = appendOptionalVarint(b, 4, u.Timestamp)
+	if u.Delay != nil {
+		b = appendVarint(b, 5, uint64(int64(*u.Delay)))
+	}
+	return b
+}
+
+func (u *StopTimeUpdate) appendTo(b []byte) []byte {
+	if u.StopSequence != nil {
+		b = appendVarint(b, 1, uint64(*u.StopSequence))
+	}
+	if u.Arrival != nil {
+		b = appendMessage(b, 2, u.Arrival.appendTo(nil))
+	}
+	if u.Departure != nil {
+		b = appendMessage(b, 3, u.Departure.appendTo(nil))
+	}
+	b = appendOptionalString(b, 4, u.StopID)
+	return b
+}
+
+func (e *StopTimeEvent) appendTo(b []byte) []byte {
+	if e.Delay != nil {
+		b = appendVarint(b, 1, uint64(int64(*e.Delay)))
+	}
+	b = appendOptionalVarint(b, 2, uint64(e.Time))
+	return b
+}
+
+func (v *VehiclePosition) appendTo(b []byte) []byte {
+	if v.Trip != nil {
+		b = appendMessage(b, 1, v.Trip.appendTo(nil))
+	}
+	if v.Position != nil {
+		b = appendMessage(b, 2, v.Position.appendTo(nil))
+	}
+	b = appendOptionalVarint(b, 5, v.Timestamp)
+	if v.Vehicle != nil {
+		b = appendMessage(b, 8, v.Vehicle.appendTo(nil))
+	}
+	return b
+}
+
+func (t *TripDescriptor) appendTo(b []byte) []byte {
+	b = appendOptionalString(b, 1, t.TripID)
+	b = appendOptionalString(b, 2, t.StartTime)
+	b = appendOptionalString(b, 3, t.StartDate)
+	b = appendVarint(b, 4, uint64(t.ScheduleRelationship))
+	b = appendOptionalString(b, 5, t.RouteID)
+	if t.DirectionID != nil {
+		b = appendVarint(b, 6, uint64(*t.DirectionID))
+	}
+	return b
+}
+
+func (v *VehicleDescriptor) appendTo(b []byte) []byte {
+	b = appendOptionalString(b, 1, v.ID)
+	b = appendOptionalString(b, 2, v.Label)
+	return b
+}
+
+func (p *Position) appendTo(b []byte) []byte {
+	b = appendFloat(b, 1, p.Latitude)
+	b = appendFloat(b, 2, p.Longitude)
+	return b
+}
+
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendOptionalString appends a string field unless it is empty, which stands for unset.
+func appendOptionalString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendString(b, num, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendOptionalVarint appends a varint field unless it is zero, which stands for unset.
+func appendOptionalVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendVarint(b, num, v)
+}
+
+func appendFloat(b []byte, num protowire.Number, f float32) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, math.Float32bits(f))
+}
@@ -0,0 +1,62 @@
+package gtfsrt
+
+import (
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/realtime"
+)
+
+// TripUpdates builds a feed with a prediction for every trip run by a tracked bus, covering the
+// stops it has yet to call at. A trip reported by two buses keeps the most recent report.
+func TripUpdates(vehicles []realtime.Vehicle, now time.Time) *FeedMessage {
+	feed := newFeed(now)
+	entities := make(map[string]int)
+	for _, v := range vehicles {
+		if v.Trip == nil {
+			continue
+		}
+
+		id := v.Trip.ID + "-" + v.ServiceDay.Format("20060102")
+		update := FeedEntity{ID: id, TripUpdate: tripUpdate(v)}
+		if i, ok := entities[id]; ok {
+			if v.Location.Timestamp.After(time.Unix(int64(feed.Entity[i].TripUpdate.Timestamp), 0)) {
+				feed.Entity[i] = update
+			}
+			continue
+		}
+		entities[id] = len(feed.Entity)
+		feed.Entity = append(feed.Entity, update)
+	}
+	return feed
+}
+
+func tripUpdate(v realtime.Vehicle) *TripUpdate {
+	predictions := v.Predictions()
+	update := &TripUpdate{
+		Trip:           tripDescriptor(v.Trip, v.ServiceDay),
+		Vehicle:        &VehicleDescriptor{ID: v.Location.BusID},
+		StopTimeUpdate: make([]StopTimeUpdate, len(predictions)),
+		Timestamp:      unixTime(v.Location.Timestamp),
+		Delay:          seconds(v.Delay),
+	}
+
+	last := len(v.Trip.StopTimes) - 1
+	for i, p := range predictions {
+		sequence := uint32(p.StopTime.Sequence)
+		stopTimeUpdate := StopTimeUpdate{StopSequence: &sequence, StopID: p.StopTime.Stop.ID}
+		// no one boards at the last stop or alights at the first, so those events are left out
+		if p.StopTime != v.Trip.StopTimes[0] {
+			stopTimeUpdate.Arrival = &StopTimeEvent{Delay: seconds(p.ArrivalDelay), Time: p.Arrival.Unix()}
+		}
+		if p.StopTime != v.Trip.StopTimes[last] {
+			stopTimeUpdate.Departure = &StopTimeEvent{Delay: seconds(p.DepartureDelay), Time: p.Departure.Unix()}
+		}
+		update.StopTimeUpdate[i] = stopTimeUpdate
+	}
+	return update
+}
+
+func seconds(d time.Duration) *int32 {
+	s := int32(d / time.Second)
+	return &s
+}
@@ -0,0 +1,59 @@
+package gtfsrt
+
+import (
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+)
+
+// VehiclePositions builds a feed with the position of every tracked bus. Buses matched to a trip
+// carry a descriptor of that trip.
+func VehiclePositions(vehicles []realtime.Vehicle, now time.Time) *FeedMessage {
+	feed := newFeed(now)
+	for _, v := range vehicles {
+		position := &VehiclePosition{
+			Vehicle: &VehicleDescriptor{ID: v.Location.BusID},
+			Position: &Position{
+				Latitude:  float32(v.Location.Latitude),
+				Longitude: float32(v.Location.Longitude),
+			},
+			Timestamp: unixTime(v.Location.Timestamp),
+		}
+		if v.Trip != nil {
+			position.Trip = tripDescriptor(v.Trip, v.ServiceDay)
+		}
+		feed.Entity = append(feed.Entity, FeedEntity{ID: v.Location.BusID, Vehicle: position})
+	}
+	return feed
+}
+
+func newFeed(now time.Time) *FeedMessage {
+	return &FeedMessage{
+		Header: FeedHeader{
+			GTFSRealtimeVersion: Version,
+			Incrementality:      FullDataset,
+			Timestamp:           unixTime(now),
+		},
+		Entity: []FeedEntity{},
+	}
+}
+
+func tripDescriptor(trip *gtfs.Trip, serviceDay time.Time) *TripDescriptor {
+	directionID := uint32(trip.DirectionID)
+	return &TripDescriptor{
+		TripID:               trip.ID,
+		RouteID:              trip.Route.ID,
+		DirectionID:          &directionID,
+		StartTime:            gtfs.FormatTime(trip.Departure()),
+		StartDate:            serviceDay.Format("20060102"),
+		ScheduleRelationship: Scheduled,
+	}
+}
+
+func unixTime(t time.Time) uint64 {
+	if t.IsZero() || t.Unix() < 0 {
+		return 0
+	}
+	return uint64(t.Unix())
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -8,7 +9,10 @@ import (
 	"github.com/go-chi/httprate"
 	httpSwagger "github.com/swaggo/http-swagger"
 	_ "github.com/transitIOM/projectMercury/docs"
+	v2 "github.com/transitIOM/projectMercury/internal/handlers/v2"
 	internalMiddleware "github.com/transitIOM/projectMercury/internal/middleware"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
 	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
@@ -29,17 +33,28 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name X-API-Key
-func Handler(r *chi.Mux, sm tools.ObjectStorageManager) {
+func Handler(ctx context.Context, r *chi.Mux, sm tools.ObjectStorageManager, ss *schedule.Store, tracker *realtime.Tracker, locations *tools.LocationStore, feed *tools.LocationFeed) {
 	r.Use(middleware.Logger)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Timeout(30 * time.Second))
-	r.Use(middleware.ThrottleBacklog(50, 100, time.Second*30))
 	r.Use(middleware.StripSlashes)
 	r.Use(middleware.CleanPath)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Heartbeat("/health"))
 
+	// the location stream is held open until ctx is done, so it is kept out of the request timeout
+	// and backlog
+	r.With(httprate.LimitByIP(3, time.Second)).Get("/v1/locations/stream", StreamBusLocations(ctx, feed))
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(30 * time.Second))
+		r.Use(middleware.ThrottleBacklog(50, 100, time.Second*30))
+		routes(r, sm, ss, tracker, locations)
+	})
+}
+
+// routes mounts every versioned API on the router.
+func routes(r chi.Router, sm tools.ObjectStorageManager, ss *schedule.Store, tracker *realtime.Tracker, locations *tools.LocationStore) {
 	v1 := chi.NewRouter()
 
 	v1.Get("/docs/*", httpSwagger.WrapHandler)
@@ -51,13 +66,16 @@ func Handler(r *chi.Mux, sm tools.ObjectStorageManager) {
 		// public routes
 		r.Group(func(r chi.Router) {
 			r.Get("/version", GetScheduleVersionID(sm))
+			r.Get("/versions", GetScheduleVersions(sm))
+			r.Get("/{versionID}", GetScheduleVersionDownloadURL(sm))
 			r.Get("/", GetScheduleDownloadURL(sm))
 		})
 
 		// private routes
 		r.Group(func(r chi.Router) {
 			r.Use(internalMiddleware.APIKeyAuth)
-			r.Put("/", PutGTFSSchedule(sm))
+			r.Put("/", PutGTFSSchedule(sm, ss))
+			r.Post("/rollback", RollbackGTFSSchedule(sm, ss))
 		})
 	})
 
@@ -71,13 +89,36 @@ func Handler(r *chi.Mux, sm tools.ObjectStorageManager) {
 		// private routes
 		r.Group(func(r chi.Router) {
 			r.Use(internalMiddleware.APIKeyAuth)
-			r.Put("/", PutMessage(sm))
+			r.Put("/", PutMessage(sm, ss))
+			r.Put("/{messageID}", EditMessage(sm, ss))
+			r.Delete("/{messageID}", RetractMessage(sm))
 		})
 	})
 
+	v1.Route("/stops", func(r chi.Router) {
+		r.Use(httprate.LimitByIP(120, time.Minute))
+		r.Get("/", GetStops(ss))
+		r.Get("/{stopID}", GetStop(ss))
+		r.Get("/{stopID}/departures", GetDepartures(ss, tracker, locations.Buses))
+	})
+
+	v1.Route("/routes", func(r chi.Router) {
+		r.Use(httprate.LimitByIP(120, time.Minute))
+		r.Get("/", GetRoutes(ss))
+		r.Get("/{routeID}", GetRoute(ss))
+	})
+
 	v1.Route("/locations", func(r chi.Router) {
 		r.Use(httprate.LimitByIP(3, time.Second))
-		r.Get("/", GetBusLocations)
+		r.Get("/", GetBusLocations(tracker, locations.Buses))
+		r.Get("/{busID}/history", GetBusHistory(sm))
+	})
+
+	v1.Route("/gtfs-rt", func(r chi.Router) {
+		r.Use(httprate.LimitByIP(3, time.Second))
+		r.Get("/vehicle-positions", GetGTFSRTVehiclePositions(tracker, locations.Buses))
+		r.Get("/trip-updates", GetGTFSRTTripUpdates(tracker, locations.Buses))
+		r.Get("/alerts", GetGTFSRTAlerts(sm, ss))
 	})
 
 	v1.Route("/report", func(r chi.Router) {
@@ -86,4 +127,5 @@ func Handler(r *chi.Mux, sm tools.ObjectStorageManager) {
 	})
 
 	r.Mount("/v1", v1)
+	r.Mount("/v2", v2.Router(sm, ss, tracker, locations.Buses))
 }
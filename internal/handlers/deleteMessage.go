@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// RetractMessage godoc
+// @Summary      Retract a message
+// @Description  Withdraws a message by appending a tombstone for it to the message log, after which it is no longer returned. Requires API key authentication.
+// @Tags         messages
+// @Produce      json
+// @Param        messageID  path  string  true  "Message ID"
+// @Security     ApiKeyAuth
+// @Success      202  {object}  api.PutMessageResponse
+// @Failure      404  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Router       /messages/{messageID} [delete]
+func RetractMessage(sm tools.ObjectStorageManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling RetractMessage request")
+
+		original, ok := findMessage(w, sm, chi.URLParam(r, "messageID"))
+		if !ok {
+			return
+		}
+
+		tombstone := tools.NewTombstone(original)
+		log.Debugf("Appending tombstone of message %s", tombstone.ID)
+		versionID, err := messages.Append(sm, tombstone)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		response := api.PutMessageResponse{
+			Code:      http.StatusAccepted,
+			VersionID: versionID,
+			ID:        tombstone.ID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err = json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/history"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// defaultHistoryRange is how far back a track goes when no from time is given
+const defaultHistoryRange = time.Hour
+
+// GetBusHistory godoc
+// @Summary      Get the track of a bus
+// @Description  Lists every position recorded for a bus between two times, oldest first. At most 24 hours can be read at once. A bus with no recorded positions in the range has an empty track.
+// @Tags         locations
+// @Produce      json
+// @Param        busID  path      string  true   "Bus ID"
+// @Param        from   query     string  false  "RFC 3339 time to start the track at (defaults to an hour before to)"
+// @Param        to     query     string  false  "RFC 3339 time to end the track at (defaults to now)"
+// @Success      200  {object}  api.GetBusHistoryResponse
+// @Failure      400  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Router       /locations/{busID}/history [get]
+func GetBusHistory(hs tools.PositionHistoryStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetBusHistory request")
+
+		to := time.Now()
+		if toStr := r.URL.Query().Get("to"); toStr != "" {
+			var err error
+			if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+				api.RequestErrorHandler(w, fmt.Errorf("invalid to time %q, expected RFC 3339", toStr))
+				return
+			}
+		}
+
+		from := to.Add(-defaultHistoryRange)
+		if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+			var err error
+			if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+				api.RequestErrorHandler(w, fmt.Errorf("invalid from time %q, expected RFC 3339", fromStr))
+				return
+			}
+		}
+
+		busID := chi.URLParam(r, "busID")
+		track, err := history.Track(hs, busID, from, to)
+		if errors.Is(err, history.InvalidRange) {
+			api.RequestErrorHandler(w, err)
+			return
+		}
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+		log.Debugf("Found %d positions of bus %s", len(track), busID)
+
+		response := api.GetBusHistoryResponse{
+			Code:      http.StatusOK,
+			BusID:     busID,
+			From:      from.UTC(),
+			To:        to.UTC(),
+			Positions: make([]api.Position, len(track)),
+		}
+		for i, position := range track {
+			response.Positions[i] = api.Position{
+				Timestamp:     position.Time,
+				Latitude:      position.Latitude,
+				Longitude:     position.Longitude,
+				RouteNumber:   position.RouteNumber,
+				Direction:     position.Direction,
+				DepartureTime: position.DepartureTime,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(w).Encode(response); err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+	}
+}
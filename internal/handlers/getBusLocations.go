@@ -6,40 +6,49 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/realtime"
 	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
 // GetBusLocations godoc
 // @Summary      Get all current bus locations
-// @Description  Retrieves real-time GPS coordinates and metadata for all active buses on the tracker.
+// @Description  Retrieves real-time GPS coordinates and metadata for all active buses on the tracker. Each bus is matched to the GTFS route and trip it is most likely running, with a confidence between 0 and 1, and how many seconds late it is running, found by projecting its position onto the trip's shape. Buses that match no trip, or all buses while no schedule is loaded, are returned without a route and trip.
 // @Tags         locations
 // @Produce      json
 // @Success      200  {object}  api.GetBusLocationsResponse
 // @Failure      500  {object}  api.Error
 // @Router       /locations/ [get]
-func GetBusLocations(w http.ResponseWriter, r *http.Request) {
-	log.Debug("Handling getBusLocations request")
-
-	busLocationsBytes, err := json.Marshal(tools.GetAllBuses())
-	if err != nil {
-		log.Error(err)
-		api.InternalErrorHandler(w)
-		return
-	}
+func GetBusLocations(tracker *realtime.Tracker, buses func() []tools.BusLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling getBusLocations request")
 
-	stringBusLocations := string(busLocationsBytes)
+		vehicles := tracker.Vehicles(buses())
+		trackedBuses := make([]api.TrackedBus, len(vehicles))
+		for i, vehicle := range vehicles {
+			trackedBuses[i] = toAPITrackedBus(vehicle)
+		}
 
-	response := api.GetBusLocationsResponse{
-		Code:      http.StatusOK,
-		Locations: stringBusLocations,
-	}
+		busLocationsBytes, err := json.Marshal(trackedBuses)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		stringBusLocations := string(busLocationsBytes)
+
+		response := api.GetBusLocationsResponse{
+			Code:      http.StatusOK,
+			Locations: stringBusLocations,
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(response)
-	if err != nil {
-		log.Error(err)
-		api.InternalErrorHandler(w)
-		return
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(response)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
 	}
 }
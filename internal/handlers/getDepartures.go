@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+const (
+	defaultDepartureLimit = 10
+	maxDepartureLimit     = 100
+)
+
+// GetDepartures godoc
+// @Summary      Get departures from a stop
+// @Description  Lists the upcoming departures from a stop, resolved against the GTFS calendar for the service day in the Isle of Man. Departures from a station include those from all of its stops. Departures of trips run by a tracked bus are "live", with a time estimated from the bus's position, and trips that should be running without a tracked bus are marked "noShow".
+// @Tags         stops
+// @Produce      json
+// @Param        stopID  path      string  true   "GTFS stop ID"
+// @Param        from    query     string  false  "RFC 3339 time to list departures from (defaults to now)"
+// @Param        limit   query     int     false  "Maximum number of departures (defaults to 10, at most 100)"
+// @Success      200  {object}  api.GetDeparturesResponse
+// @Failure      400  {object}  api.Error
+// @Failure      404  {object}  api.Error
+// @Failure      503  {object}  api.Error
+// @Router       /stops/{stopID}/departures [get]
+func GetDepartures(ss *schedule.Store, tracker *realtime.Tracker, buses func() []tools.BusLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetDepartures request")
+
+		from := time.Now()
+		if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+			var err error
+			if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+				api.RequestErrorHandler(w, fmt.Errorf("invalid from time %q, expected RFC 3339", fromStr))
+				return
+			}
+		}
+
+		limit := defaultDepartureLimit
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var err error
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+				api.RequestErrorHandler(w, fmt.Errorf("invalid limit %q, expected a positive number", limitStr))
+				return
+			}
+			limit = min(limit, maxDepartureLimit)
+		}
+
+		current := currentSchedule(w, ss)
+		if current == nil {
+			return
+		}
+
+		stopID := chi.URLParam(r, "stopID")
+		stop, ok := current.Stops[stopID]
+		if !ok {
+			api.NotFoundErrorHandler(w, fmt.Errorf("stop %s not found", stopID))
+			return
+		}
+
+		vehicles := tracker.Vehicles(buses())
+		departures := realtime.LiveDepartures(current, stop, from, limit, vehicles, time.Now())
+		log.Debugf("Found %d departures from stop %s", len(departures), stopID)
+
+		response := api.GetDeparturesResponse{
+			Code:       http.StatusOK,
+			VersionID:  current.VersionID,
+			Stop:       toAPIStop(stop),
+			Departures: make([]api.Departure, len(departures)),
+		}
+		for i, departure := range departures {
+			response.Departures[i] = toAPIDeparture(departure)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// maxAlertMessages is how many of the latest messages are considered for the alerts feed
+const maxAlertMessages = 100
+
+// GetGTFSRTAlerts godoc
+// @Summary      Get the GTFS Realtime service alerts feed
+// @Description  Returns a GTFS Realtime FeedMessage with an alert for every message that is active now or later, in its latest revision, encoded as a protocol buffer. Messages without an active window are active for 24 hours. Alerts inform the routes and stops a message is scoped to or, failing that, those named in its text that are found in the current schedule, and the whole agency otherwise. Pass format=json for a JSON rendering of the same feed for debugging.
+// @Tags         gtfs-rt
+// @Produce      application/x-protobuf
+// @Produce      json
+// @Param        format  query     string  false  "Set to json for a JSON rendering of the feed"
+// @Success      200
+// @Failure      400  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Router       /gtfs-rt/alerts [get]
+func GetGTFSRTAlerts(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetGTFSRTAlerts request")
+
+		format, ok := feedFormat(w, r)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		page, err := messages.List(sm, messages.Query{Limit: maxAlertMessages}, now)
+		if errors.Is(err, tools.NoMessageLogFound) {
+			err = nil
+		}
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		feed := gtfsrt.Alerts(page.Messages, ss.Current(), now)
+		writeFeed(w, feed, format)
+	}
+}
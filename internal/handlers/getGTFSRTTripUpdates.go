@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// GetGTFSRTTripUpdates godoc
+// @Summary      Get the GTFS Realtime trip updates feed
+// @Description  Returns a GTFS Realtime FeedMessage with a trip update for every trip run by a tracked bus, encoded as a protocol buffer. Each update predicts the arrival and departure delays at the stops the bus has yet to call at, the same predictions the departures endpoint uses. Pass format=json for a JSON rendering of the same feed for debugging.
+// @Tags         gtfs-rt
+// @Produce      application/x-protobuf
+// @Produce      json
+// @Param        format  query     string  false  "Set to json for a JSON rendering of the feed"
+// @Success      200
+// @Failure      400  {object}  api.Error
+// @Router       /gtfs-rt/trip-updates [get]
+func GetGTFSRTTripUpdates(tracker *realtime.Tracker, buses func() []tools.BusLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetGTFSRTTripUpdates request")
+
+		format, ok := feedFormat(w, r)
+		if !ok {
+			return
+		}
+
+		feed := gtfsrt.TripUpdates(tracker.Vehicles(buses()), time.Now())
+		writeFeed(w, feed, format)
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// GetGTFSRTVehiclePositions godoc
+// @Summary      Get the GTFS Realtime vehicle positions feed
+// @Description  Returns a GTFS Realtime FeedMessage with the position of every tracked bus, encoded as a protocol buffer. Buses matched to a GTFS trip include a trip descriptor. Pass format=json for a JSON rendering of the same feed for debugging.
+// @Tags         gtfs-rt
+// @Produce      application/x-protobuf
+// @Produce      json
+// @Param        format  query     string  false  "Set to json for a JSON rendering of the feed"
+// @Success      200
+// @Failure      400  {object}  api.Error
+// @Router       /gtfs-rt/vehicle-positions [get]
+func GetGTFSRTVehiclePositions(tracker *realtime.Tracker, buses func() []tools.BusLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetGTFSRTVehiclePositions request")
+
+		format, ok := feedFormat(w, r)
+		if !ok {
+			return
+		}
+
+		feed := gtfsrt.VehiclePositions(tracker.Vehicles(buses()), time.Now())
+		writeFeed(w, feed, format)
+	}
+}
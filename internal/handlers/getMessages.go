@@ -2,23 +2,33 @@ package handlers
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/simonfrey/jsonl"
 	log "github.com/sirupsen/logrus"
 	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/messages"
 	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
 // GetMessages godoc
 // @Summary      Get the latest messages from the log
-// @Description  Retrieves the most recent messages from the log in JSONL format, along with the current version ID.
+// @Description  Retrieves the most recent messages from the log in JSONL format, oldest first, along with the current version ID. Messages are returned in their latest revision, and retracted or expired messages are left out.
+// @Description  Pass since to only get the messages posted after a message or time, for polling. Messages posted before it but edited after it are returned in their current state, and messages retracted after it are returned as tombstones with only their ID, posting time and retraction time. When more messages match than the limit, next_cursor is set, and passing it as cursor with the same since returns the page of messages before them.
 // @Tags         messages
 // @Produce      json
-// @Param        n     query     int    false  "Number of latest messages to retrieve (defaults to 3)"
+// @Param        limit   query     int     false  "Number of latest messages to retrieve (defaults to 3)"
+// @Param        n       query     int     false  "Deprecated alias of limit"
+// @Param        since   query     string  false  "ID of a message or RFC 3339 time to get the messages posted, edited or retracted after"
+// @Param        cursor  query     string  false  "next_cursor of the previous page"
 // @Success      200  {object}  api.GetMessagesResponse
+// @Success      204
+// @Failure      400  {object}  api.Error
 // @Failure      500  {object}  api.Error
 // @Router       /messages/ [get]
 func GetMessages(sm tools.ObjectStorageManager) http.HandlerFunc {
@@ -27,48 +37,70 @@ func GetMessages(sm tools.ObjectStorageManager) http.HandlerFunc {
 
 		var response api.GetMessagesResponse
 
-		messageCount := 3
-		if nStr := r.URL.Query().Get("n"); nStr != "" {
-			if n, err := strconv.Atoi(nStr); err == nil && n > 0 {
-				messageCount = n
+		query := messages.Query{Since: r.URL.Query().Get("since"), Limit: 3}
+		limit := cmp.Or(r.URL.Query().Get("limit"), r.URL.Query().Get("n"))
+		if limit != "" {
+			if n, err := strconv.Atoi(limit); err == nil && n > 0 {
+				query.Limit = n
 			}
 		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			before, err := messages.DecodeCursor(cursor)
+			if err != nil {
+				api.RequestErrorHandler(w, err)
+				return
+			}
+			query.Before = before
+		}
 
 		v, err := sm.GetLatestMessageVersionID()
 		if err != nil {
 			if errors.Is(err, tools.NoMessageLogFound) {
 				log.Debug(err)
 				w.WriteHeader(http.StatusNoContent)
-				return	
+				return
 			}
 			log.Error(err)
 			api.InternalErrorHandler(w)
 			return
 		}
 
-		log.Debugf("Retrieving latest message log from storage, requesting last %d messages", messageCount)
-		b, err := sm.GetLatestLog()
+		log.Debugf("Retrieving latest messages from storage, requesting last %d messages", query.Limit)
+		page, err := messages.List(sm, query, time.Now())
+		if errors.Is(err, messages.NotFound) {
+			api.RequestErrorHandler(w, err)
+			return
+		}
 		if err != nil {
 			log.Error(err)
 			api.InternalErrorHandler(w)
 			return
 		}
 
-		b = getLastNLines(b, messageCount)
+		b := bytes.Buffer{}
+		writer := jsonl.NewWriter(&b)
+		for _, message := range page.Messages {
+			if err = writer.Write(message); err != nil {
+				log.Error(err)
+				api.InternalErrorHandler(w)
+				return
+			}
+		}
 
-		log.Debugf("Retrieved %d bytes from message log after truncation", b.Len())
+		log.Debugf("Retrieved %d messages, %d bytes", len(page.Messages), b.Len())
 
-		if b.Len() == 0 {
+		if len(page.Messages) == 0 {
 			response = api.GetMessagesResponse{
 				Code:      http.StatusNoContent,
-				Messages:  "",	
+				Messages:  "",
 				VersionID: "",
 			}
 		} else {
 			response = api.GetMessagesResponse{
-				Code:      http.StatusOK,
-				Messages:  b.String(),
-				VersionID: v,
+				Code:       http.StatusOK,
+				Messages:   b.String(),
+				VersionID:  v,
+				NextCursor: page.NextCursor,
 			}
 		}
 
@@ -79,37 +111,3 @@ func GetMessages(sm tools.ObjectStorageManager) http.HandlerFunc {
 		}
 	}
 }
-
-func getLastNLines(b *bytes.Buffer, n int) *bytes.Buffer {
-	if b == nil || b.Len() == 0 {
-		return &bytes.Buffer{}
-	}
-
-	data := b.Bytes()
-	lines := bytes.Split(data, []byte("\n"))
-
-	// Remove trailing empty line if it exists
-	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
-		lines = lines[:len(lines)-1]
-	}
-
-	start := 0
-	if len(lines) > n {
-		start = len(lines) - n
-	}
-
-	result := new(bytes.Buffer)
-	for i := start; i < len(lines); i++ {
-		result.Write(lines[i])
-		if i < len(lines)-1 {
-			result.WriteByte('\n')
-		}
-	}
-
-	// Restore trailing newline if original had it and we are returning something
-	if result.Len() > 0 && len(data) > 0 && data[len(data)-1] == '\n' {
-		result.WriteByte('\n')
-	}
-
-	return result
-}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+)
+
+// GetRoute godoc
+// @Summary      Get a route
+// @Description  Returns a route of the latest GTFS schedule along with its ordered stop pattern in each direction. The pattern of a direction is the one followed by the most trips.
+// @Tags         routes
+// @Produce      json
+// @Param        routeID  path      string  true  "GTFS route ID"
+// @Success      200  {object}  api.GetRouteResponse
+// @Failure      404  {object}  api.Error
+// @Failure      503  {object}  api.Error
+// @Router       /routes/{routeID} [get]
+func GetRoute(ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetRoute request")
+
+		current := currentSchedule(w, ss)
+		if current == nil {
+			return
+		}
+
+		routeID := chi.URLParam(r, "routeID")
+		route, ok := current.Routes[routeID]
+		if !ok {
+			api.NotFoundErrorHandler(w, fmt.Errorf("route %s not found", routeID))
+			return
+		}
+
+		patterns := make([]api.RoutePattern, len(route.Patterns))
+		for i, pattern := range route.Patterns {
+			patterns[i] = api.RoutePattern{
+				DirectionID: pattern.DirectionID,
+				Headsign:    pattern.Headsign,
+				Stops:       toAPIStops(pattern.Stops),
+			}
+		}
+
+		response := api.GetRouteResponse{
+			Code:      http.StatusOK,
+			VersionID: current.VersionID,
+			Route:     toAPIRoute(route),
+			Patterns:  patterns,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+)
+
+// GetRoutes godoc
+// @Summary      List all routes
+// @Description  Lists every route of the latest GTFS schedule, in the feed's route_sort_order and then by route ID.
+// @Tags         routes
+// @Produce      json
+// @Success      200  {object}  api.GetRoutesResponse
+// @Failure      503  {object}  api.Error
+// @Router       /routes/ [get]
+func GetRoutes(ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetRoutes request")
+
+		current := currentSchedule(w, ss)
+		if current == nil {
+			return
+		}
+
+		routes := slices.SortedFunc(maps.Values(current.Routes), func(a, b *gtfs.Route) int {
+			return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), cmp.Compare(a.ID, b.ID))
+		})
+
+		response := api.GetRoutesResponse{
+			Code:      http.StatusOK,
+			VersionID: current.VersionID,
+			Routes:    toAPIRoutes(routes),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
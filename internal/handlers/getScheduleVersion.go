@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// GetScheduleVersionDownloadURL godoc
+// @Summary      Get a download URL for a specific GTFS schedule version
+// @Description  Generates a short-lived presigned URL to download a previously uploaded GTFS schedule zip file by its version ID.
+// @Tags         schedule
+// @Produce      json
+// @Param        versionID  path      string  true  "Schedule version ID, as listed by /schedule/versions"
+// @Success      200  {object}  api.GetTimetableResponse
+// @Failure      404  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Router       /schedule/{versionID} [get]
+func GetScheduleVersionDownloadURL(sm tools.ObjectStorageManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetScheduleVersionDownloadURL request")
+
+		versionID := chi.URLParam(r, "versionID")
+		downloadURL, err := sm.GetVersionURL(versionID)
+		if err != nil {
+			if errors.Is(err, tools.GTFSVersionNotFound) {
+				api.NotFoundErrorHandler(w, fmt.Errorf("schedule version %s not found", versionID))
+				return
+			}
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		log.Debugf("Retrieved schedule download URL for version ID: %s", versionID)
+		response := api.GetTimetableResponse{
+			Code:        http.StatusOK,
+			DownloadURL: downloadURL.String(),
+			VersionID:   versionID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err = json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
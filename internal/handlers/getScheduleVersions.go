@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// GetScheduleVersions godoc
+// @Summary      List every stored GTFS schedule version
+// @Description  Retrieves the version ID, upload time, size and ETag of every GTFS schedule that has been uploaded, newest first.
+// @Tags         schedule
+// @Produce      json
+// @Success      200  {object}  api.GetScheduleVersionsResponse
+// @Success      204  "No schedule available"
+// @Failure      500  {object}  api.Error
+// @Router       /schedule/versions [get]
+func GetScheduleVersions(sm tools.ObjectStorageManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetScheduleVersions request")
+
+		versions, err := sm.ListGTFSVersions()
+		if err != nil {
+			if errors.Is(err, tools.NoGTFSScheduleFound) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		log.Debugf("Retrieved %d schedule versions", len(versions))
+		response := api.GetScheduleVersionsResponse{
+			Code:     http.StatusOK,
+			Versions: make([]api.ScheduleVersion, 0, len(versions)),
+		}
+		for _, v := range versions {
+			response.Versions = append(response.Versions, api.ScheduleVersion{
+				VersionID:  v.VersionID,
+				UploadedAt: v.LastModified,
+				Size:       v.Size,
+				ETag:       v.ETag,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err = json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+)
+
+// GetStop godoc
+// @Summary      Get a stop
+// @Description  Returns a stop of the latest GTFS schedule along with the routes calling at it. For a station, the stops belonging to it are listed as children.
+// @Tags         stops
+// @Produce      json
+// @Param        stopID  path      string  true  "GTFS stop ID"
+// @Success      200  {object}  api.GetStopResponse
+// @Failure      404  {object}  api.Error
+// @Failure      503  {object}  api.Error
+// @Router       /stops/{stopID} [get]
+func GetStop(ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetStop request")
+
+		current := currentSchedule(w, ss)
+		if current == nil {
+			return
+		}
+
+		stopID := chi.URLParam(r, "stopID")
+		stop, ok := current.Stops[stopID]
+		if !ok {
+			api.NotFoundErrorHandler(w, fmt.Errorf("stop %s not found", stopID))
+			return
+		}
+
+		// a station is served by the routes calling at any of its stops
+		routes := slices.Clone(stop.Routes)
+		for _, child := range stop.Children {
+			for _, route := range child.Routes {
+				if !slices.Contains(routes, route) {
+					routes = append(routes, route)
+				}
+			}
+		}
+		slices.SortFunc(routes, func(a, b *gtfs.Route) int { return cmp.Compare(a.ID, b.ID) })
+
+		response := api.GetStopResponse{
+			Code:      http.StatusOK,
+			VersionID: current.VersionID,
+			Stop:      toAPIStop(stop),
+			Children:  toAPIStops(stop.Children),
+			Routes:    toAPIRoutes(routes),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+)
+
+// GetStops godoc
+// @Summary      List all stops
+// @Description  Lists every stop and station of the latest GTFS schedule, ordered by name.
+// @Tags         stops
+// @Produce      json
+// @Success      200  {object}  api.GetStopsResponse
+// @Failure      503  {object}  api.Error
+// @Router       /stops/ [get]
+func GetStops(ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling GetStops request")
+
+		current := currentSchedule(w, ss)
+		if current == nil {
+			return
+		}
+
+		stops := slices.SortedFunc(maps.Values(current.Stops), func(a, b *gtfs.Stop) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+		})
+
+		response := api.GetStopsResponse{
+			Code:      http.StatusOK,
+			VersionID: current.VersionID,
+			Stops:     toAPIStops(stops),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
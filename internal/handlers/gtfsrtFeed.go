@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+)
+
+const (
+	feedFormatProtobuf = "protobuf"
+	feedFormatJSON     = "json"
+)
+
+// feedFormat returns the format requested for a GTFS Realtime feed, writing a 400 response and
+// returning false if it is not supported.
+func feedFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", feedFormatProtobuf:
+		return feedFormatProtobuf, true
+	case feedFormatJSON:
+		return feedFormatJSON, true
+	default:
+		api.RequestErrorHandler(w, fmt.Errorf("invalid format %q, expected protobuf or json", format))
+		return "", false
+	}
+}
+
+func writeFeed(w http.ResponseWriter, feed *gtfsrt.FeedMessage, format string) {
+	if format == feedFormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(feed); err != nil {
+			log.Errorf("Failed to encode feed: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(feed.Marshal()); err != nil {
+		log.Errorf("Failed to write feed: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// messageFromForm builds a message from the fields of a request, returning an error that
+// describes the first invalid one.
+func messageFromForm(r *http.Request, message string, now time.Time) (tools.MessageLog, error) {
+	m := tools.NewMessage(message)
+	m.Severity = r.FormValue("severity")
+	m.Cause = r.FormValue("cause")
+	m.Effect = r.FormValue("effect")
+	m.Category = r.FormValue("category")
+	m.RouteIDs = formList(r, "routeIDs")
+	m.StopIDs = formList(r, "stopIDs")
+	m.ActiveFrom = r.FormValue("activeFrom")
+	m.ActiveUntil = r.FormValue("activeUntil")
+	return messages.Validate(m, now)
+}
+
+// formList returns the values of a form field that may be repeated or hold a comma separated
+// list.
+func formList(r *http.Request, field string) []string {
+	var values []string
+	for _, value := range r.Form[field] {
+		values = append(values, strings.Split(value, ",")...)
+	}
+	return values
+}
+
+// validateMessageScope checks the routes and stops a message applies to against the current
+// schedule, writing an error response if they are not all in it or no schedule is loaded.
+func validateMessageScope(w http.ResponseWriter, m tools.MessageLog, ss *schedule.Store) bool {
+	if !messages.Scoped(m) {
+		return true
+	}
+	current := currentSchedule(w, ss)
+	if current == nil {
+		return false
+	}
+	if err := messages.ValidateScope(m, current); err != nil {
+		api.RequestErrorHandler(w, err)
+		return false
+	}
+	return true
+}
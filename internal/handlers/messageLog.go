@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// findMessage returns the current state of a message, writing an error response if it does not
+// exist, was retracted or the log cannot be read.
+func findMessage(w http.ResponseWriter, sm tools.ObjectStorageManager, messageID string) (tools.MessageLog, bool) {
+	message, err := messages.Find(sm, messageID)
+	if errors.Is(err, messages.NotFound) {
+		api.NotFoundErrorHandler(w, err)
+		return message, false
+	}
+	if err != nil {
+		log.Error(err)
+		api.InternalErrorHandler(w)
+		return message, false
+	}
+	return message, true
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	internalMiddleware "github.com/transitIOM/projectMercury/internal/middleware"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// RollbackGTFSSchedule godoc
+// @Summary      Roll back the published GTFS schedule
+// @Description  Republishes a previously uploaded GTFS schedule version as the latest schedule. The rollback is stored as a new version, so the full history is preserved. Versions that fail validation, such as ones uploaded before validation existed, are rejected with the list of problems found. Requires API key authentication.
+// @Tags         schedule
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        versionID  formData  string  true  "Version ID of the schedule to republish"
+// @Security     ApiKeyAuth
+// @Success      202  {object}  api.RollbackScheduleResponse
+// @Failure      400  {object}  api.GTFSValidationErrorResponse
+// @Failure      404  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Router       /schedule/rollback [post]
+func RollbackGTFSSchedule(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling RollbackGTFSSchedule request")
+		versionID := r.FormValue("versionID")
+
+		if versionID == "" {
+			log.Debug("versionID parameter missing in request")
+			api.RequestErrorHandler(w, errors.New("versionID parameter is required"))
+			return
+		}
+
+		data, err := sm.GetScheduleVersion(versionID)
+		if err != nil {
+			if errors.Is(err, tools.GTFSVersionNotFound) {
+				api.NotFoundErrorHandler(w, fmt.Errorf("schedule version %s not found", versionID))
+				return
+			}
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		// a version that does not parse would be published without ever being loaded
+		report := gtfs.Validate(bytes.NewReader(data), int64(len(data)))
+		if !report.Valid() {
+			log.Debugf("Rejected rollback to GTFS schedule %s with %d errors and %d warnings", versionID, report.ErrorCount, report.WarningCount)
+			api.GTFSValidationErrorHandler(w, api.GTFSValidationErrorResponse{
+				Errors:       toAPIValidationIssues(report.Errors),
+				Warnings:     toAPIValidationIssues(report.Warnings),
+				ErrorCount:   report.ErrorCount,
+				WarningCount: report.WarningCount,
+			})
+			return
+		}
+		if _, err = gtfs.Parse(bytes.NewReader(data), int64(len(data))); err != nil {
+			log.Debugf("Rejected rollback to GTFS schedule %s: %v", versionID, err)
+			api.RequestErrorHandler(w, fmt.Errorf("schedule version %s cannot be loaded: %w", versionID, err))
+			return
+		}
+
+		newVersionID, err := sm.RollbackSchedule(versionID)
+		if err != nil {
+			if errors.Is(err, tools.GTFSVersionNotFound) {
+				api.NotFoundErrorHandler(w, fmt.Errorf("schedule version %s not found", versionID))
+				return
+			}
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"api_key_id":        internalMiddleware.APIKeyID(r.Context()),
+			"remote_addr":       r.RemoteAddr,
+			"request_id":        middleware.GetReqID(r.Context()),
+			"source_version_id": versionID,
+			"new_version_id":    newVersionID,
+		}).Info("GTFS schedule rolled back")
+
+		if err = ss.Load(); err != nil {
+			log.Errorf("Failed to load rolled back GTFS schedule: %v", err)
+		}
+
+		response := api.RollbackScheduleResponse{
+			Code:            http.StatusAccepted,
+			VersionID:       newVersionID,
+			SourceVersionID: versionID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err = json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -1,29 +1,39 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"net/http"
+	"time"
 
-	"github.com/simonfrey/jsonl"
 	log "github.com/sirupsen/logrus"
 	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
 	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
 // PutMessage godoc
 // @Summary      Append a new message to the log
-// @Description  Appends a new message entry to the existing message log. Requires API key authentication.
+// @Description  Appends a new message entry to the existing message log and returns its ID. Requires API key authentication. A message can optionally carry a severity, cause and effect named after the GTFS Realtime alert enums, a category, the routes and stops it applies to and a window it is active in. Routes and stops are validated against the current GTFS schedule.
 // @Tags         messages
 // @Accept       x-www-form-urlencoded
 // @Produce      json
-// @Param        message  formData  string  true  "Message content"
+// @Param        message      formData  string  true   "Message content"
+// @Param        severity     formData  string  false  "INFO, WARNING or SEVERE"
+// @Param        cause        formData  string  false  "GTFS Realtime cause, such as CONSTRUCTION"
+// @Param        effect       formData  string  false  "GTFS Realtime effect, such as DETOUR"
+// @Param        category     formData  string  false  "general, disruption, diversion, plannedWorks, event or weather"
+// @Param        routeIDs     formData  string  false  "Comma separated GTFS route IDs the message applies to"
+// @Param        stopIDs      formData  string  false  "Comma separated GTFS stop IDs the message applies to"
+// @Param        activeFrom   formData  string  false  "RFC 3339 time the message applies from"
+// @Param        activeUntil  formData  string  false  "RFC 3339 time the message expires at"
 // @Security     ApiKeyAuth
 // @Success      202  {object}  api.PutMessageResponse
 // @Failure      400  {object}  api.Error
 // @Failure      500  {object}  api.Error
+// @Failure      503  {object}  api.Error
 // @Router       /messages/ [put]
-func PutMessage(sm tools.ObjectStorageManager) http.HandlerFunc {
+func PutMessage(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("Handling PutMessage request")
 		message := r.FormValue("message")
@@ -36,17 +46,16 @@ func PutMessage(sm tools.ObjectStorageManager) http.HandlerFunc {
 
 		log.Debugf("Received message to store, length: %d", len(message))
 
-		messageObj := tools.NewMessage(message)
-		b := bytes.Buffer{}
-		writer := jsonl.NewWriter(&b)
-		err := writer.Write(messageObj)
+		messageObj, err := messageFromForm(r, message, time.Now())
 		if err != nil {
-			log.Error(err)
-			api.InternalErrorHandler(w)
+			api.RequestErrorHandler(w, err)
+			return
+		}
+		if !validateMessageScope(w, messageObj, ss) {
 			return
 		}
 
-		versionID, err := sm.AppendMessage(&b)
+		versionID, err := messages.Append(sm, messageObj)
 		if err != nil {
 			log.Error(err)
 			api.InternalErrorHandler(w)
@@ -56,6 +65,7 @@ func PutMessage(sm tools.ObjectStorageManager) http.HandlerFunc {
 		response := api.PutMessageResponse{
 			Code:      http.StatusAccepted,
 			VersionID: versionID,
+			ID:        messageObj.ID,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
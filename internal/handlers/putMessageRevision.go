@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// EditMessage godoc
+// @Summary      Edit a message
+// @Description  Replaces a message with a revised one, appended to the message log as a revision. The revision keeps the ID and posting time of the message and takes the same fields as a new message, which replace all of its fields. Requires API key authentication.
+// @Tags         messages
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        messageID    path      string  true   "Message ID"
+// @Param        message      formData  string  true   "Message content"
+// @Param        severity     formData  string  false  "INFO, WARNING or SEVERE"
+// @Param        cause        formData  string  false  "GTFS Realtime cause, such as CONSTRUCTION"
+// @Param        effect       formData  string  false  "GTFS Realtime effect, such as DETOUR"
+// @Param        category     formData  string  false  "general, disruption, diversion, plannedWorks, event or weather"
+// @Param        routeIDs     formData  string  false  "Comma separated GTFS route IDs the message applies to"
+// @Param        stopIDs      formData  string  false  "Comma separated GTFS stop IDs the message applies to"
+// @Param        activeFrom   formData  string  false  "RFC 3339 time the message applies from"
+// @Param        activeUntil  formData  string  false  "RFC 3339 time the message expires at"
+// @Security     ApiKeyAuth
+// @Success      202  {object}  api.PutMessageResponse
+// @Failure      400  {object}  api.Error
+// @Failure      404  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Failure      503  {object}  api.Error
+// @Router       /messages/{messageID} [put]
+func EditMessage(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling EditMessage request")
+		message := r.FormValue("message")
+
+		if message == "" {
+			log.Debug("Message parameter missing in request")
+			http.Error(w, "message parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		revised, err := messageFromForm(r, message, time.Now())
+		if err != nil {
+			api.RequestErrorHandler(w, err)
+			return
+		}
+		if !validateMessageScope(w, revised, ss) {
+			return
+		}
+
+		original, ok := findMessage(w, sm, chi.URLParam(r, "messageID"))
+		if !ok {
+			return
+		}
+
+		revision := tools.NewRevision(original, revised)
+		log.Debugf("Appending revision of message %s", revision.ID)
+		versionID, err := messages.Append(sm, revision)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		response := api.PutMessageResponse{
+			Code:      http.StatusAccepted,
+			VersionID: versionID,
+			ID:        revision.ID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.Code)
+		if err = json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
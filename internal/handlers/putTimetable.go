@@ -4,27 +4,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
 	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
 // PutGTFSSchedule godoc
 // @Summary      Upload a new GTFS schedule package
-// @Description  Uploads a new GTFS schedule zip file to the storage system. Requires API key authentication.
+// @Description  Validates and uploads a new GTFS schedule zip file to the storage system. Feeds with missing files or columns, malformed values or broken references between files are rejected with the list of problems found. Requires API key authentication.
 // @Tags         schedule
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        GTFSSchedule  formData  file  true  "GTFS Schedule zip file"
+// @Param        GTFSSchedule  formData  file    true   "GTFS Schedule zip file"
+// @Param        If-Match      header    string  false  "Only publish if this is still the latest schedule version ID"
 // @Security     ApiKeyAuth
 // @Success      202  {object}  api.PutTimetableResponse
-// @Failure      400  {object}  api.Error
+// @Failure      400  {object}  api.GTFSValidationErrorResponse
+// @Failure      412  {object}  api.Error
 // @Failure      500  {object}  api.Error
 // @Router       /schedule/ [put]
-func PutGTFSSchedule(sm tools.ObjectStorageManager) http.HandlerFunc {
+func PutGTFSSchedule(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("Handling PutGTFSSchedule request")
 		file, fileHeader, err := r.FormFile("GTFSSchedule")
@@ -53,16 +59,44 @@ func PutGTFSSchedule(sm tools.ObjectStorageManager) http.HandlerFunc {
 			return
 		}
 
-		versionID, err := sm.PutSchedule(file, fileHeader.Size)
+		report := gtfs.Validate(file, fileHeader.Size)
+		if !report.Valid() {
+			log.Debugf("Rejected GTFS schedule with %d errors and %d warnings", report.ErrorCount, report.WarningCount)
+			api.GTFSValidationErrorHandler(w, api.GTFSValidationErrorResponse{
+				Errors:       toAPIValidationIssues(report.Errors),
+				Warnings:     toAPIValidationIssues(report.Warnings),
+				ErrorCount:   report.ErrorCount,
+				WarningCount: report.WarningCount,
+			})
+			return
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		expectedVersionID := strings.Trim(r.Header.Get("If-Match"), `"`)
+		versionID, err := sm.PutSchedule(file, fileHeader.Size, expectedVersionID)
 		if err != nil {
+			if errors.Is(err, tools.GTFSVersionConflict) {
+				log.Debug(err)
+				api.PreconditionFailedErrorHandler(w, fmt.Errorf("schedule is no longer at version %s", expectedVersionID))
+				return
+			}
 			log.Error(err)
 			api.InternalErrorHandler(w)
 			return
 		}
 
+		if err = ss.Load(); err != nil {
+			log.Errorf("Failed to load uploaded GTFS schedule: %v", err)
+		}
+
 		response := api.PutTimetableResponse{
 			Code:      http.StatusAccepted,
 			VersionID: versionID,
+			Warnings:  toAPIValidationIssues(report.Warnings),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -74,3 +108,14 @@ func PutGTFSSchedule(sm tools.ObjectStorageManager) http.HandlerFunc {
 		}
 	}
 }
+
+func toAPIValidationIssues(issues []gtfs.Issue) []api.ValidationIssue {
+	if issues == nil {
+		return nil
+	}
+	result := make([]api.ValidationIssue, len(issues))
+	for i, issue := range issues {
+		result[i] = api.ValidationIssue{File: issue.File, Line: issue.Line, Field: issue.Field, Message: issue.Message}
+	}
+	return result
+}
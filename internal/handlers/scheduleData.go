@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// currentSchedule returns the loaded GTFS schedule, or writes a 503 response and returns nil
+// if no schedule has been loaded yet.
+func currentSchedule(w http.ResponseWriter, ss *schedule.Store) *schedule.Schedule {
+	current := ss.Current()
+	if current == nil {
+		api.ServiceUnavailableErrorHandler(w, errors.New("no GTFS schedule has been loaded yet"))
+	}
+	return current
+}
+
+func toAPIStop(stop *gtfs.Stop) api.Stop {
+	s := api.Stop{
+		StopID:             stop.ID,
+		Code:               stop.Code,
+		Name:               stop.Name,
+		Lat:                stop.Lat,
+		Lon:                stop.Lon,
+		LocationType:       stop.LocationType,
+		PlatformCode:       stop.PlatformCode,
+		WheelchairBoarding: stop.WheelchairBoarding,
+	}
+	if stop.Parent != nil {
+		s.ParentStationID = stop.Parent.ID
+	}
+	return s
+}
+
+func toAPIStops(stops []*gtfs.Stop) []api.Stop {
+	result := make([]api.Stop, len(stops))
+	for i, stop := range stops {
+		result[i] = toAPIStop(stop)
+	}
+	return result
+}
+
+func toAPIRoute(route *gtfs.Route) api.Route {
+	return api.Route{
+		RouteID:   route.ID,
+		Agency:    route.Agency.Name,
+		ShortName: route.ShortName,
+		LongName:  route.LongName,
+		Type:      route.Type,
+		Color:     route.Color,
+		TextColor: route.TextColor,
+	}
+}
+
+func toAPIRoutes(routes []*gtfs.Route) []api.Route {
+	result := make([]api.Route, len(routes))
+	for i, route := range routes {
+		result[i] = toAPIRoute(route)
+	}
+	return result
+}
+
+func toAPIDeparture(departure realtime.LiveDeparture) api.Departure {
+	trip := departure.StopTime.Trip
+	d := api.Departure{
+		TripID:         trip.ID,
+		RouteID:        trip.Route.ID,
+		RouteShortName: trip.Route.Name(),
+		Headsign:       departure.Headsign(),
+		StopID:         departure.StopTime.Stop.ID,
+		ServiceDate:    departure.ServiceDay.Format("20060102"),
+		ScheduledTime:  departure.Time.In(schedule.Location),
+		Status:         string(departure.Status),
+		EstimatedTime:  departure.Estimated.In(schedule.Location),
+	}
+	if departure.Vehicle != nil {
+		delay := int(departure.Vehicle.Delay.Seconds())
+		d.DelaySeconds = &delay
+		d.BusID = departure.Vehicle.Location.BusID
+	}
+	return d
+}
+
+func toAPIBusLocation(location tools.BusLocation) api.BusLocation {
+	return api.BusLocation{
+		BusID:         location.BusID,
+		DepartureTime: location.DepartureTime,
+		RouteNumber:   location.RouteNumber,
+		Direction:     location.Direction,
+		Latitude:      location.Latitude,
+		Longitude:     location.Longitude,
+	}
+}
+
+func toAPITrackedBus(vehicle realtime.Vehicle) api.TrackedBus {
+	bus := api.TrackedBus{BusLocation: toAPIBusLocation(vehicle.Location)}
+	if vehicle.Route != nil {
+		bus.RouteID = vehicle.Route.ID
+	}
+	if vehicle.Trip != nil {
+		bus.TripID = vehicle.Trip.ID
+		bus.MatchConfidence = vehicle.Confidence
+		delay := int(vehicle.Delay.Seconds())
+		bus.DelaySeconds = &delay
+	}
+	return bus
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+const (
+	// streamKeepAliveInterval is how often an idle stream sends a comment so proxies keep it open
+	streamKeepAliveInterval = 15 * time.Second
+	// streamRetry is how long clients are asked to wait before reconnecting, in milliseconds
+	streamRetry = 5000
+)
+
+// locationFilter selects the buses a stream is about. Empty fields select every bus.
+type locationFilter struct {
+	routes []string
+	// bbox is the minimum longitude and latitude followed by the maximum ones
+	bbox []float64
+}
+
+func parseLocationFilter(r *http.Request) (locationFilter, error) {
+	var filter locationFilter
+	for _, route := range strings.Split(r.URL.Query().Get("route"), ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			filter.routes = append(filter.routes, route)
+		}
+	}
+
+	if bbox := r.URL.Query().Get("bbox"); bbox != "" {
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return filter, fmt.Errorf("invalid bbox %q, expected minLon,minLat,maxLon,maxLat", bbox)
+		}
+		for _, part := range parts {
+			f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid bbox %q, expected minLon,minLat,maxLon,maxLat", bbox)
+			}
+			filter.bbox = append(filter.bbox, f)
+		}
+		if filter.bbox[0] > filter.bbox[2] || filter.bbox[1] > filter.bbox[3] {
+			return filter, fmt.Errorf("invalid bbox %q, the minimum is above the maximum", bbox)
+		}
+	}
+	return filter, nil
+}
+
+func (f locationFilter) matches(bus tools.BusLocation) bool {
+	if len(f.routes) > 0 && !slices.Contains(f.routes, bus.RouteNumber) {
+		return false
+	}
+	if f.bbox != nil && (bus.Longitude < f.bbox[0] || bus.Latitude < f.bbox[1] || bus.Longitude > f.bbox[2] || bus.Latitude > f.bbox[3]) {
+		return false
+	}
+	return true
+}
+
+// delta returns the event to send a client for a change, given the buses it was sent and has not
+// been told are gone, which it updates. A bus leaving the filter is sent as removed.
+func (f locationFilter) delta(sent map[string]bool, change tools.LocationChange) (kind string, ok bool) {
+	busID := change.Location.BusID
+	switch {
+	case change.Kind == tools.LocationExpired:
+		if !sent[busID] {
+			return "", false
+		}
+		delete(sent, busID)
+		return string(tools.LocationExpired), true
+	case f.matches(change.Location):
+		if !sent[busID] {
+			sent[busID] = true
+			return string(tools.LocationAdded), true
+		}
+		return string(tools.LocationUpdated), true
+	case sent[busID]:
+		delete(sent, busID)
+		return "removed", true
+	}
+	return "", false
+}
+
+// StreamBusLocations godoc
+// @Summary      Stream bus locations
+// @Description  Streams changes to the tracked buses as Server-Sent Events. The stream starts with an "added" event for every tracked bus, followed by "added", "updated" and "expired" events as buses start being tracked, move and stop being tracked. With filters, only the buses on the given routes and within the bounding box are sent, and a bus that leaves them is sent as "removed". Clients reconnecting with the Last-Event-ID header, or the lastEventId parameter, resume after that event if it is recent enough, and otherwise start over. Buses are not matched to trips; use /locations for that.
+// @Tags         locations
+// @Produce      text/event-stream
+// @Param        route        query   string  false  "Comma separated route numbers to stream the buses of"
+// @Param        bbox         query   string  false  "Bounding box to stream the buses within, as minLon,minLat,maxLon,maxLat"
+// @Param        lastEventId  query   string  false  "ID of the last event received, for clients that cannot set the Last-Event-ID header"
+// @Param        Last-Event-ID  header  string  false  "ID of the last event received"
+// @Success      200  {object}  api.LocationEvent
+// @Failure      400  {object}  api.Error
+// @Failure      500  {object}  api.Error
+// @Router       /locations/stream [get]
+func StreamBusLocations(ctx context.Context, feed *tools.LocationFeed) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling StreamBusLocations request")
+
+		filter, err := parseLocationFilter(r)
+		if err != nil {
+			api.RequestErrorHandler(w, err)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Error("Response writer does not support streaming")
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("lastEventId")
+		}
+		sub := feed.Subscribe(lastEventID)
+		defer sub.Close()
+
+		// a resumed client may hold any of the tracked buses, so any of them leaving the filter is
+		// sent as removed
+		sent := make(map[string]bool)
+		if sub.Resumed {
+			for _, busID := range sub.Tracked {
+				sent[busID] = true
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+
+		send := func(event tools.LocationEvent) error {
+			kind, ok := filter.delta(sent, event.LocationChange)
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(api.LocationEvent{BusLocation: toAPIBusLocation(event.Location), LastSeen: event.Location.Timestamp})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, kind, data)
+			return err
+		}
+
+		for _, event := range sub.Backlog {
+			if err := send(event); err != nil {
+				log.Debugf("Location stream closed: %v", err)
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ctx.Done():
+				log.Debug("Closing location stream as the server is shutting down")
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					log.Debugf("Location stream closed: %v", err)
+					return
+				}
+			case event, ok := <-sub.Events():
+				if !ok {
+					log.Debug("Location stream fell behind, closing it so the client resumes")
+					return
+				}
+				if err := send(event); err != nil {
+					log.Debugf("Location stream closed: %v", err)
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
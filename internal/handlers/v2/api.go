@@ -0,0 +1,78 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	httpSwagger "github.com/swaggo/http-swagger"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	_ "github.com/transitIOM/projectMercury/docs/v2"
+	internalMiddleware "github.com/transitIOM/projectMercury/internal/middleware"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// @title           Project Mercury
+// @version         v2.0.0
+// @description     Version 2 of the Project Mercury REST API. Every response is JSON with the requested data under "data" and details about it under "meta", and every error has the same shape under "error". Resources not covered by v2 yet are served by v1 unchanged.
+// @termsOfService  coming soon
+
+// @contact.name   Jayden Thompson
+// @contact.email  admin@transitIOM.com
+
+// @license.name  Apache 2.0
+// @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @host      api.transitiom.com
+// @BasePath  /v2
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
+// Router returns the router of the v2 API, meant to be mounted at /v2 next to v1.
+func Router(sm tools.ObjectStorageManager, ss *schedule.Store, tracker *realtime.Tracker, buses func() []tools.BusLocation) chi.Router {
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		api.NotFoundErrorHandler(w, fmt.Errorf("%s not found", r.URL.Path))
+	})
+	r.MethodNotAllowed(api.MethodNotAllowedErrorHandler)
+
+	r.Get("/docs/*", httpSwagger.Handler(httpSwagger.InstanceName("v2")))
+
+	r.Route("/messages", func(r chi.Router) {
+		r.Use(limitByIP(60, time.Minute))
+		// public routes
+		r.Group(func(r chi.Router) {
+			r.Get("/", GetMessages(sm))
+			r.Get("/{messageID}", GetMessage(sm))
+		})
+		// private routes
+		r.Group(func(r chi.Router) {
+			r.Use(internalMiddleware.APIKeyAuthWith(api.UnauthorizedErrorHandler))
+			r.Post("/", PostMessage(sm, ss))
+			r.Put("/{messageID}", EditMessage(sm, ss))
+			r.Delete("/{messageID}", RetractMessage(sm))
+		})
+	})
+
+	r.Route("/locations", func(r chi.Router) {
+		r.Use(limitByIP(3, time.Second))
+		r.Get("/", GetBusLocations(tracker, buses))
+	})
+
+	return r
+}
+
+// limitByIP limits the rate of requests from each IP address like httprate.LimitByIP, answering
+// requests over the limit with a v2 error.
+func limitByIP(requestLimit int, windowLength time.Duration) func(http.Handler) http.Handler {
+	return httprate.Limit(requestLimit, windowLength,
+		httprate.WithKeyFuncs(httprate.KeyByIP),
+		httprate.WithLimitHandler(api.TooManyRequestsErrorHandler),
+	)
+}
@@ -0,0 +1,49 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// RetractMessage godoc
+// @Summary      Retract a message
+// @Description  Withdraws a message by appending a tombstone for it to the message log, and returns the message as it was with the time it was retracted. Requires API key authentication.
+// @Tags         messages
+// @Produce      json
+// @Param        messageID  path      string  true  "Message ID"
+// @Security     ApiKeyAuth
+// @Success      200  {object}  api.MessageResponse
+// @Failure      401  {object}  api.ErrorResponse
+// @Failure      404  {object}  api.ErrorResponse
+// @Failure      500  {object}  api.ErrorResponse
+// @Router       /messages/{messageID} [delete]
+func RetractMessage(sm tools.ObjectStorageManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling v2 RetractMessage request")
+
+		original, ok := findMessage(w, sm, chi.URLParam(r, "messageID"))
+		if !ok {
+			return
+		}
+
+		tombstone := tools.NewTombstone(original)
+		versionID, err := messages.Append(sm, tombstone)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		retracted := toAPIMessage(original)
+		retracted.RetractedAt = optionalTime(tombstone.RetractedAt)
+		api.Write(w, http.StatusOK, api.MessageResponse{
+			Data: retracted,
+			Meta: api.Meta{VersionID: versionID},
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// GetBusLocations godoc
+// @Summary      Get all current bus locations
+// @Description  Returns every bus on the tracker, matched to the GTFS route and trip it is most likely running with a confidence between 0 and 1, and how many seconds late it is running. Buses that match no trip, or all buses while no schedule is loaded, are returned without a route and trip.
+// @Tags         locations
+// @Produce      json
+// @Success      200  {object}  api.VehiclesResponse
+// @Failure      429  {object}  api.ErrorResponse
+// @Router       /locations [get]
+func GetBusLocations(tracker *realtime.Tracker, buses func() []tools.BusLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling v2 GetBusLocations request")
+
+		vehicles := tracker.Vehicles(buses())
+		response := api.VehiclesResponse{Data: make([]api.Vehicle, len(vehicles))}
+		for i, vehicle := range vehicles {
+			response.Data[i] = toAPIVehicle(vehicle)
+		}
+
+		api.Write(w, http.StatusOK, response)
+	}
+}
+
+func toAPIVehicle(vehicle realtime.Vehicle) api.Vehicle {
+	location := vehicle.Location
+	v := api.Vehicle{
+		BusID:         location.BusID,
+		RouteNumber:   location.RouteNumber,
+		Direction:     location.Direction,
+		DepartureTime: location.DepartureTime,
+		Lat:           location.Latitude,
+		Lon:           location.Longitude,
+	}
+	if !location.Timestamp.IsZero() {
+		v.LastSeen = &location.Timestamp
+	}
+	if vehicle.Route != nil {
+		v.RouteID = vehicle.Route.ID
+	}
+	if vehicle.Trip != nil {
+		v.TripID = vehicle.Trip.ID
+		v.MatchConfidence = vehicle.Confidence
+		delay := int(vehicle.Delay.Seconds())
+		v.DelaySeconds = &delay
+	}
+	return v
+}
@@ -0,0 +1,33 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// GetMessage godoc
+// @Summary      Get a message
+// @Description  Returns a message in its latest revision, including after it expired. Retracted messages are not found.
+// @Tags         messages
+// @Produce      json
+// @Param        messageID  path      string  true  "Message ID"
+// @Success      200  {object}  api.MessageResponse
+// @Failure      404  {object}  api.ErrorResponse
+// @Failure      500  {object}  api.ErrorResponse
+// @Router       /messages/{messageID} [get]
+func GetMessage(sm tools.ObjectStorageManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling v2 GetMessage request")
+
+		message, ok := findMessage(w, sm, chi.URLParam(r, "messageID"))
+		if !ok {
+			return
+		}
+
+		api.Write(w, http.StatusOK, api.MessageResponse{Data: toAPIMessage(message)})
+	}
+}
@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+const (
+	defaultMessageLimit = 20
+	maxMessageLimit     = 100
+)
+
+// GetMessages godoc
+// @Summary      List the latest messages
+// @Description  Returns the latest messages, oldest first, in their latest revision. Retracted and expired messages are left out.
+// @Description  Pass since to only get the messages posted after a message or time, for polling. Messages posted before it but edited after it are returned in their current state, and messages retracted after it are returned as tombstones with only their ID, posting time and retraction time. When more messages match than the limit, meta.nextCursor is set, and passing it as cursor with the same since returns the page of messages before them.
+// @Tags         messages
+// @Produce      json
+// @Param        limit   query     int     false  "Number of messages to return, up to 100 (defaults to 20)"
+// @Param        since   query     string  false  "ID of a message or RFC 3339 time to get the messages posted, edited or retracted after"
+// @Param        cursor  query     string  false  "meta.nextCursor of the previous page"
+// @Success      200  {object}  api.MessagesResponse
+// @Failure      400  {object}  api.ErrorResponse
+// @Failure      500  {object}  api.ErrorResponse
+// @Router       /messages [get]
+func GetMessages(sm tools.ObjectStorageManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling v2 GetMessages request")
+
+		query := messages.Query{Since: r.URL.Query().Get("since"), Limit: defaultMessageLimit}
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 || n > maxMessageLimit {
+				api.RequestErrorHandler(w, errors.New("limit must be a number from 1 to 100"))
+				return
+			}
+			query.Limit = n
+		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			before, err := messages.DecodeCursor(cursor)
+			if err != nil {
+				api.RequestErrorHandler(w, err)
+				return
+			}
+			query.Before = before
+		}
+
+		response := api.MessagesResponse{Data: []api.Message{}}
+
+		versionID, err := sm.GetLatestMessageVersionID()
+		if errors.Is(err, tools.NoMessageLogFound) {
+			api.Write(w, http.StatusOK, response)
+			return
+		}
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		page, err := messages.List(sm, query, time.Now())
+		if errors.Is(err, messages.NotFound) {
+			api.RequestErrorHandler(w, err)
+			return
+		}
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		for _, message := range page.Messages {
+			response.Data = append(response.Data, toAPIMessage(message))
+		}
+		response.Meta = api.Meta{VersionID: versionID, NextCursor: page.NextCursor}
+		api.Write(w, http.StatusOK, response)
+	}
+}
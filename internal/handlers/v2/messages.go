@@ -0,0 +1,105 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// maxMessageRequestSize is the largest body accepted when posting or editing a message
+const maxMessageRequestSize = 64 << 10
+
+// messageFromRequest builds a message from the JSON body of a request, writing an error response
+// if the body is invalid or names routes or stops that are not in the current schedule.
+func messageFromRequest(w http.ResponseWriter, r *http.Request, ss *schedule.Store, now time.Time) (tools.MessageLog, bool) {
+	var body api.MessageRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMessageRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		api.RequestErrorHandler(w, fmt.Errorf("invalid message: %w", err))
+		return tools.MessageLog{}, false
+	}
+	if body.Message == "" {
+		api.RequestErrorHandler(w, errors.New("message is required"))
+		return tools.MessageLog{}, false
+	}
+
+	m := tools.NewMessage(body.Message)
+	m.Severity = body.Severity
+	m.Cause = body.Cause
+	m.Effect = body.Effect
+	m.Category = body.Category
+	m.RouteIDs = body.RouteIDs
+	m.StopIDs = body.StopIDs
+	m.ActiveFrom = body.ActiveFrom
+	m.ActiveUntil = body.ActiveUntil
+
+	m, err := messages.Validate(m, now)
+	if err != nil {
+		api.RequestErrorHandler(w, err)
+		return m, false
+	}
+	if messages.Scoped(m) {
+		current := ss.Current()
+		if current == nil {
+			api.ServiceUnavailableErrorHandler(w, errors.New("no GTFS schedule has been loaded yet"))
+			return m, false
+		}
+		if err = messages.ValidateScope(m, current); err != nil {
+			api.RequestErrorHandler(w, err)
+			return m, false
+		}
+	}
+	return m, true
+}
+
+// findMessage returns the current state of a message, writing an error response if it does not
+// exist, was retracted or the log cannot be read.
+func findMessage(w http.ResponseWriter, sm tools.ObjectStorageManager, messageID string) (tools.MessageLog, bool) {
+	message, err := messages.Find(sm, messageID)
+	if errors.Is(err, messages.NotFound) {
+		api.NotFoundErrorHandler(w, err)
+		return message, false
+	}
+	if err != nil {
+		log.Error(err)
+		api.InternalErrorHandler(w)
+		return message, false
+	}
+	return message, true
+}
+
+func toAPIMessage(m tools.MessageLog) api.Message {
+	return api.Message{
+		ID:          m.MessageID(),
+		PostedAt:    m.Time(),
+		EditedAt:    optionalTime(m.EditedAt),
+		RetractedAt: optionalTime(m.RetractedAt),
+		Message:     m.Message,
+		Severity:    m.Severity,
+		Cause:       m.Cause,
+		Effect:      m.Effect,
+		Category:    m.Category,
+		RouteIDs:    m.RouteIDs,
+		StopIDs:     m.StopIDs,
+		ActiveFrom:  optionalTime(m.ActiveFrom),
+		ActiveUntil: optionalTime(m.ActiveUntil),
+	}
+}
+
+// optionalTime parses an RFC 3339 timestamp, returning nil if it is empty or invalid.
+func optionalTime(timestamp string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,49 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// PostMessage godoc
+// @Summary      Post a message
+// @Description  Appends a new message to the message log and returns it. Routes and stops are validated against the current GTFS schedule. Requires API key authentication.
+// @Tags         messages
+// @Accept       json
+// @Produce      json
+// @Param        message  body      api.MessageRequest  true  "Message"
+// @Security     ApiKeyAuth
+// @Success      201  {object}  api.MessageResponse
+// @Failure      400  {object}  api.ErrorResponse
+// @Failure      401  {object}  api.ErrorResponse
+// @Failure      500  {object}  api.ErrorResponse
+// @Failure      503  {object}  api.ErrorResponse
+// @Router       /messages [post]
+func PostMessage(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling v2 PostMessage request")
+
+		message, ok := messageFromRequest(w, r, ss, time.Now())
+		if !ok {
+			return
+		}
+
+		versionID, err := messages.Append(sm, message)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		api.Write(w, http.StatusCreated, api.MessageResponse{
+			Data: toAPIMessage(message),
+			Meta: api.Meta{VersionID: versionID},
+		})
+	}
+}
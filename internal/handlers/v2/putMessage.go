@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// EditMessage godoc
+// @Summary      Edit a message
+// @Description  Replaces a message with a revised one, appended to the message log as a revision, and returns it. The revision keeps the ID and posting time of the message, and its fields replace all of those of the message. Requires API key authentication.
+// @Tags         messages
+// @Accept       json
+// @Produce      json
+// @Param        messageID  path      string              true  "Message ID"
+// @Param        message    body      api.MessageRequest  true  "Revised message"
+// @Security     ApiKeyAuth
+// @Success      200  {object}  api.MessageResponse
+// @Failure      400  {object}  api.ErrorResponse
+// @Failure      401  {object}  api.ErrorResponse
+// @Failure      404  {object}  api.ErrorResponse
+// @Failure      500  {object}  api.ErrorResponse
+// @Failure      503  {object}  api.ErrorResponse
+// @Router       /messages/{messageID} [put]
+func EditMessage(sm tools.ObjectStorageManager, ss *schedule.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Handling v2 EditMessage request")
+
+		revised, ok := messageFromRequest(w, r, ss, time.Now())
+		if !ok {
+			return
+		}
+		original, ok := findMessage(w, sm, chi.URLParam(r, "messageID"))
+		if !ok {
+			return
+		}
+
+		revision := tools.NewRevision(original, revised)
+		versionID, err := messages.Append(sm, revision)
+		if err != nil {
+			log.Error(err)
+			api.InternalErrorHandler(w)
+			return
+		}
+
+		api.Write(w, http.StatusOK, api.MessageResponse{
+			Data: toAPIMessage(revision),
+			Meta: api.Meta{VersionID: versionID},
+		})
+	}
+}
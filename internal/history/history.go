@@ -0,0 +1,171 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// MaxTrackRange bounds how long a track can be read at once, as each hour is a separate request.
+const MaxTrackRange = 24 * time.Hour
+
+// InvalidRange is returned for a track that ends before it starts or is longer than MaxTrackRange
+var InvalidRange = errors.New("invalid time range")
+
+// Position is a fix of a bus as stored in its history. Keys are kept short as every fix is stored.
+type Position struct {
+	Time          time.Time `json:"t"`
+	Latitude      float64   `json:"lat"`
+	Longitude     float64   `json:"lon"`
+	RouteNumber   string    `json:"r,omitempty"`
+	Direction     string    `json:"d,omitempty"`
+	DepartureTime string    `json:"dep,omitempty"`
+}
+
+func newPosition(bus tools.BusLocation) Position {
+	return Position{
+		Time:          bus.Timestamp.UTC(),
+		Latitude:      bus.Latitude,
+		Longitude:     bus.Longitude,
+		RouteNumber:   bus.RouteNumber,
+		Direction:     bus.Direction,
+		DepartureTime: bus.DepartureTime,
+	}
+}
+
+// Recorder collects the fixes of tracked buses and appends them to their history in batches, so
+// storage is written once per bus and hour every flush rather than on every frame.
+type Recorder struct {
+	hs tools.PositionHistoryStorage
+
+	mutex sync.Mutex
+	// recorded is the time of the last fix recorded for each bus, so fixes repeated in later
+	// frames are only stored once
+	recorded map[string]time.Time
+	pending  map[string][]Position
+}
+
+func NewRecorder(hs tools.PositionHistoryStorage) *Recorder {
+	return &Recorder{
+		hs:       hs,
+		recorded: make(map[string]time.Time),
+		pending:  make(map[string][]Position),
+	}
+}
+
+// Record queues the fixes of buses that changed since they were last recorded. It is meant to be
+// called with all tracked buses whenever new locations are received.
+func (r *Recorder) Record(buses []tools.BusLocation) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, bus := range buses {
+		if bus.Timestamp.IsZero() || !bus.Timestamp.After(r.recorded[bus.BusID]) {
+			continue
+		}
+		r.recorded[bus.BusID] = bus.Timestamp
+		r.pending[bus.BusID] = append(r.pending[bus.BusID], newPosition(bus))
+	}
+}
+
+// Run flushes the queued fixes at every interval until the context is cancelled, then flushes
+// them one last time.
+func (r *Recorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.Flush()
+			return
+		case <-ticker.C:
+			r.Flush()
+		}
+	}
+}
+
+// Flush appends the queued fixes to the history of their bus. Fixes that cannot be stored are
+// logged and dropped, so a storage outage does not grow the queue without bound.
+func (r *Recorder) Flush() {
+	r.mutex.Lock()
+	pending := r.pending
+	r.pending = make(map[string][]Position)
+	r.mutex.Unlock()
+
+	for busID, positions := range pending {
+		for hour, positions := range byHour(positions) {
+			b := bytes.Buffer{}
+			encoder := json.NewEncoder(&b)
+			for _, position := range positions {
+				if err := encoder.Encode(position); err != nil {
+					log.Errorf("Failed to encode position of bus %s: %v", busID, err)
+				}
+			}
+			if err := r.hs.AppendPositions(busID, hour, &b); err != nil {
+				log.Errorf("Failed to store %d positions of bus %s: %v", len(positions), busID, err)
+			}
+		}
+	}
+}
+
+func byHour(positions []Position) map[time.Time][]Position {
+	hours := make(map[time.Time][]Position)
+	for _, position := range positions {
+		hour := position.Time.Truncate(time.Hour)
+		hours[hour] = append(hours[hour], position)
+	}
+	return hours
+}
+
+// Track returns the positions of a bus recorded from one time up to and including another, oldest
+// first. Fixes stored more than once, such as by several replicas, are only returned once.
+func Track(hs tools.PositionHistoryStorage, busID string, from, to time.Time) ([]Position, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from %s is after to %s", InvalidRange, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	if to.Sub(from) > MaxTrackRange {
+		return nil, fmt.Errorf("%w: cannot read more than %s of history at once", InvalidRange, MaxTrackRange)
+	}
+
+	var track []Position
+	for hour := from.UTC().Truncate(time.Hour); !hour.After(to); hour = hour.Add(time.Hour) {
+		stored, err := hs.GetPositions(busID, hour)
+		if errors.Is(err, tools.NoPositionsFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for line := range bytes.Lines(stored.Bytes()) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			var position Position
+			if err := json.Unmarshal(line, &position); err != nil {
+				log.Warnf("Skipping invalid position of bus %s: %v", busID, err)
+				continue
+			}
+			if position.Time.Before(from) || position.Time.After(to) {
+				continue
+			}
+			track = append(track, position)
+		}
+	}
+
+	slices.SortStableFunc(track, func(a, b Position) int { return a.Time.Compare(b.Time) })
+	return slices.CompactFunc(track, func(a, b Position) bool {
+		a.Time, b.Time = a.Time.UTC(), b.Time.UTC()
+		return a == b
+	}), nil
+}
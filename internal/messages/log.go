@@ -0,0 +1,191 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/simonfrey/jsonl"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// NotFound is returned for a message that is not in the log or was retracted
+var NotFound = errors.New("message not found")
+
+// Query selects the messages posted after since and before a cursor.
+type Query struct {
+	// Since is the ID of a message or an RFC 3339 timestamp, or empty for no lower bound
+	Since string
+	// Before is the time decoded from a cursor, or zero for no upper bound
+	Before time.Time
+	// Limit is the most messages returned in a page, taken as one if it is less
+	Limit int
+}
+
+// Page is up to the limit of a query of the latest messages it selects, oldest first. If the
+// query selects older messages too, NextCursor resumes the query before the first of them.
+type Page struct {
+	Messages   []tools.MessageLog
+	NextCursor string
+}
+
+// List returns a page of the messages a query selects in their current state, leaving out
+// retracted and expired ones. With since, messages posted before it but edited after it are
+// selected too, and messages retracted after it are selected as their tombstones. The tail of the
+// log that is read grows until it holds more than a page of them, reaches back to since or covers
+// the whole log.
+func List(ms tools.MessageStorage, q Query, now time.Time) (Page, error) {
+	q.Limit = max(q.Limit, 1)
+	after, err := time.Parse(time.RFC3339Nano, q.Since)
+	resolved := q.Since == "" || err == nil
+
+	for tail := q.Limit + 1; ; tail *= 2 {
+		messageLog, err := ms.GetLatestLogTail(tail)
+		if err != nil {
+			return Page{}, err
+		}
+		// invalid lines are left out of the entries, so the lines read tell whether the tail is the
+		// whole log
+		whole := countLines(messageLog.Bytes()) < tail
+		entries := tools.ParseMessageLog(messageLog)
+
+		if !resolved {
+			i := slices.IndexFunc(entries, func(m tools.MessageLog) bool {
+				return m.IsOriginal() && m.MessageID() == q.Since
+			})
+			if i < 0 {
+				if whole {
+					return Page{}, fmt.Errorf("%w: %s", NotFound, q.Since)
+				}
+				continue
+			}
+			after, resolved = entries[i].Time(), true
+		}
+
+		changes := changedAfter(entries, after)
+		changed := make(map[string]bool, len(changes))
+		for _, change := range changes {
+			changed[change.MessageID()] = true
+		}
+
+		var selected []tools.MessageLog
+		for _, message := range tools.EffectiveMessages(entries) {
+			if changed[message.MessageID()] || (!after.IsZero() && !message.Time().After(after)) {
+				continue
+			}
+			selected = append(selected, message)
+		}
+		selected = append(selected, changes...)
+		selected = slices.DeleteFunc(selected, func(m tools.MessageLog) bool {
+			return m.Expired(now) || (!q.Before.IsZero() && !m.Time().Before(q.Before))
+		})
+		slices.SortStableFunc(selected, func(a, b tools.MessageLog) int {
+			return a.Time().Compare(b.Time())
+		})
+
+		// entries are appended in the order they are made, so a tail starting at or before since
+		// holds every message posted, edited or retracted after it
+		first := slices.IndexFunc(entries, tools.MessageLog.IsOriginal)
+		reachedSince := !after.IsZero() && first >= 0 && !entries[first].Time().After(after)
+
+		if len(selected) > q.Limit {
+			page := Page{Messages: selected[len(selected)-q.Limit:]}
+			page.NextCursor = EncodeCursor(page.Messages[0])
+			return page, nil
+		}
+		if whole || reachedSince {
+			return Page{Messages: selected}, nil
+		}
+	}
+}
+
+// changedAfter returns the latest revision or tombstone of each message edited or retracted
+// after a time, in the order they were first changed. A revision holds the whole message, so it
+// stands in for a message posted before the entries. A tombstone is never replaced, so a message
+// retracted after the time is returned as its tombstone. It returns nil for a zero time.
+func changedAfter(entries []tools.MessageLog, after time.Time) []tools.MessageLog {
+	if after.IsZero() {
+		return nil
+	}
+
+	var changes []tools.MessageLog
+	index := make(map[string]int)
+	for _, entry := range entries {
+		changedAt := entry.EditedAt
+		if entry.RetractedAt != "" {
+			changedAt = entry.RetractedAt
+		}
+		t, err := time.Parse(time.RFC3339Nano, changedAt)
+		if err != nil || !t.After(after) {
+			continue
+		}
+
+		id := entry.MessageID()
+		i, ok := index[id]
+		switch {
+		case !ok:
+			index[id] = len(changes)
+			changes = append(changes, entry)
+		case changes[i].RetractedAt == "":
+			changes[i] = entry
+		}
+	}
+	return changes
+}
+
+// EncodeCursor returns a cursor selecting the messages posted before a message.
+func EncodeCursor(message tools.MessageLog) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(message.Timestamp))
+}
+
+// DecodeCursor returns the time before which a cursor selects messages.
+func DecodeCursor(cursor string) (time.Time, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	before, err := time.Parse(time.RFC3339Nano, string(b))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return before, nil
+}
+
+// Find returns the current state of a message, or NotFound if it does not exist or was retracted.
+func Find(ms tools.MessageStorage, messageID string) (tools.MessageLog, error) {
+	messageLog, err := ms.GetLatestLog()
+	if errors.Is(err, tools.NoMessageLogFound) {
+		return tools.MessageLog{}, fmt.Errorf("%w: %s", NotFound, messageID)
+	}
+	if err != nil {
+		return tools.MessageLog{}, err
+	}
+
+	for _, message := range tools.EffectiveMessages(tools.ParseMessageLog(messageLog)) {
+		if message.MessageID() == messageID {
+			return message, nil
+		}
+	}
+	return tools.MessageLog{}, fmt.Errorf("%w: %s", NotFound, messageID)
+}
+
+// Append appends an entry to the message log, returning the version ID of the log.
+func Append(ms tools.MessageStorage, message tools.MessageLog) (versionID string, err error) {
+	b := bytes.Buffer{}
+	if err = jsonl.NewWriter(&b).Write(message); err != nil {
+		return "", err
+	}
+	return ms.AppendMessage(&b)
+}
+
+// countLines counts the lines of a log, including a last one without a newline.
+func countLines(data []byte) int {
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
@@ -0,0 +1,98 @@
+package messages
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// Validate checks the fields of a message as submitted, returning it with severity, cause, effect
+// and category, which are matched ignoring case, under their canonical names, and without blank
+// or repeated route and stop IDs. The error describes the first invalid field.
+func Validate(m tools.MessageLog, now time.Time) (tools.MessageLog, error) {
+	if m.Severity != "" {
+		if _, ok := gtfsrt.ParseSeverityLevel(strings.ToUpper(m.Severity)); !ok {
+			return m, fmt.Errorf("invalid severity %q, expected INFO, WARNING or SEVERE", m.Severity)
+		}
+		m.Severity = strings.ToUpper(m.Severity)
+	}
+	if m.Cause != "" {
+		if _, ok := gtfsrt.ParseCause(strings.ToUpper(m.Cause)); !ok {
+			return m, fmt.Errorf("invalid cause %q, expected a GTFS Realtime cause such as CONSTRUCTION", m.Cause)
+		}
+		m.Cause = strings.ToUpper(m.Cause)
+	}
+	if m.Effect != "" {
+		if _, ok := gtfsrt.ParseEffect(strings.ToUpper(m.Effect)); !ok {
+			return m, fmt.Errorf("invalid effect %q, expected a GTFS Realtime effect such as DETOUR", m.Effect)
+		}
+		m.Effect = strings.ToUpper(m.Effect)
+	}
+	if m.Category != "" {
+		i := slices.IndexFunc(tools.MessageCategories, func(c string) bool { return strings.EqualFold(c, m.Category) })
+		if i < 0 {
+			return m, fmt.Errorf("invalid category %q, expected one of %s", m.Category, strings.Join(tools.MessageCategories, ", "))
+		}
+		m.Category = tools.MessageCategories[i]
+	}
+
+	m.RouteIDs = uniqueIDs(m.RouteIDs)
+	m.StopIDs = uniqueIDs(m.StopIDs)
+
+	var activeFrom, activeUntil time.Time
+	var err error
+	if m.ActiveFrom != "" {
+		if activeFrom, err = time.Parse(time.RFC3339, m.ActiveFrom); err != nil {
+			return m, fmt.Errorf("invalid activeFrom %q, expected RFC 3339", m.ActiveFrom)
+		}
+	}
+	if m.ActiveUntil != "" {
+		if activeUntil, err = time.Parse(time.RFC3339, m.ActiveUntil); err != nil {
+			return m, fmt.Errorf("invalid activeUntil %q, expected RFC 3339", m.ActiveUntil)
+		}
+		if !activeUntil.After(now) {
+			return m, fmt.Errorf("activeUntil %s has already passed", m.ActiveUntil)
+		}
+		if !activeFrom.IsZero() && !activeUntil.After(activeFrom) {
+			return m, fmt.Errorf("activeUntil %s is not after activeFrom %s", m.ActiveUntil, m.ActiveFrom)
+		}
+	}
+
+	return m, nil
+}
+
+func uniqueIDs(ids []string) []string {
+	var unique []string
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Scoped reports whether a message applies to particular routes or stops, which have to be
+// validated against the schedule.
+func Scoped(m tools.MessageLog) bool {
+	return len(m.RouteIDs) > 0 || len(m.StopIDs) > 0
+}
+
+// ValidateScope checks that the routes and stops a message applies to are in the schedule.
+func ValidateScope(m tools.MessageLog, sched *schedule.Schedule) error {
+	for _, routeID := range m.RouteIDs {
+		if _, ok := sched.Routes[routeID]; !ok {
+			return fmt.Errorf("route %s not found in GTFS schedule %s", routeID, sched.VersionID)
+		}
+	}
+	for _, stopID := range m.StopIDs {
+		if _, ok := sched.Stops[stopID]; !ok {
+			return fmt.Errorf("stop %s not found in GTFS schedule %s", stopID, sched.VersionID)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"crypto"
 	"crypto/subtle"
 	"encoding/hex"
@@ -15,6 +16,10 @@ import (
 
 var ExpectedHash string
 
+type contextKey string
+
+const apiKeyCtxKey contextKey = "apiKeyID"
+
 func init() {
 	if err := godotenv.Load(); err != nil {
 		log.Warn("No .env file found")
@@ -45,12 +50,26 @@ func init() {
 }
 
 func APIKeyAuth(next http.Handler) http.Handler {
+	return APIKeyAuthWith(func(w http.ResponseWriter, err error) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})(next)
+}
+
+// APIKeyAuthWith is APIKeyAuth with unauthorized requests answered by a handler, so APIs can
+// answer them in their own error format.
+func APIKeyAuthWith(unauthorized func(w http.ResponseWriter, err error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return apiKeyAuth(next, unauthorized)
+	}
+}
+
+func apiKeyAuth(next http.Handler, unauthorized func(w http.ResponseWriter, err error)) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("Authenticating request")
 		headerKey := r.Header.Get("X-API-Key")
 		if headerKey == "" {
 			log.Debug("X-API-Key header missing")
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			unauthorized(w, errors.New("X-API-Key header missing"))
 			return
 		}
 
@@ -61,14 +80,21 @@ func APIKeyAuth(next http.Handler) http.Handler {
 
 		if subtle.ConstantTimeCompare([]byte(userHash), []byte(ExpectedHash)) != 1 {
 			log.Debug("API key hash mismatch")
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			unauthorized(w, errors.New("invalid API key"))
 			return
 		}
 
 		log.Debug("Authentication successful")
-		// add info to context
-		//ctx := context.WithValue(r.Context(), apiKeyCtxKey, "admin")
+		// add a fingerprint of the key to the context, so handlers can attribute actions without logging the key
+		ctx := context.WithValue(r.Context(), apiKeyCtxKey, userHash[:12])
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// APIKeyID returns the fingerprint of the API key that authenticated the request,
+// or an empty string if the request did not pass through APIKeyAuth.
+func APIKeyID(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyCtxKey).(string)
+	return id
+}
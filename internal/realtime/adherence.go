@@ -0,0 +1,165 @@
+package realtime
+
+import (
+	"math"
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+)
+
+const (
+	// maxShapeDistance is how far in metres a bus may be from the shape of its trip for its position
+	// along the trip to be trusted. Further away, its delay is taken from the nearest stop instead.
+	maxShapeDistance = 500.0
+
+	// stopRadius is how far in metres past a stop a bus is still considered to be calling at it
+	stopRadius = 30.0
+)
+
+// pathPoint is a point of the path a trip follows, with its distance in metres along the path.
+type pathPoint struct {
+	lat, lon float64
+	along    float64
+}
+
+// tripPath is the path a trip follows, with the distance along it of each of its stops.
+type tripPath struct {
+	points []pathPoint
+	// stops holds the distance along the path of each stop time of the trip
+	stops []float64
+}
+
+// newTripPath builds the path of a trip from its shape, or from the positions of its stops if it
+// has none. Stops are placed on the shape in order, so a shape that passes a stop twice places it
+// on the pass that follows the previous stop. A trip without stop times has an empty path, which
+// no position is near.
+func newTripPath(trip *gtfs.Trip) *tripPath {
+	p := &tripPath{stops: make([]float64, len(trip.StopTimes))}
+	if len(trip.StopTimes) == 0 {
+		return p
+	}
+
+	if trip.Shape != nil && len(trip.Shape.Points) >= 2 {
+		for _, point := range trip.Shape.Points {
+			p.addPoint(point.Lat, point.Lon)
+		}
+		segment, along := 0, 0.0
+		for i, stopTime := range trip.StopTimes {
+			segment, along = p.project(stopTime.Stop.Lat, stopTime.Stop.Lon, segment, along)
+			p.stops[i] = along
+		}
+		return p
+	}
+
+	for i, stopTime := range trip.StopTimes {
+		p.addPoint(stopTime.Stop.Lat, stopTime.Stop.Lon)
+		p.stops[i] = p.points[len(p.points)-1].along
+	}
+	return p
+}
+
+func (p *tripPath) addPoint(lat, lon float64) {
+	along := 0.0
+	if n := len(p.points); n > 0 {
+		previous := p.points[n-1]
+		along = previous.along + distance(previous.lat, previous.lon, lat, lon)
+	}
+	p.points = append(p.points, pathPoint{lat, lon, along})
+}
+
+// project returns the segment of the path nearest to a position, starting from a segment and not
+// before a distance along the path, and the distance along the path of the nearest point on it.
+func (p *tripPath) project(lat, lon float64, fromSegment int, notBefore float64) (segment int, along float64) {
+	segment, along = fromSegment, notBefore
+	nearest := math.Inf(1)
+	for i := fromSegment; i < len(p.points)-1; i++ {
+		a, b := p.points[i], p.points[i+1]
+		fraction, metres := projectOnSegment(lat, lon, a, b)
+		if metres < nearest {
+			nearest = metres
+			segment, along = i, max(notBefore, a.along+fraction*(b.along-a.along))
+		}
+	}
+	return segment, along
+}
+
+// locate returns the distance along the path of the point nearest to a position, and how far the
+// position is from it in metres.
+func (p *tripPath) locate(lat, lon float64) (along float64, metres float64) {
+	if len(p.points) == 1 {
+		return 0, distance(lat, lon, p.points[0].lat, p.points[0].lon)
+	}
+
+	metres = math.Inf(1)
+	for i := 0; i < len(p.points)-1; i++ {
+		a, b := p.points[i], p.points[i+1]
+		fraction, d := projectOnSegment(lat, lon, a, b)
+		if d < metres {
+			metres, along = d, a.along+fraction*(b.along-a.along)
+		}
+	}
+	return along, metres
+}
+
+// projectOnSegment returns how far along a segment the point nearest to a position is, as a fraction
+// of its length, and the distance in metres to that point. The segment is treated as straight on a
+// local equirectangular projection, which is accurate enough for the short segments of a shape.
+func projectOnSegment(lat, lon float64, a, b pathPoint) (fraction float64, metres float64) {
+	scale := math.Cos(radians((a.lat + b.lat) / 2))
+	bx, by := (b.lon-a.lon)*scale, b.lat-a.lat
+	px, py := (lon-a.lon)*scale, lat-a.lat
+
+	if length := bx*bx + by*by; length > 0 {
+		fraction = min(1, max(0, (px*bx+py*by)/length))
+	}
+	nearestLat := a.lat + fraction*(b.lat-a.lat)
+	nearestLon := a.lon + fraction*(b.lon-a.lon)
+	return fraction, distance(lat, lon, nearestLat, nearestLon)
+}
+
+// scheduledAt returns the time in seconds since the start of the service day at which a trip is
+// scheduled to pass a distance along its path, interpolating between the departure from the stop
+// before it and the arrival at the stop after it.
+func (p *tripPath) scheduledAt(trip *gtfs.Trip, along float64) int {
+	stopTimes := trip.StopTimes
+	if len(stopTimes) == 0 || len(p.stops) == 0 {
+		return 0
+	}
+	if along <= p.stops[0] {
+		return stopTimes[0].Departure
+	}
+	for i := 1; i < len(stopTimes); i++ {
+		if along > p.stops[i] {
+			continue
+		}
+		from, to := stopTimes[i-1], stopTimes[i]
+		length := p.stops[i] - p.stops[i-1]
+		if length <= 0 {
+			return to.Arrival
+		}
+		fraction := (along - p.stops[i-1]) / length
+		return from.Departure + int(math.Round(fraction*float64(to.Arrival-from.Departure)))
+	}
+	return stopTimes[len(stopTimes)-1].Arrival
+}
+
+// adherence returns how late a matched vehicle is running, by projecting its position onto the
+// path of its trip and comparing the time it was reported with the time it was scheduled to be
+// there, along with the index of the next stop it will call at. It returns false if the vehicle is
+// too far from the path for the projection to be trusted.
+func (p *tripPath) adherence(v Vehicle) (delay time.Duration, nextStop int, ok bool) {
+	along, metres := p.locate(v.Location.Latitude, v.Location.Longitude)
+	if metres > maxShapeDistance {
+		return 0, 0, false
+	}
+	scheduled := v.ServiceDay.Add(time.Duration(p.scheduledAt(v.Trip, along)) * time.Second)
+
+	nextStop = len(p.stops)
+	for i, stopAlong := range p.stops {
+		if stopAlong+stopRadius >= along {
+			nextStop = i
+			break
+		}
+	}
+	return v.Location.Timestamp.Sub(scheduled).Truncate(time.Second), nextStop, true
+}
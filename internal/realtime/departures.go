@@ -0,0 +1,115 @@
+package realtime
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+)
+
+// Status describes where the time of a departure comes from.
+type Status string
+
+const (
+	// StatusScheduled is a departure without a tracked bus, expected on time
+	StatusScheduled Status = "scheduled"
+	// StatusLive is a departure with a tracked bus, with a time estimated from its position
+	StatusLive Status = "live"
+	// StatusNoShow is a departure whose trip should be running but has no tracked bus,
+	// which usually means it was cancelled
+	StatusNoShow Status = "noShow"
+)
+
+const (
+	// maxLateness is how long after its scheduled time a departure with a tracked bus is still listed
+	maxLateness = 30 * time.Minute
+
+	// noShowGrace is how long after a trip should have started it is considered a no-show
+	// if no bus is tracked running it
+	noShowGrace = 5 * time.Minute
+)
+
+// LiveDeparture is a scheduled departure combined with the tracked bus running its trip, if any.
+type LiveDeparture struct {
+	schedule.Departure
+	Status Status
+	// Estimated is the expected departure time, which is the scheduled time unless the departure is live
+	Estimated time.Time
+	// Vehicle is the bus running the trip of a live departure
+	Vehicle *Vehicle
+}
+
+// LiveDepartures returns up to limit departures from a stop expected at or after from, in order
+// of their expected time. Departures of late buses are included up to maxLateness after their
+// scheduled time, and departures of buses that already passed the stop are left out.
+//
+// Trips that should be running at now without a matching vehicle are marked as no-shows, as long
+// as some vehicle is matched to a trip at all, so a tracker outage does not show every trip as
+// cancelled. Vehicles without a trip are ignored.
+func LiveDepartures(sched *schedule.Schedule, stop *gtfs.Stop, from time.Time, limit int, vehicles []Vehicle, now time.Time) []LiveDeparture {
+	type tripKey struct {
+		tripID     string
+		serviceDay int64
+	}
+	byTrip := make(map[tripKey]*Vehicle, len(vehicles))
+	for i := range vehicles {
+		v := &vehicles[i]
+		if v.Trip == nil {
+			continue
+		}
+		key := tripKey{v.Trip.ID, v.ServiceDay.Unix()}
+		// a trip reported by two buses keeps the most recent report
+		if current, ok := byTrip[key]; !ok || v.Location.Timestamp.After(current.Location.Timestamp) {
+			byTrip[key] = v
+		}
+	}
+
+	// each vehicle that already passed the stop removes a departure, so more are looked up
+	candidates := sched.DeparturesBetween(stop, from.Add(-maxLateness), from)
+	candidates = append(candidates, sched.Departures(stop, from, limit+len(vehicles))...)
+
+	departures := make([]LiveDeparture, 0, len(candidates))
+	for _, d := range candidates {
+		departure := LiveDeparture{Departure: d, Status: StatusScheduled, Estimated: d.Time}
+
+		if vehicle, ok := byTrip[tripKey{d.StopTime.Trip.ID, d.ServiceDay.Unix()}]; ok {
+			prediction, ok := vehicle.prediction(d.StopTime)
+			if !ok {
+				// the bus already left the stop
+				continue
+			}
+			departure.Status = StatusLive
+			departure.Vehicle = vehicle
+			departure.Estimated = prediction.Departure
+			if departure.Estimated.Before(now) {
+				departure.Estimated = now
+			}
+		} else if len(byTrip) > 0 && shouldBeRunning(d, now) {
+			departure.Status = StatusNoShow
+		}
+
+		if departure.Estimated.Before(from) {
+			continue
+		}
+		departures = append(departures, departure)
+	}
+
+	slices.SortStableFunc(departures, func(a, b LiveDeparture) int {
+		return cmp.Or(a.Estimated.Compare(b.Estimated), cmp.Compare(a.StopTime.Trip.ID, b.StopTime.Trip.ID))
+	})
+	if len(departures) > limit {
+		departures = departures[:limit]
+	}
+	return departures
+}
+
+// shouldBeRunning reports whether the trip of a departure should have left its first stop by now,
+// allowing for noShowGrace, and not yet have reached its last stop.
+func shouldBeRunning(d schedule.Departure, now time.Time) bool {
+	stopTimes := d.StopTime.Trip.StopTimes
+	start := d.ServiceDay.Add(time.Duration(stopTimes[0].Departure)*time.Second + noShowGrace)
+	end := d.ServiceDay.Add(time.Duration(stopTimes[len(stopTimes)-1].Arrival) * time.Second)
+	return now.After(start) && now.Before(end)
+}
@@ -0,0 +1,21 @@
+package realtime
+
+import (
+	"math"
+)
+
+const earthRadiusMetres = 6371000
+
+// distance returns the great-circle distance in metres between two coordinates.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1, lat2 = radians(lat1), radians(lat2)
+	dLat := lat2 - lat1
+	dLon := radians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMetres * math.Asin(math.Sqrt(a))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,249 @@
+package realtime
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+const (
+	// departureTolerance is how far in seconds a trip's departure time may be from the one reported
+	// by the tracker, which is sometimes rounded or taken from the driver's running board
+	departureTolerance = 2 * 60
+
+	// delayHorizon is the delay at which a trip is no longer considered a match
+	delayHorizon = time.Hour
+
+	// nearDistance and farDistance are the distances in metres from a trip's stops within which
+	// a bus is considered on its route, and beyond which it most likely runs another trip
+	nearDistance = 200.0
+	farDistance  = 2000.0
+
+	// minConfidence is the confidence below which a bus is left unmatched
+	minConfidence = 0.25
+)
+
+// Matcher assigns tracked buses the GTFS trips they are most likely running. The tracker only
+// reports a bus's route number, the departure time of its journey and a direction label, so
+// candidate trips are scored on how well they agree with those and the bus's position.
+type Matcher struct {
+	mutex sync.Mutex
+	// unmatched holds the journey of each bus last logged as unmatched, so it is only logged once
+	unmatched map[string]string
+}
+
+func NewMatcher() *Matcher {
+	return &Matcher{unmatched: make(map[string]string)}
+}
+
+// candidate is a trip a bus may be running, with how well it agrees with the bus's report.
+type candidate struct {
+	vehicle Vehicle
+	score   float64
+}
+
+// Match matches every bus to the trip it is most likely running. Buses that match no trip are
+// returned without one, and logged once per journey.
+func (m *Matcher) Match(sched *schedule.Schedule, buses []tools.BusLocation) []Vehicle {
+	vehicles := make([]Vehicle, len(buses))
+	for i, bus := range buses {
+		vehicles[i] = matchBus(sched, bus)
+	}
+	m.logUnmatched(vehicles)
+	return vehicles
+}
+
+func matchBus(sched *schedule.Schedule, bus tools.BusLocation) Vehicle {
+	vehicle := Vehicle{Location: bus}
+
+	routes := sched.RoutesByNumber(bus.RouteNumber)
+	if len(routes) == 0 {
+		return vehicle
+	}
+	vehicle.Route = routes[0]
+
+	departure, ok := parseDepartureTime(bus.DepartureTime)
+	if !ok {
+		return vehicle
+	}
+	directionID, directionKnown := parseDirection(bus.Direction)
+
+	today := schedule.ServiceDay(bus.Timestamp)
+	yesterday := schedule.ServiceDay(today.Add(-time.Hour))
+
+	var best, runnerUp candidate
+	for _, route := range routes {
+		for _, trip := range route.Trips {
+			// a trip without stop times has no departure or stops to match against
+			if len(trip.StopTimes) == 0 {
+				continue
+			}
+
+			// a trip running past midnight reports its departure on the clock, not past 24:00
+			offset := min(abs(trip.Departure()-departure), abs(trip.Departure()-departure-24*3600))
+			if offset > departureTolerance {
+				continue
+			}
+
+			for _, day := range []time.Time{today, yesterday} {
+				if !trip.Service.ActiveOn(day.Add(12 * time.Hour)) {
+					continue
+				}
+
+				c := scoreTrip(bus, trip, day, offset, directionID, directionKnown)
+				if c.score > best.score {
+					best, runnerUp = c, best
+				} else if c.score > runnerUp.score {
+					runnerUp = c
+				}
+			}
+		}
+	}
+	if best.score == 0 {
+		return vehicle
+	}
+
+	// a close runner-up makes the match ambiguous
+	confidence := best.score * best.score / (best.score + runnerUp.score)
+	if confidence < minConfidence {
+		return vehicle
+	}
+	best.vehicle.Confidence = confidence
+	return best.vehicle
+}
+
+// scoreTrip scores how likely a bus is running a trip on a service day. Each part of the score
+// is between 0 and 1, so a trip that disagrees strongly on any of them scores low.
+func scoreTrip(bus tools.BusLocation, trip *gtfs.Trip, day time.Time, departureOffset int, directionID int, directionKnown bool) candidate {
+	stopIndex, stopDistance := nearestStop(trip, bus.Latitude, bus.Longitude)
+	scheduled := day.Add(time.Duration(trip.StopTimes[stopIndex].Departure) * time.Second)
+	delay := bus.Timestamp.Sub(scheduled).Truncate(time.Second)
+
+	departureScore := 1 - float64(departureOffset)/(2*departureTolerance)
+	delayScore := max(0, 1-float64(delay.Abs())/float64(delayHorizon))
+	// a bus far from the route may be on a diversion or layover, so distance alone does not rule a trip out
+	distanceScore := 1 - 0.5*min(1, max(0, stopDistance-nearDistance)/(farDistance-nearDistance))
+	directionScore := 0.75
+	if directionKnown {
+		directionScore = 0.25
+		if directionID == trip.DirectionID {
+			directionScore = 1
+		}
+	}
+
+	// without the path of the trip, the nearest stop is the best guess of the next one until the
+	// Tracker refines it
+	return candidate{
+		vehicle: Vehicle{
+			Location:      bus,
+			Route:         trip.Route,
+			Trip:          trip,
+			ServiceDay:    day,
+			NextStopIndex: stopIndex,
+			Delay:         delay,
+		},
+		score: departureScore * delayScore * distanceScore * directionScore,
+	}
+}
+
+// parseDepartureTime parses the departure time reported by the tracker into seconds since the
+// start of the service day. The tracker reports it as HHMM, but HH:MM, HH:MM:SS and full RFC 3339
+// timestamps are accepted too.
+func parseDepartureTime(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		t = t.In(schedule.Location)
+		return t.Hour()*3600 + t.Minute()*60 + t.Second(), true
+	}
+
+	var parts []string
+	switch {
+	case strings.Contains(s, ":"):
+		parts = strings.Split(s, ":")
+	case len(s) == 3 || len(s) == 4:
+		parts = []string{s[:len(s)-2], s[len(s)-2:]}
+	default:
+		return 0, false
+	}
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	seconds := 0
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 || (i > 0 && (len(part) != 2 || v > 59)) || (i == 0 && v > 23) {
+			return 0, false
+		}
+		seconds += v * []int{3600, 60, 1}[i]
+	}
+	return seconds, true
+}
+
+// parseDirection maps the direction label reported by the tracker to a GTFS direction ID, following
+// the usual convention of outbound journeys having direction 0 and inbound ones direction 1.
+func parseDirection(s string) (directionID int, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "outbound", "out", "o", "0":
+		return 0, true
+	case "inbound", "in", "i", "1":
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// logUnmatched logs the buses that could not be matched to a trip, once per journey of each bus.
+func (m *Matcher) logUnmatched(vehicles []Vehicle) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tracked := make(map[string]bool, len(vehicles))
+	for _, v := range vehicles {
+		bus := v.Location
+		tracked[bus.BusID] = true
+		if v.Trip != nil {
+			delete(m.unmatched, bus.BusID)
+			continue
+		}
+
+		journey := bus.RouteNumber + "|" + bus.DepartureTime + "|" + bus.Direction
+		if m.unmatched[bus.BusID] == journey {
+			continue
+		}
+		m.unmatched[bus.BusID] = journey
+
+		fields := log.Fields{
+			"bus_id":         bus.BusID,
+			"route_number":   bus.RouteNumber,
+			"departure_time": bus.DepartureTime,
+			"direction":      bus.Direction,
+			"latitude":       bus.Latitude,
+			"longitude":      bus.Longitude,
+		}
+		if v.Route != nil {
+			fields["route_id"] = v.Route.ID
+		}
+		log.WithFields(fields).Warn("Could not match tracked bus to a GTFS trip")
+	}
+
+	// forget buses that are no longer tracked, so the map does not grow forever
+	for busID := range m.unmatched {
+		if !tracked[busID] {
+			delete(m.unmatched, busID)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,68 @@
+package realtime
+
+import (
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+)
+
+// StopPrediction is when a vehicle is expected at a stop of its trip.
+type StopPrediction struct {
+	StopTime  *gtfs.StopTime
+	Arrival   time.Time
+	Departure time.Time
+	// ArrivalDelay and DepartureDelay are how much later than scheduled the vehicle is expected
+	// to arrive at and depart from the stop, negative when early
+	ArrivalDelay   time.Duration
+	DepartureDelay time.Duration
+}
+
+// Predictions returns when a vehicle is expected at each stop of its trip from the next one it
+// calls at, or nil if it is not matched to a trip.
+//
+// The current delay is carried on to the following stops, except that a late bus makes up time
+// where it is scheduled to wait at a stop, and an early bus waits for its scheduled departure.
+// A bus is never expected anywhere before it was last seen.
+func (v *Vehicle) Predictions() []StopPrediction {
+	if v.Trip == nil || v.NextStopIndex >= len(v.Trip.StopTimes) {
+		return nil
+	}
+
+	stopTimes := v.Trip.StopTimes[v.NextStopIndex:]
+	predictions := make([]StopPrediction, len(stopTimes))
+	delay := v.Delay
+	for i, stopTime := range stopTimes {
+		scheduledArrival := v.ServiceDay.Add(time.Duration(stopTime.Arrival) * time.Second)
+		scheduledDeparture := v.ServiceDay.Add(time.Duration(stopTime.Departure) * time.Second)
+
+		arrival := scheduledArrival.Add(delay)
+		if arrival.Before(v.Location.Timestamp) {
+			arrival = v.Location.Timestamp
+		}
+		departure := arrival
+		if departure.Before(scheduledDeparture) {
+			departure = scheduledDeparture
+		}
+
+		predictions[i] = StopPrediction{
+			StopTime:       stopTime,
+			Arrival:        arrival,
+			Departure:      departure,
+			ArrivalDelay:   arrival.Sub(scheduledArrival),
+			DepartureDelay: departure.Sub(scheduledDeparture),
+		}
+		delay = predictions[i].DepartureDelay
+	}
+	return predictions
+}
+
+// prediction returns when a vehicle is expected at a stop of its trip, or false if it already
+// passed it.
+func (v *Vehicle) prediction(stopTime *gtfs.StopTime) (StopPrediction, bool) {
+	for _, p := range v.Predictions() {
+		if p.StopTime == stopTime {
+			return p, true
+		}
+	}
+	return StopPrediction{}, false
+}
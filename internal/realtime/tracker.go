@@ -0,0 +1,102 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// Tracker keeps the tracked buses matched to the trips of the current schedule, along with how
+// late each of them is running. It is updated with every frame received from the tracker, so
+// reading the vehicles does not repeat the work for locations that did not change.
+type Tracker struct {
+	ss      *schedule.Store
+	matcher *Matcher
+
+	mutex sync.Mutex
+	// versionID is the schedule version the vehicles and paths were computed against
+	versionID string
+	vehicles  map[string]Vehicle
+	paths     map[*gtfs.Trip]*tripPath
+}
+
+func NewTracker(ss *schedule.Store) *Tracker {
+	return &Tracker{
+		ss:       ss,
+		matcher:  NewMatcher(),
+		vehicles: make(map[string]Vehicle),
+		paths:    make(map[*gtfs.Trip]*tripPath),
+	}
+}
+
+// Update matches every tracked bus to a trip and computes how late it is running. It is meant to
+// be called with all tracked buses whenever new locations are received.
+func (t *Tracker) Update(buses []tools.BusLocation) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.update(t.ss.Current(), buses)
+}
+
+// Vehicles returns the tracked buses matched to trips, in the order given. The results of the
+// last update are reused if the locations are unchanged since, and recomputed otherwise.
+func (t *Tracker) Vehicles(buses []tools.BusLocation) []Vehicle {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	sched := t.ss.Current()
+	if !t.current(sched, buses) {
+		t.update(sched, buses)
+	}
+
+	vehicles := make([]Vehicle, len(buses))
+	for i, bus := range buses {
+		vehicles[i] = t.vehicles[bus.BusID]
+	}
+	return vehicles
+}
+
+// current reports whether the vehicles were computed against a schedule for exactly these buses.
+func (t *Tracker) current(sched *schedule.Schedule, buses []tools.BusLocation) bool {
+	if sched == nil || sched.VersionID != t.versionID || len(buses) != len(t.vehicles) {
+		return false
+	}
+	for _, bus := range buses {
+		if v, ok := t.vehicles[bus.BusID]; !ok || v.Location != bus {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tracker) update(sched *schedule.Schedule, buses []tools.BusLocation) {
+	clear(t.vehicles)
+	if sched == nil {
+		t.versionID = ""
+		for _, bus := range buses {
+			t.vehicles[bus.BusID] = Vehicle{Location: bus}
+		}
+		return
+	}
+
+	if sched.VersionID != t.versionID {
+		t.versionID = sched.VersionID
+		clear(t.paths)
+	}
+
+	for _, v := range t.matcher.Match(sched, buses) {
+		if v.Trip != nil {
+			path, ok := t.paths[v.Trip]
+			if !ok {
+				path = newTripPath(v.Trip)
+				t.paths[v.Trip] = path
+			}
+			if delay, nextStop, ok := path.adherence(v); ok {
+				v.Delay, v.NextStopIndex = delay, nextStop
+			}
+		}
+		t.vehicles[v.Location.BusID] = v
+	}
+}
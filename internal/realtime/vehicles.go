@@ -0,0 +1,38 @@
+package realtime
+
+import (
+	"math"
+	"time"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// Vehicle is a tracked bus along with the GTFS route and trip it was matched to.
+type Vehicle struct {
+	Location tools.BusLocation
+	// Route is the route the bus is running, or nil if its route number matches no route
+	Route *gtfs.Route
+	// Trip is the trip the bus is running, or nil if it matches no trip
+	Trip       *gtfs.Trip
+	ServiceDay time.Time
+	// Confidence is how likely the match to Trip is correct, from 0 to 1
+	Confidence float64
+	// NextStopIndex is the index in Trip.StopTimes of the next stop the bus will call at, or
+	// len(Trip.StopTimes) once it passed the last one. It counts a bus at a stop as not yet departed.
+	NextStopIndex int
+	// Delay is how late the bus is running, negative when it is early. The Matcher estimates it from
+	// the nearest stop, and the Tracker refines it from the bus's position along the trip's shape.
+	Delay time.Duration
+}
+
+// nearestStop returns the index of the stop of a trip nearest to a position, and its distance in metres.
+func nearestStop(trip *gtfs.Trip, lat, lon float64) (index int, metres float64) {
+	metres = math.Inf(1)
+	for i, stopTime := range trip.StopTimes {
+		if d := distance(lat, lon, stopTime.Stop.Lat, stopTime.Stop.Lon); d < metres {
+			index, metres = i, d
+		}
+	}
+	return index, metres
+}
@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"cmp"
+	"slices"
+	"time"
+	// embedded so Timezone resolves on hosts without a timezone database
+	_ "time/tzdata"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+)
+
+const (
+	// Timezone is the timezone the schedule is run in, which service days and times are relative to.
+	Timezone = "Europe/Isle_of_Man"
+
+	// departureSearchDays is how many service days ahead departures are looked for,
+	// so a stop with no more service does not search forever.
+	departureSearchDays = 7
+)
+
+// Location is the location of Timezone.
+var Location = mustLoadLocation(Timezone)
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+// ServiceDay returns the start of the service day on the calendar date of t in Location.
+// GTFS times are relative to noon minus 12 hours, which is midnight except on days when the
+// clocks change.
+func ServiceDay(t time.Time) time.Time {
+	year, month, day := t.In(Location).Date()
+	return time.Date(year, month, day, 12, 0, 0, 0, Location).Add(-12 * time.Hour)
+}
+
+// Departure is a scheduled call of a trip at a stop on a specific service day.
+type Departure struct {
+	StopTime *gtfs.StopTime
+	// ServiceDay is the start of the service day the trip runs on, see ServiceDay
+	ServiceDay time.Time
+	// Time is the scheduled departure time
+	Time time.Time
+}
+
+// Headsign returns the destination shown for the departure.
+func (d Departure) Headsign() string {
+	if d.StopTime.Headsign != "" {
+		return d.StopTime.Headsign
+	}
+	if d.StopTime.Trip.Headsign != "" {
+		return d.StopTime.Trip.Headsign
+	}
+	stopTimes := d.StopTime.Trip.StopTimes
+	return stopTimes[len(stopTimes)-1].Stop.Name
+}
+
+// Departures returns up to limit scheduled departures from a stop at or after from, in time order.
+// Departures from a station include those from all of its stops. Calls where passengers cannot
+// board, including the last stop of each trip, are left out.
+func (s *Schedule) Departures(stop *gtfs.Stop, from time.Time, limit int) []Departure {
+	return s.departures(stop, from, time.Time{}, limit)
+}
+
+// DeparturesBetween returns the scheduled departures from a stop at or after from and before until,
+// in time order, following the same rules as Departures.
+func (s *Schedule) DeparturesBetween(stop *gtfs.Stop, from, until time.Time) []Departure {
+	return s.departures(stop, from, until, 0)
+}
+
+// departures looks for departures from a stop at or after from, until either limit departures
+// are found or until is reached. A zero limit or until is not applied.
+func (s *Schedule) departures(stop *gtfs.Stop, from, until time.Time, limit int) []Departure {
+	stops := append([]*gtfs.Stop{stop}, stop.Children...)
+
+	var departures []Departure
+	// trips of the previous service day may still be running past midnight
+	day := ServiceDay(ServiceDay(from).Add(-time.Hour))
+	for range departureSearchDays + 1 {
+		nextDay := ServiceDay(day.Add(36 * time.Hour))
+		for _, stop := range stops {
+			for _, stopTime := range s.StopTimesByStop[stop.ID] {
+				if !boards(stopTime) || !stopTime.Trip.Service.ActiveOn(day.Add(12*time.Hour)) {
+					continue
+				}
+				departure := day.Add(time.Duration(stopTime.Departure) * time.Second)
+				if departure.Before(from) || (!until.IsZero() && !departure.Before(until)) {
+					continue
+				}
+				departures = append(departures, Departure{StopTime: stopTime, ServiceDay: day, Time: departure})
+			}
+		}
+
+		// every departure of later service days is after the start of the next one
+		if (limit > 0 && countBefore(departures, nextDay) >= limit) || (!until.IsZero() && !nextDay.Before(until)) {
+			break
+		}
+		day = nextDay
+	}
+
+	slices.SortStableFunc(departures, func(a, b Departure) int {
+		return cmp.Or(a.Time.Compare(b.Time), cmp.Compare(a.StopTime.Trip.ID, b.StopTime.Trip.ID))
+	})
+	if limit > 0 && len(departures) > limit {
+		departures = departures[:limit]
+	}
+	return departures
+}
+
+// boards reports whether passengers can board at a stop time.
+func boards(stopTime *gtfs.StopTime) bool {
+	stopTimes := stopTime.Trip.StopTimes
+	return stopTime.PickupType != 1 && stopTime != stopTimes[len(stopTimes)-1]
+}
+
+func countBefore(departures []Departure, t time.Time) int {
+	count := 0
+	for _, departure := range departures {
+		if departure.Time.Before(t) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+)
+
+// RoutesByNumber returns the routes whose short name or ID matches a route number as riders and
+// the tracker write it, ignoring case and leading zeros, ordered by route ID.
+func (s *Schedule) RoutesByNumber(number string) []*gtfs.Route {
+	number = normaliseRouteNumber(number)
+	if number == "" {
+		return nil
+	}
+
+	var routes []*gtfs.Route
+	for _, id := range slices.Sorted(maps.Keys(s.Routes)) {
+		route := s.Routes[id]
+		if normaliseRouteNumber(route.ShortName) == number || normaliseRouteNumber(route.ID) == number {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+func normaliseRouteNumber(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if trimmed := strings.TrimLeft(s, "0"); trimmed != "" {
+		return trimmed
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// Schedule is a parsed GTFS schedule along with the storage version it was loaded from.
+type Schedule struct {
+	*gtfs.Feed
+	VersionID string
+	LoadedAt  time.Time
+}
+
+// Store keeps the latest GTFS schedule parsed in memory for handlers to query.
+// A reload parses the new schedule next to the current one and swaps them atomically,
+// so readers always see a complete schedule and never wait for a reload.
+type Store struct {
+	storage tools.GTFSStorage
+	current atomic.Pointer[Schedule]
+
+	// loadMutex serialises reloads, so a slow one cannot overwrite a newer schedule
+	loadMutex sync.Mutex
+	// failedVersionID is the last version that failed to parse, so it is not downloaded again
+	failedVersionID string
+}
+
+// NewStore creates an empty Store that loads schedules from storage. Call Load to load the
+// latest schedule.
+func NewStore(storage tools.GTFSStorage) *Store {
+	return &Store{storage: storage}
+}
+
+// Current returns the loaded schedule, or nil if no schedule has been loaded yet.
+// The returned schedule must not be modified.
+func (s *Store) Current() *Schedule {
+	return s.current.Load()
+}
+
+// Load downloads, parses and swaps in the latest schedule from storage, unless it is the
+// version already loaded. It returns tools.NoGTFSScheduleFound if no schedule has been uploaded.
+// On failure, the previously loaded schedule is kept. A version that failed to parse is skipped
+// until another version is published.
+func (s *Store) Load() error {
+	s.loadMutex.Lock()
+	defer s.loadMutex.Unlock()
+
+	latestVersionID, err := s.storage.GetLatestGTFSVersionID()
+	if err != nil {
+		return err
+	}
+	if current := s.current.Load(); current != nil && current.VersionID == latestVersionID {
+		log.Debugf("GTFS schedule %s is already loaded", latestVersionID)
+		return nil
+	}
+	if latestVersionID == s.failedVersionID {
+		log.Debugf("GTFS schedule %s failed to parse, keeping the loaded schedule", latestVersionID)
+		return nil
+	}
+
+	data, versionID, err := s.storage.GetLatestSchedule()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	feed, err := gtfs.Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		s.failedVersionID = versionID
+		return fmt.Errorf("failed to parse GTFS schedule %s: %w", versionID, err)
+	}
+
+	s.current.Store(&Schedule{Feed: feed, VersionID: versionID, LoadedAt: time.Now()})
+	log.WithFields(log.Fields{
+		"version_id": versionID,
+		"stops":      len(feed.Stops),
+		"routes":     len(feed.Routes),
+		"trips":      len(feed.Trips),
+		"duration":   time.Since(start),
+	}).Info("Loaded GTFS schedule")
+	return nil
+}
+
+// Watch reloads the schedule every interval until ctx is cancelled, picking up schedules
+// uploaded through other replicas. Checking for a new version only fetches its version ID.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(); err != nil && !errors.Is(err, tools.NoGTFSScheduleFound) {
+				log.Errorf("Failed to reload GTFS schedule: %v", err)
+			}
+		}
+	}
+}
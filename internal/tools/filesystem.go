@@ -0,0 +1,601 @@
+package tools
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// nullVersionID is the version ID given to objects written while versioning is disabled,
+// matching the behaviour of S3 compatible storage.
+const nullVersionID = "null"
+
+// FilesystemClient implements the ObjectStorageClient interface on top of the local filesystem.
+// Buckets are directories under the root directory, and every object keeps its full version
+// history on disk. Presigned URLs point back at the client itself, which serves them via ServeHTTP.
+//
+// The on-disk layout is:
+//
+//	<root>/<bucket>/bucket.json                         bucket metadata
+//	<root>/<bucket>/objects/<escaped key>/versions.json  ordered version metadata, oldest first
+//	<root>/<bucket>/objects/<escaped key>/<version ID>   object data
+type FilesystemClient struct {
+	root       string
+	publicURL  *url.URL
+	signingKey []byte
+	mutex      sync.RWMutex
+}
+
+type fsBucketMetadata struct {
+	CreationDate time.Time `json:"creation_date"`
+	Versioning   bool      `json:"versioning"`
+}
+
+type fsObjectVersion struct {
+	VersionID    string    `json:"version_id"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	ContentType  string    `json:"content_type"`
+}
+
+// NewFilesystemClient creates a new FilesystemClient storing its data under root.
+// publicURL is the externally reachable address that ServeHTTP is mounted at, and is used
+// as the base of presigned URLs. signingKey is used to sign presigned URLs; if it is empty
+// a random key is generated, which invalidates outstanding URLs whenever the process restarts.
+func NewFilesystemClient(root string, publicURL *url.URL, signingKey []byte) (*FilesystemClient, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+
+	if len(signingKey) == 0 {
+		log.Warn("no storage signing key configured, generating a random one")
+		key := uuid.New()
+		signingKey = key[:]
+	}
+
+	return &FilesystemClient{
+		root:       root,
+		publicURL:  publicURL,
+		signingKey: signingKey,
+	}, nil
+}
+
+// BucketExists checks if a bucket directory exists.
+func (f *FilesystemClient) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	_, err := f.readBucketMetadata(bucketName)
+	if errors.Is(err, BucketNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MakeBucket creates a new bucket directory. The region is ignored.
+func (f *FilesystemClient) MakeBucket(ctx context.Context, bucketName string, region string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := validateBucketName(bucketName); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(f.bucketPath(bucketName)); err == nil {
+		return fmt.Errorf("bucket %s already exists", bucketName)
+	}
+
+	if err := os.MkdirAll(filepath.Join(f.bucketPath(bucketName), "objects"), 0o755); err != nil {
+		return fmt.Errorf("failed to create bucket directory: %w", err)
+	}
+
+	return f.writeBucketMetadata(bucketName, fsBucketMetadata{CreationDate: time.Now().UTC()})
+}
+
+// SetBucketVersioning enables or disables versioning for a bucket.
+// Existing versions are kept when versioning is disabled.
+func (f *FilesystemClient) SetBucketVersioning(ctx context.Context, bucketName string, enabled bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	meta, err := f.readBucketMetadata(bucketName)
+	if err != nil {
+		return err
+	}
+	meta.Versioning = enabled
+	return f.writeBucketMetadata(bucketName, meta)
+}
+
+// ListBuckets returns every bucket under the root directory, sorted by name.
+func (f *FilesystemClient) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	entries, err := os.ReadDir(f.root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BucketInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		meta, err := f.readBucketMetadata(entry.Name())
+		if err != nil {
+			log.Debugf("Skipping %s, not a bucket: %v", entry.Name(), err)
+			continue
+		}
+		result = append(result, BucketInfo{
+			Name:         entry.Name(),
+			CreationDate: meta.CreationDate,
+		})
+	}
+	return result, nil
+}
+
+// GetObject retrieves the latest version of an object.
+// Returned io.ReadCloser must be closed after use to release resources.
+func (f *FilesystemClient) GetObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	version, err := f.latestVersion(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(f.objectPath(bucketName, objectName), version.VersionID))
+}
+
+// GetObjectVersion retrieves a specific version of an object.
+// Returned io.ReadCloser must be closed after use to release resources.
+func (f *FilesystemClient) GetObjectVersion(ctx context.Context, bucketName, objectName, versionID string) (io.ReadCloser, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	version, err := f.findVersion(bucketName, objectName, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(f.objectPath(bucketName, objectName), version.VersionID))
+}
+
+// PutObject stores a new version of an object.
+// When versioning is disabled on the bucket, the object's null version is overwritten instead.
+// Conditions in opts are checked against the latest version while holding the write lock.
+func (f *FilesystemClient) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, opts PutObjectOptions) (UploadInfo, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.checkPreconditions(bucketName, objectName, opts); err != nil {
+		return UploadInfo{}, err
+	}
+
+	return f.putObject(bucketName, objectName, reader, objectSize, opts.ContentType)
+}
+
+// checkPreconditions verifies the conditions of a conditional write against the latest version of an object.
+func (f *FilesystemClient) checkPreconditions(bucketName, objectName string, opts PutObjectOptions) error {
+	if opts.MatchETag == "" && opts.NoneMatchETag == "" {
+		return nil
+	}
+
+	current, err := f.latestVersion(bucketName, objectName)
+	if err != nil && !errors.Is(err, KeyNotFound) {
+		return err
+	}
+	exists := err == nil
+
+	if opts.MatchETag != "" && (!exists || (opts.MatchETag != "*" && opts.MatchETag != current.ETag)) {
+		return PreconditionFailed
+	}
+	if opts.NoneMatchETag != "" && exists && (opts.NoneMatchETag == "*" || opts.NoneMatchETag == current.ETag) {
+		return PreconditionFailed
+	}
+	return nil
+}
+
+// CopyObject copies a specific version of an object, storing it as the latest version of the destination object.
+func (f *FilesystemClient) CopyObject(ctx context.Context, dstBucketName, dstObjectName, srcBucketName, srcObjectName, srcVersionID string) (UploadInfo, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	version, err := f.findVersion(srcBucketName, srcObjectName, srcVersionID)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	src, err := os.Open(filepath.Join(f.objectPath(srcBucketName, srcObjectName), version.VersionID))
+	if err != nil {
+		return UploadInfo{}, fmt.Errorf("failed to open source object: %w", err)
+	}
+	defer func(src *os.File) {
+		if closeErr := src.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}(src)
+
+	return f.putObject(dstBucketName, dstObjectName, src, version.Size, version.ContentType)
+}
+
+// putObject stores a new version of an object. The caller must hold the write lock.
+func (f *FilesystemClient) putObject(bucketName, objectName string, reader io.Reader, objectSize int64, contentType string) (UploadInfo, error) {
+	meta, err := f.readBucketMetadata(bucketName)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	objectPath := f.objectPath(bucketName, objectName)
+	if err = os.MkdirAll(objectPath, 0o755); err != nil {
+		return UploadInfo{}, fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	versionID := nullVersionID
+	if meta.Versioning {
+		versionID = uuid.NewString()
+	}
+
+	// write to a temporary file first so readers never observe a partial object
+	tmp, err := os.CreateTemp(objectPath, ".upload-*")
+	if err != nil {
+		return UploadInfo{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	hash := md5.New()
+	if objectSize >= 0 {
+		reader = io.LimitReader(reader, objectSize)
+	}
+	written, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return UploadInfo{}, fmt.Errorf("failed to write object: %w", err)
+	}
+	if objectSize >= 0 && written != objectSize {
+		return UploadInfo{}, fmt.Errorf("object size mismatch: expected %d bytes, got %d", objectSize, written)
+	}
+
+	if err = os.Rename(tmp.Name(), filepath.Join(objectPath, versionID)); err != nil {
+		return UploadInfo{}, fmt.Errorf("failed to store object: %w", err)
+	}
+
+	version := fsObjectVersion{
+		VersionID:    versionID,
+		Size:         written,
+		ETag:         hex.EncodeToString(hash.Sum(nil)),
+		LastModified: time.Now().UTC(),
+		ContentType:  contentType,
+	}
+
+	versions, err := f.readVersions(bucketName, objectName)
+	if err != nil && !errors.Is(err, KeyNotFound) {
+		return UploadInfo{}, err
+	}
+	versions = removeVersion(versions, versionID)
+	versions = append(versions, version)
+	if err = f.writeVersions(bucketName, objectName, versions); err != nil {
+		return UploadInfo{}, err
+	}
+
+	return UploadInfo{
+		Bucket:    bucketName,
+		Key:       objectName,
+		VersionID: version.VersionID,
+		ETag:      version.ETag,
+	}, nil
+}
+
+// StatObject retrieves metadata about the latest version of an object.
+func (f *FilesystemClient) StatObject(ctx context.Context, bucketName, objectName string) (ObjectInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	version, err := f.latestVersion(bucketName, objectName)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return ObjectInfo{
+		Key:          objectName,
+		Size:         version.Size,
+		ETag:         version.ETag,
+		LastModified: version.LastModified,
+		ContentType:  version.ContentType,
+		VersionID:    version.VersionID,
+	}, nil
+}
+
+// GetObjectAttributes retrieves the version ID and ETag of the latest version of an object.
+func (f *FilesystemClient) GetObjectAttributes(ctx context.Context, bucketName, objectName string) (ObjectAttributes, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	version, err := f.latestVersion(bucketName, objectName)
+	if err != nil {
+		return ObjectAttributes{}, err
+	}
+
+	return ObjectAttributes{
+		VersionID: version.VersionID,
+		ETag:      version.ETag,
+	}, nil
+}
+
+// PresignedGetObject generates a signed URL, relative to the client's public URL, that ServeHTTP
+// will answer with the object until the expiry duration has passed. The URL is pinned to the
+// version given by the "versionId" request parameter, or to the latest version if it is not set.
+func (f *FilesystemClient) PresignedGetObject(ctx context.Context, bucketName, objectName string, expiry time.Duration, reqParams url.Values) (*url.URL, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	version, err := f.findVersion(bucketName, objectName, reqParams.Get("versionId"))
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for k, v := range reqParams {
+		query[k] = v
+	}
+	query.Set("versionId", version.VersionID)
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expiry).Unix(), 10))
+
+	objectURL := f.publicURL.JoinPath(bucketName, objectName)
+	query.Set("signature", f.sign(objectURL.Path, query))
+	objectURL.RawQuery = query.Encode()
+
+	return objectURL, nil
+}
+
+// ListObjectVersions lists every stored version of an object, newest first.
+func (f *FilesystemClient) ListObjectVersions(ctx context.Context, bucketName, objectName string) ([]ObjectInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	versions, err := f.readVersions(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ObjectInfo, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		result = append(result, ObjectInfo{
+			Key:          objectName,
+			Size:         versions[i].Size,
+			ETag:         versions[i].ETag,
+			LastModified: versions[i].LastModified,
+			ContentType:  versions[i].ContentType,
+			VersionID:    versions[i].VersionID,
+		})
+	}
+	return result, nil
+}
+
+// ServeHTTP serves objects for presigned URLs generated by PresignedGetObject.
+// It must be mounted, without stripping the prefix, at the path of the public URL the client was created with.
+func (f *FilesystemClient) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	signature := query.Get("signature")
+	query.Del("signature")
+
+	if !hmac.Equal([]byte(signature), []byte(f.sign(r.URL.Path, query))) {
+		log.Debug("Presigned URL signature mismatch")
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		log.Debug("Presigned URL expired")
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	objectPath := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(f.publicURL.Path, "/"))
+	bucketName, objectName, found := strings.Cut(strings.TrimPrefix(objectPath, "/"), "/")
+	if !found || bucketName == "" || objectName == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	f.mutex.RLock()
+	version, err := f.findVersion(bucketName, objectName, query.Get("versionId"))
+	if err != nil {
+		f.mutex.RUnlock()
+		if errors.Is(err, KeyNotFound) || errors.Is(err, BucketNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Error(err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	file, err := os.Open(filepath.Join(f.objectPath(bucketName, objectName), version.VersionID))
+	f.mutex.RUnlock()
+	if err != nil {
+		log.Error(err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer func(file *os.File) {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}(file)
+
+	contentType := version.ContentType
+	if override := query.Get("response-content-type"); override != "" {
+		contentType = override
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if disposition := query.Get("response-content-disposition"); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	w.Header().Set("ETag", `"`+version.ETag+`"`)
+
+	http.ServeContent(w, r, objectName, version.LastModified, file)
+}
+
+// sign returns the hex encoded HMAC of the URL path and query parameters.
+func (f *FilesystemClient) sign(path string, query url.Values) string {
+	mac := hmac.New(sha256.New, f.signingKey)
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(query.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *FilesystemClient) bucketPath(bucketName string) string {
+	return filepath.Join(f.root, bucketName)
+}
+
+func (f *FilesystemClient) objectPath(bucketName, objectName string) string {
+	return filepath.Join(f.bucketPath(bucketName), "objects", escapeObjectName(objectName))
+}
+
+// escapeObjectName returns the name of the directory an object is stored in. Path escaping
+// leaves dots alone, so names made only of dots, which would resolve outside of the objects
+// directory, have them escaped too. Escaped names never contain a literal "%2E", so these cannot
+// collide with other names.
+func escapeObjectName(objectName string) string {
+	if objectName == "." || objectName == ".." {
+		return strings.Repeat("%2E", len(objectName))
+	}
+	return url.PathEscape(objectName)
+}
+
+func (f *FilesystemClient) readBucketMetadata(bucketName string) (fsBucketMetadata, error) {
+	var meta fsBucketMetadata
+	if err := validateBucketName(bucketName); err != nil {
+		return meta, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.bucketPath(bucketName), "bucket.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, BucketNotFound
+	}
+	if err != nil {
+		return meta, fmt.Errorf("failed to read bucket metadata: %w", err)
+	}
+
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("failed to decode bucket metadata: %w", err)
+	}
+	return meta, nil
+}
+
+func (f *FilesystemClient) writeBucketMetadata(bucketName string, meta fsBucketMetadata) error {
+	return writeJSONFile(filepath.Join(f.bucketPath(bucketName), "bucket.json"), meta)
+}
+
+// readVersions returns every stored version of an object, oldest first.
+func (f *FilesystemClient) readVersions(bucketName, objectName string) ([]fsObjectVersion, error) {
+	if _, err := f.readBucketMetadata(bucketName); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.objectPath(bucketName, objectName), "versions.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, KeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object metadata: %w", err)
+	}
+
+	var versions []fsObjectVersion
+	if err = json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("failed to decode object metadata: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, KeyNotFound
+	}
+	return versions, nil
+}
+
+func (f *FilesystemClient) writeVersions(bucketName, objectName string, versions []fsObjectVersion) error {
+	return writeJSONFile(filepath.Join(f.objectPath(bucketName, objectName), "versions.json"), versions)
+}
+
+func (f *FilesystemClient) latestVersion(bucketName, objectName string) (fsObjectVersion, error) {
+	versions, err := f.readVersions(bucketName, objectName)
+	if err != nil {
+		return fsObjectVersion{}, err
+	}
+	return versions[len(versions)-1], nil
+}
+
+// findVersion returns the requested version of an object, or the latest version if versionID is empty.
+func (f *FilesystemClient) findVersion(bucketName, objectName, versionID string) (fsObjectVersion, error) {
+	versions, err := f.readVersions(bucketName, objectName)
+	if err != nil {
+		return fsObjectVersion{}, err
+	}
+	if versionID == "" {
+		return versions[len(versions)-1], nil
+	}
+	for _, v := range versions {
+		if v.VersionID == versionID {
+			return v, nil
+		}
+	}
+	return fsObjectVersion{}, KeyNotFound
+}
+
+func removeVersion(versions []fsObjectVersion, versionID string) []fsObjectVersion {
+	result := versions[:0]
+	for _, v := range versions {
+		if v.VersionID != versionID {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// validateBucketName rejects names that would escape the storage root.
+func validateBucketName(bucketName string) error {
+	if bucketName == "" || bucketName == "." || bucketName == ".." || strings.ContainsAny(bucketName, `/\`) {
+		return fmt.Errorf("invalid bucket name: %q", bucketName)
+	}
+	return nil
+}
+
+// writeJSONFile atomically replaces the file at path with the JSON encoding of v.
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultRecordingMaxSize is the size at which a recording file is closed and a new one started.
+const DefaultRecordingMaxSize = 64 << 20
+
+// DefaultRecordingMaxFiles is the number of recording files kept before the oldest are deleted.
+const DefaultRecordingMaxFiles = 48
+
+// recordingTimeFormat names recording files so they sort in the order they were started
+const recordingTimeFormat = "20060102T150405.000000000Z"
+
+// Frame is a response received from a location source, exactly as it was received, so parsing it
+// can be repeated later.
+type Frame struct {
+	ReceivedAt time.Time `json:"receivedAt"`
+	Source     string    `json:"source"`
+	Data       string    `json:"data"`
+}
+
+// FrameRecorder keeps the frames received from location sources.
+type FrameRecorder interface {
+	Record(frame Frame)
+}
+
+// recordFrame passes a frame received now to a recorder, if there is one.
+func recordFrame(recorder FrameRecorder, source string, data string) {
+	if recorder != nil {
+		recorder.Record(Frame{ReceivedAt: time.Now().UTC(), Source: source, Data: data})
+	}
+}
+
+// FileRecorder writes frames as JSONL to files in a directory, starting a new file once the
+// current one reaches a maximum size.
+type FileRecorder struct {
+	dir     string
+	maxSize int64
+
+	// MaxFiles is the number of recording files kept in the directory, deleting the oldest when
+	// a new one is started. Zero or less keeps every file.
+	MaxFiles int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+func NewFileRecorder(dir string, maxSize int64) (*FileRecorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &FileRecorder{dir: dir, maxSize: maxSize}, nil
+}
+
+// Record appends a frame to the current recording file. Frames that cannot be written are logged
+// and dropped, so recording never holds up a source.
+func (r *FileRecorder) Record(frame Frame) {
+	line, err := json.Marshal(frame)
+	if err != nil {
+		log.Errorf("Failed to encode frame: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file != nil && r.size+int64(len(line)) > r.maxSize {
+		r.closeFile()
+	}
+	if r.file == nil {
+		name := filepath.Join(r.dir, "frames-"+frame.ReceivedAt.UTC().Format(recordingTimeFormat)+".jsonl")
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Errorf("Failed to create recording file: %v", err)
+			return
+		}
+		log.Debugf("Recording frames to %s", name)
+		r.file, r.size = file, 0
+		r.deleteOldFiles()
+	}
+
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		log.Errorf("Failed to record frame: %v", err)
+	}
+}
+
+// Close closes the current recording file. Recording again starts a new one.
+func (r *FileRecorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.closeFile()
+}
+
+// deleteOldFiles deletes the oldest recording files beyond MaxFiles.
+func (r *FileRecorder) deleteOldFiles() {
+	if r.MaxFiles <= 0 {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(r.dir, "frames-*.jsonl"))
+	if err != nil {
+		log.Errorf("Failed to list recording files: %v", err)
+		return
+	}
+	if len(files) <= r.MaxFiles {
+		return
+	}
+
+	slices.Sort(files)
+	for _, name := range files[:len(files)-r.MaxFiles] {
+		if err := os.Remove(name); err != nil {
+			log.Errorf("Failed to delete old recording file: %v", err)
+			continue
+		}
+		log.Debugf("Deleted old recording file %s", name)
+	}
+}
+
+func (r *FileRecorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -15,18 +14,8 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const expiryTime = time.Minute * 2
-
-var BusLocations struct {
-	Buses  map[string]*TrackedBus
-	Mutex  sync.RWMutex
-	expiry time.Duration
-}
-
-type TrackedBus struct {
-	Location BusLocation
-	timer    *time.Timer
-}
+// FindMyBusURL is the live tracking site the browser source watches.
+const FindMyBusURL = "https://findmybus.im"
 
 type BusLocation struct {
 	DriverNumber  string    `json:"-"`
@@ -41,16 +30,34 @@ type BusLocation struct {
 	Unknown2      string    `json:"-"`
 }
 
-func init() {
-	BusLocations.Buses = make(map[string]*TrackedBus)
-	BusLocations.expiry = expiryTime
+// BrowserSource loads a live tracking page in a headless browser and reads bus locations from the
+// SignalR frames the page receives.
+type BrowserSource struct {
+	url string
+
+	// Recorder, if set, keeps every response captured from the page
+	Recorder FrameRecorder
 }
 
-func InitializeBrowser(ctx context.Context) {
-	browser := rod.New().MustConnect()
-	defer browser.MustClose()
+func NewBrowserSource(url string) *BrowserSource {
+	return &BrowserSource{url: url}
+}
 
-	page := browser.MustPage("https://findmybus.im")
+func (b *BrowserSource) Name() string {
+	return "browser " + b.url
+}
+
+func (b *BrowserSource) Run(ctx context.Context, store *LocationStore) error {
+	browser := rod.New().Context(ctx)
+	if err := browser.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: b.url})
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", b.url, err)
+	}
 
 	go page.EachEvent(func(e *proto.NetworkResponseReceived) {
 		isRelevant := strings.Contains(strings.ToLower(e.Response.MIMEType), "application/octet-stream")
@@ -78,29 +85,30 @@ func InitializeBrowser(ctx context.Context) {
 					data = []byte(result.Body)
 				}
 
-				BusLocations.Mutex.Lock()
-				defer BusLocations.Mutex.Unlock()
-
-				err = updateInMemBusLocations(string(data))
+				recordFrame(b.Recorder, b.Name(), string(data))
+				locations, err := ParseFrames(string(data))
 				if err != nil {
 					log.Debugf("Skipping parse (likely not location data or empty frame): %v", err)
-				} else {
-					log.Debug("Bus locations updated successfully")
+					return
 				}
+				store.Update(locations)
 			}(reqID, url)
 		}
 	})()
 
-	page.MustWaitLoad()
+	if err := page.WaitLoad(); err != nil {
+		return fmt.Errorf("failed to load %s: %w", b.url, err)
+	}
 
 	<-ctx.Done()
 	log.Info("browser closed gracefully")
+	return ctx.Err()
 }
 
-func updateInMemBusLocations(response string) (err error) {
-	messages := strings.Split(response, "\x1e")
+// ParseFrames returns the bus locations in a response holding one or more SignalR frames.
+func ParseFrames(response string) ([]BusLocation, error) {
+	messages := strings.Split(response, recordSeparator)
 	var allLocations []BusLocation
-	foundLocations := false
 
 	for _, msg := range messages {
 		msg = strings.TrimSpace(msg)
@@ -114,52 +122,13 @@ func updateInMemBusLocations(response string) (err error) {
 			continue
 		}
 
-		if len(busLocations) > 0 {
-			allLocations = append(allLocations, busLocations...)
-			foundLocations = true
-		}
-	}
-
-	if !foundLocations {
-		return fmt.Errorf("no bus locations found in any message frame")
+		allLocations = append(allLocations, busLocations...)
 	}
 
-	for _, loc := range allLocations {
-		if tracked, exists := BusLocations.Buses[loc.BusID]; exists {
-			tracked.timer.Stop()
-		}
-
-		timer := time.AfterFunc(BusLocations.expiry, func(busID string) func() {
-			return func() {
-				removeBus(busID)
-			}
-		}(loc.BusID))
-
-		BusLocations.Buses[loc.BusID] = &TrackedBus{
-			Location: loc,
-			timer:    timer,
-		}
-	}
-
-	return nil
-}
-
-func removeBus(busID string) {
-	BusLocations.Mutex.Lock()
-	defer BusLocations.Mutex.Unlock()
-	log.Debugf("Bus %s expired and removed", busID)
-	delete(BusLocations.Buses, busID)
-}
-
-func GetAllBuses() []BusLocation {
-	BusLocations.Mutex.RLock()
-	defer BusLocations.Mutex.RUnlock()
-
-	locations := make([]BusLocation, 0, len(BusLocations.Buses))
-	for _, tracked := range BusLocations.Buses {
-		locations = append(locations, tracked.Location)
+	if len(allLocations) == 0 {
+		return nil, fmt.Errorf("no bus locations found in any message frame")
 	}
-	return locations
+	return allLocations, nil
 }
 
 type SignalRResponse struct {
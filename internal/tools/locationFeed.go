@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLocationFeedSize is how many recent changes are kept for subscribers to resume from
+	DefaultLocationFeedSize = 4096
+	// locationSubscriberBuffer is how many changes a subscriber can fall behind by before it is
+	// dropped
+	locationSubscriberBuffer = 256
+)
+
+// LocationEvent is a numbered change to the tracked buses. IDs are only comparable between events
+// of the same feed, which a restart replaces.
+type LocationEvent struct {
+	ID string
+	LocationChange
+}
+
+// LocationFeed numbers the changes to the tracked buses and passes them on to subscribers. The
+// most recent changes are kept, so a subscriber that reconnects can resume where it left off.
+type LocationFeed struct {
+	mutex sync.Mutex
+	// epoch tells the events of this feed from those of a feed before a restart
+	epoch string
+	seq   uint64
+	// recent is a ring of the last changes, each at its number modulo the size
+	recent []LocationEvent
+	buses  map[string]BusLocation
+
+	subscribers map[*LocationSubscription]struct{}
+}
+
+func NewLocationFeed(size int) *LocationFeed {
+	return &LocationFeed{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		recent:      make([]LocationEvent, size),
+		buses:       make(map[string]BusLocation),
+		subscribers: make(map[*LocationSubscription]struct{}),
+	}
+}
+
+func (f *LocationFeed) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", f.epoch, seq)
+}
+
+// Publish numbers changes and sends them to every subscriber. Subscribers too far behind to take
+// them are dropped. It is meant to be registered with LocationStore.OnChange.
+func (f *LocationFeed) Publish(changes []LocationChange) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, change := range changes {
+		f.seq++
+		event := LocationEvent{ID: f.eventID(f.seq), LocationChange: change}
+
+		f.recent[f.seq%uint64(len(f.recent))] = event
+
+		if change.Kind == LocationExpired {
+			delete(f.buses, change.Location.BusID)
+		} else {
+			f.buses[change.Location.BusID] = change.Location
+		}
+
+		for sub := range f.subscribers {
+			select {
+			case sub.events <- event:
+			default:
+				f.drop(sub)
+			}
+		}
+	}
+}
+
+// LocationSubscription follows the changes to the tracked buses from the point it was made.
+type LocationSubscription struct {
+	// Backlog catches the subscriber up before it follows Events. It is the changes since the
+	// event resumed from if Resumed, and otherwise every tracked bus as added.
+	Backlog []LocationEvent
+	Resumed bool
+	// Tracked is the IDs of the buses tracked at the point the backlog starts from
+	Tracked []string
+
+	feed   *LocationFeed
+	events chan LocationEvent
+}
+
+// Events receives the changes that follow the backlog. It is closed if the subscriber falls too
+// far behind.
+func (s *LocationSubscription) Events() <-chan LocationEvent {
+	return s.events
+}
+
+// Close stops following the feed.
+func (s *LocationSubscription) Close() {
+	s.feed.mutex.Lock()
+	defer s.feed.mutex.Unlock()
+	s.feed.drop(s)
+}
+
+// drop removes a subscriber. The caller must hold the lock.
+func (f *LocationFeed) drop(sub *LocationSubscription) {
+	if _, ok := f.subscribers[sub]; ok {
+		delete(f.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Subscribe follows the feed, resuming after the event with lastEventID if it is one of the
+// recent events of this feed, or starting from the current buses otherwise.
+func (f *LocationFeed) Subscribe(lastEventID string) *LocationSubscription {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	sub := &LocationSubscription{
+		Tracked: slices.Sorted(maps.Keys(f.buses)),
+		feed:    f,
+		events:  make(chan LocationEvent, locationSubscriberBuffer),
+	}
+
+	if seq, ok := f.resumable(lastEventID); ok {
+		sub.Resumed = true
+		for seq++; seq <= f.seq; seq++ {
+			sub.Backlog = append(sub.Backlog, f.recent[seq%uint64(len(f.recent))])
+		}
+
+		// undo the backlog to find the buses tracked when the subscriber left off
+		tracked := make(map[string]bool)
+		for _, busID := range sub.Tracked {
+			tracked[busID] = true
+		}
+		for _, event := range slices.Backward(sub.Backlog) {
+			switch event.Kind {
+			case LocationAdded:
+				delete(tracked, event.Location.BusID)
+			case LocationExpired:
+				tracked[event.Location.BusID] = true
+			}
+		}
+		sub.Tracked = slices.Sorted(maps.Keys(tracked))
+	} else {
+		id := f.eventID(f.seq)
+		for _, busID := range sub.Tracked {
+			change := LocationChange{Kind: LocationAdded, Location: f.buses[busID]}
+			sub.Backlog = append(sub.Backlog, LocationEvent{ID: id, LocationChange: change})
+		}
+	}
+
+	f.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// resumable reports whether the events after an event are all still kept, returning its number.
+// The caller must hold the lock.
+func (f *LocationFeed) resumable(lastEventID string) (uint64, bool) {
+	epoch, seqStr, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != f.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > f.seq || f.seq-seq > min(f.seq, uint64(len(f.recent))) {
+		return 0, false
+	}
+	return seq, true
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LocationSource is a feed of bus locations, such as a live tracking site.
+type LocationSource interface {
+	// Name identifies the source in logs
+	Name() string
+	// Run records locations in the store as they are received, until the context is cancelled or
+	// the source fails.
+	Run(ctx context.Context, store *LocationStore) error
+}
+
+// RunLocationSources runs every source into the same store, returning once all of them have
+// stopped. A source failing is logged and does not stop the others.
+func RunLocationSources(ctx context.Context, store *LocationStore, sources ...LocationSource) {
+	var wg sync.WaitGroup
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Infof("Starting location source %s", source.Name())
+			err := source.Run(ctx, store)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Errorf("Location source %s stopped: %v", source.Name(), err)
+				return
+			}
+			log.Infof("Location source %s stopped", source.Name())
+		}()
+	}
+	wg.Wait()
+}
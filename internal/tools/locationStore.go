@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultLocationExpiry is how long a bus is kept after its last reported location.
+const DefaultLocationExpiry = time.Minute * 2
+
+// LocationStore holds the latest location of every tracked bus. Buses that are not reported again
+// within the expiry are removed.
+type LocationStore struct {
+	mutex  sync.RWMutex
+	buses  map[string]*TrackedBus
+	expiry time.Duration
+	// onUpdate are called with all tracked buses after new locations are received
+	onUpdate []func([]BusLocation)
+	// onChange are called with the changes to the tracked buses as they happen
+	onChange []func([]LocationChange)
+}
+
+type LocationChangeKind string
+
+const (
+	LocationAdded   LocationChangeKind = "added"
+	LocationUpdated LocationChangeKind = "updated"
+	LocationExpired LocationChangeKind = "expired"
+)
+
+// LocationChange is a bus starting to be tracked, moving or expiring. Expired changes hold the
+// last location of the bus.
+type LocationChange struct {
+	Kind     LocationChangeKind
+	Location BusLocation
+}
+
+type TrackedBus struct {
+	Location BusLocation
+	timer    *time.Timer
+}
+
+func NewLocationStore(expiry time.Duration) *LocationStore {
+	return &LocationStore{
+		buses:  make(map[string]*TrackedBus),
+		expiry: expiry,
+	}
+}
+
+// Update records new locations, replacing any held for the same buses, then calls the functions
+// registered with OnUpdate with all tracked buses.
+func (s *LocationStore) Update(locations []BusLocation) {
+	if len(locations) == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	var changes []LocationChange
+	for _, loc := range locations {
+		change := LocationChange{Kind: LocationAdded, Location: loc}
+		if tracked, exists := s.buses[loc.BusID]; exists {
+			tracked.timer.Stop()
+			change.Kind = LocationUpdated
+			if tracked.Location == loc {
+				change.Kind = ""
+			}
+		}
+		if change.Kind != "" {
+			changes = append(changes, change)
+		}
+
+		tracked := &TrackedBus{Location: loc}
+		tracked.timer = time.AfterFunc(s.expiry, func() { s.remove(tracked) })
+		s.buses[loc.BusID] = tracked
+	}
+	s.notifyChange(changes)
+	onUpdate := s.onUpdate
+	s.mutex.Unlock()
+
+	log.Debug("Bus locations updated successfully")
+	if len(onUpdate) > 0 {
+		buses := s.Buses()
+		for _, f := range onUpdate {
+			f(buses)
+		}
+	}
+}
+
+// remove expires a bus, unless it was updated since the timer expiring it fired.
+func (s *LocationStore) remove(expired *TrackedBus) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	busID := expired.Location.BusID
+	if s.buses[busID] != expired {
+		return
+	}
+	log.Debugf("Bus %s expired and removed", busID)
+	delete(s.buses, busID)
+	s.notifyChange([]LocationChange{{Kind: LocationExpired, Location: expired.Location}})
+}
+
+// notifyChange calls the functions registered with OnChange. The caller must hold the lock, so
+// changes are passed on in the order they happen.
+func (s *LocationStore) notifyChange(changes []LocationChange) {
+	if len(changes) == 0 {
+		return
+	}
+	for _, f := range s.onChange {
+		f(changes)
+	}
+}
+
+// OnUpdate registers a function to call with all tracked buses whenever new locations are
+// received, along with any registered before.
+func (s *LocationStore) OnUpdate(onUpdate func(locations []BusLocation)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onUpdate = append(s.onUpdate, onUpdate)
+}
+
+// OnChange registers a function to call with every change to the tracked buses, in the order they
+// happen. It is called while the store is locked, so it must not call back into the store.
+func (s *LocationStore) OnChange(onChange func(changes []LocationChange)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onChange = append(s.onChange, onChange)
+}
+
+// Buses returns the latest location of every tracked bus.
+func (s *LocationStore) Buses() []BusLocation {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	locations := make([]BusLocation, 0, len(s.buses))
+	for _, tracked := range s.buses {
+		locations = append(locations, tracked.Location)
+	}
+	return locations
+}
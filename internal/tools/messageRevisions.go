@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"time"
+)
+
+// MessageID returns the ID of the message an entry belongs to, which is its timestamp for
+// messages written before messages had IDs.
+func (m MessageLog) MessageID() string {
+	if m.ID != "" {
+		return m.ID
+	}
+	return m.Timestamp
+}
+
+// IsOriginal reports whether an entry posts a message, rather than revising or retracting one.
+func (m MessageLog) IsOriginal() bool {
+	return m.EditedAt == "" && m.RetractedAt == ""
+}
+
+// Expired reports whether the active window of a message ended at or before now.
+func (m MessageLog) Expired(now time.Time) bool {
+	until, err := time.Parse(time.RFC3339, m.ActiveUntil)
+	return err == nil && !until.After(now)
+}
+
+// NewRevision creates a log entry that replaces a message with a revised one, keeping its ID and
+// the time it was first posted.
+func NewRevision(original, revised MessageLog) MessageLog {
+	revised.ID = original.MessageID()
+	revised.Timestamp = original.Timestamp
+	revised.EditedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	revised.RetractedAt = ""
+	return revised
+}
+
+// NewTombstone creates a log entry that retracts a message.
+func NewTombstone(original MessageLog) MessageLog {
+	return MessageLog{
+		ID:          original.MessageID(),
+		Timestamp:   original.Timestamp,
+		RetractedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// EffectiveMessages folds the entries of a message log, oldest first, into the current state of
+// each message, in the order they were first posted. Revisions replace the message they revise
+// and tombstones remove it. Revisions and tombstones of messages that are not among the entries
+// are ignored, so a tail of the log gives the state of the messages posted within it.
+func EffectiveMessages(entries []MessageLog) []MessageLog {
+	var messages []MessageLog
+	var retracted []bool
+	index := make(map[string]int, len(entries))
+
+	for _, entry := range entries {
+		id := entry.MessageID()
+		i, posted := index[id]
+		switch {
+		case entry.IsOriginal():
+			if !posted {
+				index[id] = len(messages)
+				messages = append(messages, entry)
+				retracted = append(retracted, false)
+			}
+		case !posted || retracted[i]:
+			continue
+		case entry.RetractedAt != "":
+			retracted[i] = true
+		default:
+			messages[i] = entry
+		}
+	}
+
+	effective := messages[:0]
+	for i, message := range messages {
+		if !retracted[i] {
+			effective = append(effective, message)
+		}
+	}
+	return effective
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// messageSegmentMaxLines is the number of messages a segment holds before a new one is started.
+	// It bounds how much data a single append has to download and upload again.
+	messageSegmentMaxLines = 500
+
+	// conditionalWriteAttempts is how often a conditional write is retried after losing a race
+	// with another replica before giving up.
+	conditionalWriteAttempts = 10
+)
+
+// messageLogManifest indexes the segments that make up the message log, oldest first.
+// The manifest is rewritten on every append, so its version ID identifies the state of the whole log.
+type messageLogManifest struct {
+	Segments []messageSegment `json:"segments"`
+}
+
+// messageSegment describes a single JSONL segment object of the message log.
+type messageSegment struct {
+	Name  string `json:"name"`
+	Lines int    `json:"lines"`
+	Size  int64  `json:"size"`
+}
+
+// tailSegments returns the newest segments that together hold at least n messages.
+func (ml *messageLogManifest) tailSegments(n int) []messageSegment {
+	lines := 0
+	start := len(ml.Segments)
+	for start > 0 && lines < n {
+		start--
+		lines += ml.Segments[start].Lines
+	}
+	return ml.Segments[start:]
+}
+
+func (m *MinIOStorageManager) segmentName(index int) string {
+	return fmt.Sprintf("%s%08d.jsonl", m.messagingSegmentPrefix, index)
+}
+
+// readManifest retrieves the message log manifest along with its version ID and ETag.
+// It returns NoMessageLogFound if no message has been stored yet.
+func (m *MinIOStorageManager) readManifest() (manifest messageLogManifest, versionID string, etag string, err error) {
+	data, info, err := m.readMessagingObjectWithInfo(m.messagingManifestName)
+	if err != nil {
+		if errors.Is(err, KeyNotFound) {
+			return manifest, "", "", NoMessageLogFound
+		}
+		return manifest, "", "", err
+	}
+
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return manifest, "", "", fmt.Errorf("failed to decode message log manifest: %w", err)
+	}
+	return manifest, info.VersionID, info.ETag, nil
+}
+
+// writeManifest uploads the message log manifest, returning its new version ID.
+func (m *MinIOStorageManager) writeManifest(manifest messageLogManifest, opts PutObjectOptions) (versionID string, err error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	opts.ContentType = "application/json"
+	uploadInfo, err := m.writeMessagingObject(m.messagingManifestName, data, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload message log manifest: %w", err)
+	}
+	return uploadInfo.VersionID, nil
+}
+
+// recordSegment updates the manifest entry of the segment at the given 1-based index.
+// Other replicas may be updating the manifest at the same time, so the update is a
+// compare-and-swap on the manifest's ETag, retried until it succeeds. Segments only ever
+// grow, so an entry that already records more lines than the given one is left as it is.
+func (m *MinIOStorageManager) recordSegment(index int, segment messageSegment) (versionID string, err error) {
+	for attempt := 1; attempt <= conditionalWriteAttempts; attempt++ {
+		manifest, _, etag, err := m.readManifest()
+		if err != nil && !errors.Is(err, NoMessageLogFound) {
+			return "", fmt.Errorf("failed to read message log manifest: %w", err)
+		}
+
+		// entries for segments created by a replica that failed before recording them are filled in
+		for len(manifest.Segments) < index {
+			manifest.Segments = append(manifest.Segments, messageSegment{Name: m.segmentName(len(manifest.Segments) + 1)})
+		}
+		if entry := &manifest.Segments[index-1]; entry.Lines < segment.Lines {
+			*entry = segment
+		}
+
+		opts := PutObjectOptions{MatchETag: etag}
+		if etag == "" {
+			opts.NoneMatchETag = "*"
+		}
+		versionID, err = m.writeManifest(manifest, opts)
+		if isWriteConflict(err) {
+			log.Debugf("Message log manifest was modified concurrently, retrying (attempt %d/%d)", attempt, conditionalWriteAttempts)
+			backoff(attempt)
+			continue
+		}
+		return versionID, err
+	}
+
+	return "", fmt.Errorf("failed to update message log manifest after %d attempts: %w", conditionalWriteAttempts, PreconditionFailed)
+}
+
+// readSegment downloads a segment along with the ETag to make a conditional write against.
+// A segment that does not exist yet is returned empty with an empty ETag.
+func (m *MinIOStorageManager) readSegment(segmentName string) (data []byte, etag string, err error) {
+	data, info, err := m.readMessagingObjectWithInfo(segmentName)
+	if errors.Is(err, KeyNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, info.ETag, nil
+}
+
+// readSegments downloads and concatenates the given segments in order.
+func (m *MinIOStorageManager) readSegments(segments []messageSegment) (*bytes.Buffer, error) {
+	messageLog := &bytes.Buffer{}
+	for _, segment := range segments {
+		data, err := m.readMessagingObject(segment.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message log segment %s: %w", segment.Name, err)
+		}
+		messageLog.Write(data)
+	}
+	return messageLog, nil
+}
+
+func (m *MinIOStorageManager) readMessagingObject(objectName string) ([]byte, error) {
+	log.Debugf("Retrieving %s from %s", objectName, m.messagingBucketName)
+	r, err := m.client.GetObject(m.ctx, m.messagingBucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer func(r io.ReadCloser) {
+		if closeErr := r.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}(r)
+
+	return io.ReadAll(r)
+}
+
+// readMessagingObjectWithInfo downloads an object along with the metadata of the version read.
+// The object is stat'ed before it is read, so the returned ETag is never newer than the data;
+// if the object changes in between, a write conditional on the ETag fails and is retried.
+func (m *MinIOStorageManager) readMessagingObjectWithInfo(objectName string) ([]byte, ObjectInfo, error) {
+	info, err := m.client.StatObject(m.ctx, m.messagingBucketName, objectName)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	data, err := m.readMessagingObject(objectName)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return data, info, nil
+}
+
+func (m *MinIOStorageManager) writeMessagingObject(objectName string, data []byte, opts PutObjectOptions) (UploadInfo, error) {
+	log.Debugf("Uploading %s to %s, size: %d", objectName, m.messagingBucketName, len(data))
+	return m.client.PutObject(
+		m.ctx,
+		m.messagingBucketName,
+		objectName,
+		bytes.NewReader(data),
+		int64(len(data)),
+		opts,
+	)
+}
+
+// migrateLegacyMessageLog imports a message log written as a single object by older
+// releases as the first segment, so existing messages survive the switch to segments.
+func (m *MinIOStorageManager) migrateLegacyMessageLog() error {
+	m.messagingMutex.Lock()
+	defer m.messagingMutex.Unlock()
+
+	_, _, _, err := m.readManifest()
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, NoMessageLogFound) {
+		return err
+	}
+
+	data, err := m.readMessagingObject(m.messagingObjectName)
+	if err != nil {
+		if errors.Is(err, KeyNotFound) {
+			log.Debug("No legacy message log to migrate")
+			return nil
+		}
+		return fmt.Errorf("failed to read legacy message log: %w", err)
+	}
+
+	log.Infof("Migrating legacy message log %s to segments", m.messagingObjectName)
+	data = terminateLine(data)
+	segment := messageSegment{
+		Name:  m.segmentName(1),
+		Lines: countLines(data),
+		Size:  int64(len(data)),
+	}
+	// another replica may be migrating at the same time, in which case its copy is kept
+	_, err = m.writeMessagingObject(segment.Name, data, PutObjectOptions{ContentType: "text/jsonl", NoneMatchETag: "*"})
+	if err == nil {
+		_, err = m.writeManifest(messageLogManifest{Segments: []messageSegment{segment}}, PutObjectOptions{NoneMatchETag: "*"})
+	}
+	if isWriteConflict(err) {
+		log.Info("Legacy message log was migrated by another replica")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate legacy message log: %w", err)
+	}
+
+	log.Infof("Migrated %d messages from legacy message log", segment.Lines)
+	return nil
+}
+
+// countLines returns the number of newline terminated lines in data.
+func countLines(data []byte) int {
+	return bytes.Count(data, []byte("\n"))
+}
+
+// terminateLine makes sure non-empty data ends with a newline,
+// so the next message appended after it starts on its own line.
+func terminateLine(data []byte) []byte {
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return data
+}
+
+// isWriteConflict reports whether a conditional write failed because another writer got there first.
+// S3 compatible storage answers an If-Match on a deleted object with "not found" rather than a
+// failed precondition, so both count as a conflict.
+func isWriteConflict(err error) bool {
+	return errors.Is(err, PreconditionFailed) || errors.Is(err, KeyNotFound)
+}
+
+// backoff sleeps before retrying a conflicting write, growing with each attempt and
+// jittered so replicas that collided do not retry in lockstep.
+func backoff(attempt int) {
+	base := time.Duration(attempt) * 10 * time.Millisecond
+	time.Sleep(base + rand.N(base))
+}
@@ -29,7 +29,8 @@ func NewMinIOClient(client *minio.Client) *MinIOClient {
 
 // BucketExists checks if a bucket exists in the object storage.
 func (m *MinIOClient) BucketExists(ctx context.Context, bucketName string) (bool, error) {
-	return m.client.BucketExists(ctx, bucketName)
+	exists, err := m.client.BucketExists(ctx, bucketName)
+	return exists, translateError(err)
 }
 
 // MakeBucket creates a new bucket with the given region.
@@ -68,17 +69,49 @@ func (m *MinIOClient) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
 
 // GetObject retrieves an object from storage.
 // Returned io.ReadCloser must be closed after use to release resources.
+// MinIO only contacts the server on first read, so the object is stat'ed up front
+// to report missing objects here rather than on the caller's first Read.
 func (m *MinIOClient) GetObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, error) {
-	return m.client.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
+	object, err := m.client.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if _, err = object.Stat(); err != nil {
+		_ = object.Close()
+		return nil, translateError(err)
+	}
+	return object, nil
+}
+
+// GetObjectVersion retrieves a specific version of an object from storage.
+// Returned io.ReadCloser must be closed after use to release resources.
+func (m *MinIOClient) GetObjectVersion(ctx context.Context, bucketName, objectName, versionID string) (io.ReadCloser, error) {
+	object, err := m.client.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{VersionID: versionID})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if _, err = object.Stat(); err != nil {
+		_ = object.Close()
+		return nil, translateError(err)
+	}
+	return object, nil
 }
 
 // PutObject uploads an object to storage.
+// Conditions in opts are sent as If-Match / If-None-Match headers, so the server rejects the
+// upload if another writer changed the object first.
 // Returns upload information including the version ID if versioning is enabled.
-func (m *MinIOClient) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, contentType string) (UploadInfo, error) {
-	opts := minio.PutObjectOptions{ContentType: contentType}
-	uploadInfo, err := m.client.PutObject(ctx, bucketName, objectName, reader, objectSize, opts)
+func (m *MinIOClient) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, opts PutObjectOptions) (UploadInfo, error) {
+	minioOpts := minio.PutObjectOptions{ContentType: opts.ContentType}
+	if opts.MatchETag != "" {
+		minioOpts.SetMatchETag(opts.MatchETag)
+	}
+	if opts.NoneMatchETag != "" {
+		minioOpts.SetMatchETagExcept(opts.NoneMatchETag)
+	}
+	uploadInfo, err := m.client.PutObject(ctx, bucketName, objectName, reader, objectSize, minioOpts)
 	if err != nil {
-		return UploadInfo{}, err
+		return UploadInfo{}, translateError(err)
 	}
 
 	// Convert MinIO upload info to generic type
@@ -94,7 +127,7 @@ func (m *MinIOClient) PutObject(ctx context.Context, bucketName, objectName stri
 func (m *MinIOClient) StatObject(ctx context.Context, bucketName, objectName string) (ObjectInfo, error) {
 	info, err := m.client.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{})
 	if err != nil {
-		return ObjectInfo{}, err
+		return ObjectInfo{}, translateError(err)
 	}
 
 	// Convert MinIO object info to generic type
@@ -113,7 +146,7 @@ func (m *MinIOClient) StatObject(ctx context.Context, bucketName, objectName str
 func (m *MinIOClient) GetObjectAttributes(ctx context.Context, bucketName, objectName string) (ObjectAttributes, error) {
 	attrs, err := m.client.GetObjectAttributes(ctx, bucketName, objectName, minio.ObjectAttributesOptions{})
 	if err != nil {
-		return ObjectAttributes{}, err
+		return ObjectAttributes{}, translateError(err)
 	}
 
 	// Convert MinIO attributes to generic type
@@ -129,6 +162,73 @@ func (m *MinIOClient) PresignedGetObject(ctx context.Context, bucketName, object
 	return m.client.PresignedGetObject(ctx, bucketName, objectName, expiry, reqParams)
 }
 
+// ListObjectVersions lists every stored version of an object, newest first.
+// Delete markers are skipped, as they hold no downloadable data.
+func (m *MinIOClient) ListObjectVersions(ctx context.Context, bucketName, objectName string) ([]ObjectInfo, error) {
+	opts := minio.ListObjectsOptions{
+		Prefix:       objectName,
+		WithVersions: true,
+	}
+
+	var result []ObjectInfo
+	for info := range m.client.ListObjects(ctx, bucketName, opts) {
+		if info.Err != nil {
+			return nil, translateError(info.Err)
+		}
+		// the prefix also matches longer keys, so only keep exact matches
+		if info.Key != objectName || info.IsDeleteMarker {
+			continue
+		}
+
+		result = append(result, ObjectInfo{
+			Key:          info.Key,
+			Size:         info.Size,
+			ETag:         info.ETag,
+			LastModified: info.LastModified,
+			ContentType:  info.ContentType,
+			VersionID:    info.VersionID,
+		})
+	}
+	return result, nil
+}
+
+// CopyObject performs a server-side copy of a specific version of an object.
+// The copy becomes the latest version of the destination object, and no data passes through Mercury.
+func (m *MinIOClient) CopyObject(ctx context.Context, dstBucketName, dstObjectName, srcBucketName, srcObjectName, srcVersionID string) (UploadInfo, error) {
+	dst := minio.CopyDestOptions{Bucket: dstBucketName, Object: dstObjectName}
+	src := minio.CopySrcOptions{Bucket: srcBucketName, Object: srcObjectName, VersionID: srcVersionID}
+	uploadInfo, err := m.client.CopyObject(ctx, dst, src)
+	if err != nil {
+		return UploadInfo{}, translateError(err)
+	}
+
+	return UploadInfo{
+		Bucket:    uploadInfo.Bucket,
+		Key:       uploadInfo.Key,
+		VersionID: uploadInfo.VersionID,
+		ETag:      uploadInfo.ETag,
+	}, nil
+}
+
+// translateError maps MinIO "not found" and precondition error responses to the generic storage errors,
+// so callers can check for them with errors.Is regardless of the storage implementation.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch minio.ToErrorResponse(err).Code {
+	case minio.PreconditionFailed:
+		return fmt.Errorf("%w: %v", PreconditionFailed, err)
+	case minio.NoSuchKey, minio.NoSuchVersion:
+		return fmt.Errorf("%w: %v", KeyNotFound, err)
+	case minio.NoSuchBucket:
+		return fmt.Errorf("%w: %v", BucketNotFound, err)
+	default:
+		return err
+	}
+}
+
 // MinIOStorageManager implements the ObjectStorageManager interface.
 // It provides a complete storage solution for GTFS schedules and message logs.
 type MinIOStorageManager struct {
@@ -141,26 +241,36 @@ type MinIOStorageManager struct {
 	gtfsMutex      sync.RWMutex
 
 	// Messaging-specific fields
-	messagingBucketName string
-	messagingObjectName string
-	messagingMutex      sync.RWMutex
+	messagingBucketName    string
+	messagingObjectName    string // single object log written by older releases
+	messagingManifestName  string
+	messagingSegmentPrefix string
+	messagingMutex         sync.RWMutex
+
+	// Position history fields
+	historyBucketName string
+	historyMutex      sync.Mutex
 }
 
 // NewMinIOStorageManager creates a new storage manager with the given client.
 // The manager uses default bucket and object names, which can be customized if needed.
 func NewMinIOStorageManager(client ObjectStorageClient, ctx context.Context) *MinIOStorageManager {
 	return &MinIOStorageManager{
-		client:              client,
-		ctx:                 ctx,
-		gtfsBucketName:      "gtfs",
-		gtfsObjectName:      "GTFSSchedule.zip",
-		messagingBucketName: "messages",
-		messagingObjectName: "messages.jsonl",
+		client:                 client,
+		ctx:                    ctx,
+		gtfsBucketName:         "gtfs",
+		gtfsObjectName:         "GTFSSchedule.zip",
+		messagingBucketName:    "messages",
+		messagingObjectName:    "messages.jsonl",
+		messagingManifestName:  "manifest.json",
+		messagingSegmentPrefix: "segments/",
+		historyBucketName:      "history",
 	}
 }
 
 // Initialize sets up the storage client and creates necessary buckets.
-// It creates both the GTFS and messaging buckets with versioning enabled.
+// It creates the GTFS and messaging buckets with versioning enabled, and the position history
+// bucket without, as its objects are rewritten as positions are appended.
 // It includes a retry mechanism for connection failures.
 func (m *MinIOStorageManager) Initialize() error {
 	maxAttempts := 10
@@ -205,21 +315,30 @@ func (m *MinIOStorageManager) Initialize() error {
 // initBuckets performs the actual bucket creation logic.
 func (m *MinIOStorageManager) initBuckets() error {
 	// Create GTFS bucket
-	if err := m.createBucket(m.gtfsBucketName); err != nil {
+	if err := m.createBucket(m.gtfsBucketName, true); err != nil {
 		return fmt.Errorf("failed to create GTFS bucket: %w", err)
 	}
 
 	// Create messaging bucket
-	if err := m.createBucket(m.messagingBucketName); err != nil {
+	if err := m.createBucket(m.messagingBucketName, true); err != nil {
 		return fmt.Errorf("failed to create messaging bucket: %w", err)
 	}
 
+	// Create position history bucket
+	if err := m.createBucket(m.historyBucketName, false); err != nil {
+		return fmt.Errorf("failed to create position history bucket: %w", err)
+	}
+
+	if err := m.migrateLegacyMessageLog(); err != nil {
+		return fmt.Errorf("failed to migrate message log: %w", err)
+	}
+
 	return nil
 }
 
 // createBucket is a helper method that creates a bucket if it doesn't exist
-// and enables versioning on it.
-func (m *MinIOStorageManager) createBucket(bucketName string) error {
+// and enables or disables versioning on it.
+func (m *MinIOStorageManager) createBucket(bucketName string, versioned bool) error {
 	// Check if bucket exists
 	exists, err := m.client.BucketExists(m.ctx, bucketName)
 	if err != nil {
@@ -237,9 +356,9 @@ func (m *MinIOStorageManager) createBucket(bucketName string) error {
 		log.Debugf("Bucket already exists: %s", bucketName)
 	}
 
-	// Enable versioning
+	// Enable or disable versioning
 	log.Debugf("Setting versioning for bucket: %s", bucketName)
-	err = m.client.SetBucketVersioning(m.ctx, bucketName, true)
+	err = m.client.SetBucketVersioning(m.ctx, bucketName, versioned)
 	if err != nil {
 		return fmt.Errorf("failed to set bucket versioning: %w", err)
 	}
@@ -286,14 +405,8 @@ func (m *MinIOStorageManager) GetLatestURL() (downloadURL *url.URL, versionID st
 	m.gtfsMutex.RLock()
 	defer m.gtfsMutex.RUnlock()
 
-	// Set up presigned URL parameters
-	expiryTime := 5 * time.Minute
-	reqParams := make(url.Values)
-	reqParams.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%s", m.gtfsObjectName))
-	reqParams.Set("response-content-type", "application/zip")
-
 	// Generate presigned URL
-	downloadURL, err = m.client.PresignedGetObject(m.ctx, m.gtfsBucketName, m.gtfsObjectName, expiryTime, reqParams)
+	downloadURL, err = m.presignGTFSSchedule(make(url.Values))
 
 	if err != nil {
 		if errors.Is(err, KeyNotFound) {
@@ -315,12 +428,138 @@ func (m *MinIOStorageManager) GetLatestURL() (downloadURL *url.URL, versionID st
 	return downloadURL, versionID, nil
 }
 
+// GetLatestSchedule downloads the latest GTFS schedule zip along with its version ID.
+// The object is stat'ed before it is read, so if another replica uploads a schedule in between,
+// the version ID is older than the data and the schedule is simply downloaded again next time.
+func (m *MinIOStorageManager) GetLatestSchedule() (schedule []byte, versionID string, err error) {
+	m.gtfsMutex.RLock()
+	defer m.gtfsMutex.RUnlock()
+
+	log.Debugf("Retrieving %s from %s", m.gtfsObjectName, m.gtfsBucketName)
+	info, err := m.client.StatObject(m.ctx, m.gtfsBucketName, m.gtfsObjectName)
+	if err == nil {
+		var r io.ReadCloser
+		r, err = m.client.GetObject(m.ctx, m.gtfsBucketName, m.gtfsObjectName)
+		if err == nil {
+			defer func(r io.ReadCloser) {
+				if closeErr := r.Close(); closeErr != nil {
+					log.Error(closeErr)
+				}
+			}(r)
+			schedule, err = io.ReadAll(r)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, KeyNotFound) {
+			log.Debug("No GTFS schedule found on server")
+			return nil, "", NoGTFSScheduleFound
+		}
+		return nil, "", err
+	}
+
+	return schedule, info.VersionID, nil
+}
+
+// GetScheduleVersion downloads a specific version of the GTFS schedule.
+// It returns GTFSVersionNotFound if the version does not exist.
+func (m *MinIOStorageManager) GetScheduleVersion(versionID string) (schedule []byte, err error) {
+	m.gtfsMutex.RLock()
+	defer m.gtfsMutex.RUnlock()
+
+	log.Debugf("Retrieving version %s of %s from %s", versionID, m.gtfsObjectName, m.gtfsBucketName)
+	r, err := m.client.GetObjectVersion(m.ctx, m.gtfsBucketName, m.gtfsObjectName, versionID)
+	if err != nil {
+		if errors.Is(err, KeyNotFound) {
+			return nil, GTFSVersionNotFound
+		}
+		return nil, err
+	}
+	defer func(r io.ReadCloser) {
+		if closeErr := r.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}(r)
+
+	return io.ReadAll(r)
+}
+
+// GetVersionURL returns a presigned URL to download a specific version of the GTFS schedule.
+// The version is looked up first, as presigning alone does not check that it exists.
+func (m *MinIOStorageManager) GetVersionURL(versionID string) (downloadURL *url.URL, err error) {
+	m.gtfsMutex.RLock()
+	defer m.gtfsMutex.RUnlock()
+
+	if _, err = m.findGTFSVersion(versionID); err != nil {
+		return nil, err
+	}
+
+	reqParams := make(url.Values)
+	reqParams.Set("versionId", versionID)
+	downloadURL, err = m.presignGTFSSchedule(reqParams)
+	if err != nil {
+		if errors.Is(err, KeyNotFound) {
+			return nil, GTFSVersionNotFound
+		}
+		return nil, err
+	}
+
+	return downloadURL, nil
+}
+
+// presignGTFSSchedule generates a 5 minute presigned URL for the GTFS schedule,
+// adding headers to force download with the correct filename to reqParams.
+func (m *MinIOStorageManager) presignGTFSSchedule(reqParams url.Values) (*url.URL, error) {
+	expiryTime := 5 * time.Minute
+	reqParams.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%s", m.gtfsObjectName))
+	reqParams.Set("response-content-type", "application/zip")
+
+	return m.client.PresignedGetObject(m.ctx, m.gtfsBucketName, m.gtfsObjectName, expiryTime, reqParams)
+}
+
+// findGTFSVersion returns the metadata of a stored GTFS schedule version.
+// The caller must hold gtfsMutex.
+func (m *MinIOStorageManager) findGTFSVersion(versionID string) (ObjectInfo, error) {
+	log.Debugf("Looking up version %s of %s/%s", versionID, m.gtfsBucketName, m.gtfsObjectName)
+	versions, err := m.client.ListObjectVersions(m.ctx, m.gtfsBucketName, m.gtfsObjectName)
+	if err != nil && !errors.Is(err, KeyNotFound) {
+		return ObjectInfo{}, err
+	}
+
+	for _, v := range versions {
+		if v.VersionID == versionID {
+			return v, nil
+		}
+	}
+
+	log.Debugf("GTFS schedule version %s not found", versionID)
+	return ObjectInfo{}, GTFSVersionNotFound
+}
+
 // PutSchedule uploads a new GTFS schedule.
+// If expectedVersionID is set, the upload is conditional on the ETag of that version, so it
+// fails with GTFSVersionConflict if any replica published another schedule in the meantime.
 // It returns the version ID of the newly uploaded schedule.
-func (m *MinIOStorageManager) PutSchedule(reader io.Reader, fileSize int64) (versionID string, err error) {
+func (m *MinIOStorageManager) PutSchedule(reader io.Reader, fileSize int64, expectedVersionID string) (versionID string, err error) {
 	m.gtfsMutex.Lock()
 	defer m.gtfsMutex.Unlock()
 
+	opts := PutObjectOptions{ContentType: "application/zip"}
+	if expectedVersionID != "" {
+		log.Debugf("Checking %s is still at version %s", m.gtfsObjectName, expectedVersionID)
+		info, err := m.client.StatObject(m.ctx, m.gtfsBucketName, m.gtfsObjectName)
+		if err != nil {
+			if errors.Is(err, KeyNotFound) {
+				return "", GTFSVersionConflict
+			}
+			return "", err
+		}
+		if info.VersionID != expectedVersionID {
+			log.Debugf("Latest GTFS version is %s, expected %s", info.VersionID, expectedVersionID)
+			return "", GTFSVersionConflict
+		}
+		opts.MatchETag = info.ETag
+	}
+
 	log.Debugf("Uploading %s to %s, size: %d", m.gtfsObjectName, m.gtfsBucketName, fileSize)
 	uploadInfo, err := m.client.PutObject(
 		m.ctx,
@@ -328,9 +567,12 @@ func (m *MinIOStorageManager) PutSchedule(reader io.Reader, fileSize int64) (ver
 		m.gtfsObjectName,
 		reader,
 		fileSize,
-		"application/zip",
+		opts,
 	)
 	if err != nil {
+		if isWriteConflict(err) && expectedVersionID != "" {
+			return "", GTFSVersionConflict
+		}
 		return "", err
 	}
 
@@ -338,118 +580,175 @@ func (m *MinIOStorageManager) PutSchedule(reader io.Reader, fileSize int64) (ver
 	return uploadInfo.VersionID, nil
 }
 
+// ListGTFSVersions returns every stored version of the GTFS schedule, newest first.
+func (m *MinIOStorageManager) ListGTFSVersions() (versions []ObjectInfo, err error) {
+	m.gtfsMutex.RLock()
+	defer m.gtfsMutex.RUnlock()
+
+	log.Debugf("Listing versions of %s/%s", m.gtfsBucketName, m.gtfsObjectName)
+	versions, err = m.client.ListObjectVersions(m.ctx, m.gtfsBucketName, m.gtfsObjectName)
+	if err != nil && !errors.Is(err, KeyNotFound) {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		log.Debug("No GTFS schedule found on server")
+		return nil, NoGTFSScheduleFound
+	}
+
+	log.Debugf("Found %d GTFS schedule versions", len(versions))
+	return versions, nil
+}
+
+// RollbackSchedule republishes a prior GTFS schedule version as the latest one.
+// The old version is copied server-side, so the rollback is itself a new version
+// and the history of what was published stays intact.
+func (m *MinIOStorageManager) RollbackSchedule(versionID string) (newVersionID string, err error) {
+	m.gtfsMutex.Lock()
+	defer m.gtfsMutex.Unlock()
+
+	if _, err = m.findGTFSVersion(versionID); err != nil {
+		return "", err
+	}
+
+	log.Debugf("Copying version %s of %s to latest", versionID, m.gtfsObjectName)
+	uploadInfo, err := m.client.CopyObject(
+		m.ctx,
+		m.gtfsBucketName,
+		m.gtfsObjectName,
+		m.gtfsBucketName,
+		m.gtfsObjectName,
+		versionID,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Successfully rolled back %s to version %s, new version ID: %s", m.gtfsObjectName, versionID, uploadInfo.VersionID)
+	return uploadInfo.VersionID, nil
+}
+
 // ---------------------------------------
 // MessageStorage Interface Implementation
 // ---------------------------------------
 
 // AppendMessage appends a new message to the message log.
-// It retrieves the existing log, appends the new message, and uploads the combined result.
+// Only the newest segment is downloaded and re-uploaded; once it is full a new segment is started.
+// Writes are conditional on the segment not having changed since it was read, and are retried
+// on conflict, so concurrent appends from several replicas never overwrite each other.
+// It returns the version ID of the updated manifest, which identifies the new state of the log.
 func (m *MinIOStorageManager) AppendMessage(message *bytes.Buffer) (versionID string, err error) {
 	m.messagingMutex.Lock()
 	defer m.messagingMutex.Unlock()
 
-	existingData := bytes.Buffer{}
+	for attempt := 1; attempt <= conditionalWriteAttempts; attempt++ {
+		manifest, _, _, err := m.readManifest()
+		if err != nil {
+			if !errors.Is(err, NoMessageLogFound) {
+				return "", fmt.Errorf("failed to read message log manifest: %w", err)
+			}
+			log.Debug("Message log does not exist, starting new one")
+		}
 
-	// Check if the message log already exists
-	log.Debugf("Checking if %s exists in %s", m.messagingObjectName, m.messagingBucketName)
-	_, err = m.client.StatObject(m.ctx, m.messagingBucketName, m.messagingObjectName)
+		// Use the newest segment, or the next one if there is none yet or the newest one is full
+		segmentIndex := len(manifest.Segments)
+		if segmentIndex == 0 || manifest.Segments[segmentIndex-1].Lines >= messageSegmentMaxLines {
+			segmentIndex++
+		}
+		segmentName := m.segmentName(segmentIndex)
 
-	if err == nil {
-		// File exists, retrieve it
-		log.Debugf("Retrieving existing %s", m.messagingObjectName)
-		r, err := m.client.GetObject(m.ctx, m.messagingBucketName, m.messagingObjectName)
+		data, etag, err := m.readSegment(segmentName)
 		if err != nil {
-			if errors.Is(err, KeyNotFound) {
-				log.Debug("No message log found on server")
-				return "", NoMessageLogFound
-			}
-			return "", fmt.Errorf("failed to get existing message log: %w", err)
+			return "", fmt.Errorf("failed to read message log segment: %w", err)
 		}
-		defer func(r io.ReadCloser) {
-			if closeErr := r.Close(); closeErr != nil {
-				log.Error(closeErr)
-			}
-		}(r)
 
-		_, err = existingData.ReadFrom(r)
+		// Append the new message
+		log.Debugf("Appending new message to segment %s", segmentName)
+		data = terminateLine(append(terminateLine(data), message.Bytes()...))
+
+		opts := PutObjectOptions{ContentType: "text/jsonl", MatchETag: etag}
+		if etag == "" {
+			opts.NoneMatchETag = "*"
+		}
+		_, err = m.writeMessagingObject(segmentName, data, opts)
+		if isWriteConflict(err) {
+			log.Debugf("Segment %s was modified concurrently, retrying (attempt %d/%d)", segmentName, attempt, conditionalWriteAttempts)
+			backoff(attempt)
+			continue
+		}
 		if err != nil {
-			return "", fmt.Errorf("failed to read existing message log: %w", err)
+			return "", err
 		}
-	} else {
-		// error checking is storage-implementation specific
-		if errors.Is(err, KeyNotFound) {
-			log.Debug("Message log does not exist, starting new one")
-		} else {
-			return "", fmt.Errorf("failed to check message log existence: %w", err)
+
+		// The message is stored once the segment is written; the manifest only indexes it
+		segment := messageSegment{Name: segmentName, Lines: countLines(data), Size: int64(len(data))}
+		versionID, err = m.recordSegment(segmentIndex, segment)
+		if err != nil {
+			return "", err
 		}
+
+		log.Debugf("Successfully appended message to %s, version ID: %s", segmentName, versionID)
+		return versionID, nil
 	}
 
-	// Append the new message
-	log.Debug("Appending new message to existing data")
-	_, err = existingData.ReadFrom(message)
+	return "", fmt.Errorf("failed to append message after %d attempts: %w", conditionalWriteAttempts, PreconditionFailed)
+}
+
+// GetLatestLog retrieves the full message log.
+// It downloads every segment and returns them concatenated as a buffer.
+func (m *MinIOStorageManager) GetLatestLog() (messageLog *bytes.Buffer, err error) {
+	m.messagingMutex.RLock()
+	defer m.messagingMutex.RUnlock()
+
+	manifest, _, _, err := m.readManifest()
 	if err != nil {
-		return "", err
+		if errors.Is(err, NoMessageLogFound) {
+			log.Debug("No message log found on server")
+		}
+		return nil, err
 	}
 
-	// Upload the combined data
-	updatedReader := bytes.NewReader(existingData.Bytes())
-	log.Debugf("Uploading updated %s to %s, total size: %d", m.messagingObjectName, m.messagingBucketName, existingData.Len())
-	uploadInfo, err := m.client.PutObject(
-		m.ctx,
-		m.messagingBucketName,
-		m.messagingObjectName,
-		updatedReader,
-		int64(existingData.Len()),
-		"text/jsonl",
-	)
-
+	messageLog, err = m.readSegments(manifest.Segments)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	log.Debugf("Successfully updated %s, version ID: %s", m.messagingObjectName, uploadInfo.VersionID)
-	return uploadInfo.VersionID, nil
+	log.Debugf("Successfully retrieved message log, size: %d bytes", messageLog.Len())
+	return messageLog, nil
 }
 
-// GetLatestLog retrieves the latest message log.
-// It downloads the entire log file and returns it as a buffer.
-func (m *MinIOStorageManager) GetLatestLog() (messageLog *bytes.Buffer, err error) {
+// GetLatestLogTail retrieves the end of the message log holding at least the last n messages.
+// Only the newest segments needed to cover n messages are downloaded, so the result
+// may contain more than n messages and should be trimmed by the caller.
+func (m *MinIOStorageManager) GetLatestLogTail(n int) (messageLog *bytes.Buffer, err error) {
 	m.messagingMutex.RLock()
 	defer m.messagingMutex.RUnlock()
 
-	log.Debugf("Retrieving %s from %s", m.messagingObjectName, m.messagingBucketName)
-	r, err := m.client.GetObject(m.ctx, m.messagingBucketName, m.messagingObjectName)
+	manifest, _, _, err := m.readManifest()
 	if err != nil {
-		if errors.Is(err, KeyNotFound) {
+		if errors.Is(err, NoMessageLogFound) {
 			log.Debug("No message log found on server")
-			return nil, NoMessageLogFound
 		}
 		return nil, err
 	}
-	defer func(r io.ReadCloser) {
-		if closeErr := r.Close(); closeErr != nil {
-			log.Error(closeErr)
-		}
-	}(r)
 
-	messageLog = &bytes.Buffer{}
-	_, err = messageLog.ReadFrom(r)
+	segments := manifest.tailSegments(n)
+	log.Debugf("Reading %d of %d message log segments for the last %d messages", len(segments), len(manifest.Segments), n)
+	messageLog, err = m.readSegments(segments)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Debugf("Successfully retrieved message log, size: %d bytes", messageLog.Len())
 	return messageLog, nil
 }
 
 // GetLatestMessageVersionID returns the version ID of the latest message log.
-// It uses GetObjectAttributes to retrieve the version ID without downloading the file.
+// It uses GetObjectAttributes on the manifest to retrieve the version ID without downloading any segment.
 func (m *MinIOStorageManager) GetLatestMessageVersionID() (versionID string, err error) {
 	m.messagingMutex.RLock()
 	defer m.messagingMutex.RUnlock()
 
-	log.Debugf("Getting attributes for %s/%s", m.messagingBucketName, m.messagingObjectName)
-	attributes, err := m.client.GetObjectAttributes(m.ctx, m.messagingBucketName, m.messagingObjectName)
+	log.Debugf("Getting attributes for %s/%s", m.messagingBucketName, m.messagingManifestName)
+	attributes, err := m.client.GetObjectAttributes(m.ctx, m.messagingBucketName, m.messagingManifestName)
 	if err != nil {
 		if errors.Is(err, KeyNotFound) {
 			log.Debug("No message log found on server")
@@ -3,16 +3,25 @@ package tools
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/url"
 	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 )
 
 var (
 	KeyNotFound         = errors.New("the specified key does not exist")
+	BucketNotFound      = errors.New("the specified bucket does not exist")
 	NoGTFSScheduleFound = errors.New("no GTFS schedule found")
 	NoMessageLogFound   = errors.New("no message log found")
+	GTFSVersionNotFound = errors.New("GTFS schedule version not found")
+	GTFSVersionConflict = errors.New("GTFS schedule has changed since the expected version")
+	PreconditionFailed  = errors.New("the object was modified by another writer")
+	NoPositionsFound    = errors.New("no positions found")
 )
 
 // BucketInfo contains information about a storage bucket
@@ -39,6 +48,20 @@ type UploadInfo struct {
 	ETag      string
 }
 
+// PutObjectOptions controls how an object is uploaded.
+// MatchETag and NoneMatchETag make the upload conditional, failing with PreconditionFailed
+// if the object was changed by another writer since it was read.
+type PutObjectOptions struct {
+	ContentType string
+
+	// MatchETag only stores the object if the current version has this ETag
+	MatchETag string
+
+	// NoneMatchETag only stores the object if the current version does not have this ETag.
+	// "*" only stores the object if it does not exist yet.
+	NoneMatchETag string
+}
+
 // ObjectAttributes contains detailed attributes of an object
 type ObjectAttributes struct {
 	VersionID string
@@ -65,8 +88,12 @@ type ObjectStorageClient interface {
 	// The returned io.ReadCloser must be closed by the caller
 	GetObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, error)
 
-	// PutObject uploads an object to storage
-	PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, contentType string) (UploadInfo, error)
+	// GetObjectVersion retrieves a specific version of an object from storage
+	// The returned io.ReadCloser must be closed by the caller
+	GetObjectVersion(ctx context.Context, bucketName, objectName, versionID string) (io.ReadCloser, error)
+
+	// PutObject uploads an object to storage, optionally only if it has not changed since it was read
+	PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, opts PutObjectOptions) (UploadInfo, error)
 
 	// StatObject gets metadata about an object
 	StatObject(ctx context.Context, bucketName, objectName string) (ObjectInfo, error)
@@ -75,23 +102,85 @@ type ObjectStorageClient interface {
 	GetObjectAttributes(ctx context.Context, bucketName, objectName string) (ObjectAttributes, error)
 
 	// PresignedGetObject generates a presigned URL for downloading an object
+	// A specific version can be requested with the "versionId" request parameter
 	PresignedGetObject(ctx context.Context, bucketName, objectName string, expiry time.Duration, reqParams url.Values) (*url.URL, error)
+
+	// ListObjectVersions lists every stored version of an object, newest first
+	ListObjectVersions(ctx context.Context, bucketName, objectName string) ([]ObjectInfo, error)
+
+	// CopyObject performs a server-side copy of a specific version of an object,
+	// storing it as the latest version of the destination object
+	CopyObject(ctx context.Context, dstBucketName, dstObjectName, srcBucketName, srcObjectName, srcVersionID string) (UploadInfo, error)
 }
 
-// MessageLog represents a single entry in the message log
+// MessageLog represents a single entry in the message log. Every field but the message and its
+// timestamp is optional, and left out of entries written by older releases.
+//
+// The log is append-only, so a message is edited by appending a revision of it and withdrawn by
+// appending a tombstone, both with the ID and timestamp of the message they replace.
 type MessageLog struct {
+	// ID identifies a message across its revisions. Entries written by older releases have none
+	// and are identified by their timestamp instead.
+	ID        string `json:"id,omitempty"`
 	Timestamp string `json:"timestamp"`
 	Message   string `json:"message"`
+	// EditedAt is set on revisions and RetractedAt on tombstones, as RFC 3339 timestamps
+	EditedAt    string `json:"editedAt,omitempty"`
+	RetractedAt string `json:"retractedAt,omitempty"`
+	// Severity, Cause and Effect take the names of the GTFS Realtime alert enums, such as WARNING,
+	// CONSTRUCTION and DETOUR
+	Severity string `json:"severity,omitempty"`
+	Cause    string `json:"cause,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+	// Category is one of MessageCategories
+	Category string `json:"category,omitempty"`
+	// RouteIDs and StopIDs are the GTFS routes and stops the message applies to, or none if it
+	// applies to the whole network
+	RouteIDs []string `json:"routeIDs,omitempty"`
+	StopIDs  []string `json:"stopIDs,omitempty"`
+	// ActiveFrom and ActiveUntil bound when the message applies, as RFC 3339 timestamps. Either
+	// may be empty for a window open on that side.
+	ActiveFrom  string `json:"activeFrom,omitempty"`
+	ActiveUntil string `json:"activeUntil,omitempty"`
 }
 
-// NewMessage creates a new MessageLog entry with the current UTC timestamp
+// MessageCategories are the categories a message can be filed under.
+var MessageCategories = []string{"general", "disruption", "diversion", "plannedWorks", "event", "weather"}
+
+// NewMessage creates a new MessageLog entry with a new ID and the current UTC timestamp
 func NewMessage(msg string) MessageLog {
 	return MessageLog{
+		ID:        uuid.NewString(),
 		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
 		Message:   msg,
 	}
 }
 
+// ParseMessageLog parses a JSONL message log into its entries, oldest first. Lines that are not a
+// valid entry are skipped.
+func ParseMessageLog(messageLog *bytes.Buffer) []MessageLog {
+	var messages []MessageLog
+	for line := range bytes.Lines(messageLog.Bytes()) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var message MessageLog
+		if err := json.Unmarshal(line, &message); err != nil {
+			log.Warnf("Skipping invalid message log entry: %v", err)
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// Time returns when the message was posted, or the zero time if its timestamp is invalid.
+func (m MessageLog) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, m.Timestamp)
+	return t
+}
+
 // GTFSStorage defines the interface for GTFS schedule storage operations.
 // This interface provides high-level operations specific to GTFS data management.
 type GTFSStorage interface {
@@ -99,7 +188,24 @@ type GTFSStorage interface {
 
 	GetLatestURL() (downloadURL *url.URL, versionID string, err error)
 
-	PutSchedule(reader io.Reader, fileSize int64) (versionID string, err error)
+	// GetLatestSchedule downloads the latest GTFS schedule zip
+	GetLatestSchedule() (schedule []byte, versionID string, err error)
+
+	// GetScheduleVersion downloads a specific GTFS schedule version zip
+	GetScheduleVersion(versionID string) (schedule []byte, err error)
+
+	// GetVersionURL returns a presigned URL to download a specific GTFS schedule version
+	GetVersionURL(versionID string) (downloadURL *url.URL, err error)
+
+	// PutSchedule uploads a new GTFS schedule. If expectedVersionID is set, the upload fails
+	// with GTFSVersionConflict unless it is still the latest version
+	PutSchedule(reader io.Reader, fileSize int64, expectedVersionID string) (versionID string, err error)
+
+	// ListGTFSVersions returns every stored version of the GTFS schedule, newest first
+	ListGTFSVersions() (versions []ObjectInfo, err error)
+
+	// RollbackSchedule republishes a prior GTFS schedule version as the latest one
+	RollbackSchedule(versionID string) (newVersionID string, err error)
 }
 
 // MessageStorage defines the interface for message log storage operations.
@@ -109,13 +215,27 @@ type MessageStorage interface {
 
 	GetLatestLog() (messageLog *bytes.Buffer, err error)
 
+	// GetLatestLogTail returns the end of the message log, holding at least the last n messages
+	GetLatestLogTail(n int) (messageLog *bytes.Buffer, err error)
+
 	// GetLatestMessageVersionID returns the version ID of the latest message log
 	GetLatestMessageVersionID() (versionID string, err error)
 }
 
+// PositionHistoryStorage defines the interface for storing where buses were tracked.
+// Positions are stored as JSONL, partitioned by bus and by the hour they were recorded in.
+type PositionHistoryStorage interface {
+	// AppendPositions appends positions of a bus to the partition of the hour they were recorded in
+	AppendPositions(busID string, hour time.Time, positions *bytes.Buffer) error
+
+	// GetPositions returns the positions of a bus recorded in an hour, or NoPositionsFound
+	GetPositions(busID string, hour time.Time) (positions *bytes.Buffer, err error)
+}
+
 type ObjectStorageManager interface {
 	GTFSStorage
 	MessageStorage
+	PositionHistoryStorage
 
 	Initialize() error
 	Close() error
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// positionObjectName returns the name of the object holding the positions of a bus recorded in an
+// hour, such as positions/2026-01-13/08/123.jsonl, so an hour of a bus is read with one request.
+func positionObjectName(busID string, hour time.Time) string {
+	return fmt.Sprintf("positions/%s/%s.jsonl", hour.UTC().Format("2006-01-02/15"), url.PathEscape(busID))
+}
+
+// AppendPositions appends positions of a bus to the partition of the hour they were recorded in.
+// Like message log segments, the partition is downloaded and re-uploaded conditionally on it not
+// having changed, and retried on conflict, so replicas appending at once do not lose positions.
+func (m *MinIOStorageManager) AppendPositions(busID string, hour time.Time, positions *bytes.Buffer) error {
+	m.historyMutex.Lock()
+	defer m.historyMutex.Unlock()
+
+	objectName := positionObjectName(busID, hour)
+	for attempt := 1; attempt <= conditionalWriteAttempts; attempt++ {
+		data, etag, err := m.readHistoryObject(objectName)
+		if err != nil && !errors.Is(err, KeyNotFound) {
+			return fmt.Errorf("failed to read positions: %w", err)
+		}
+
+		data = terminateLine(append(terminateLine(data), positions.Bytes()...))
+
+		opts := PutObjectOptions{ContentType: "text/jsonl", MatchETag: etag}
+		if etag == "" {
+			opts.NoneMatchETag = "*"
+		}
+		log.Debugf("Uploading %s to %s, size: %d", objectName, m.historyBucketName, len(data))
+		_, err = m.client.PutObject(m.ctx, m.historyBucketName, objectName, bytes.NewReader(data), int64(len(data)), opts)
+		if isWriteConflict(err) {
+			log.Debugf("Positions %s were modified concurrently, retrying (attempt %d/%d)", objectName, attempt, conditionalWriteAttempts)
+			backoff(attempt)
+			continue
+		}
+		return err
+	}
+
+	return fmt.Errorf("failed to append positions after %d attempts: %w", conditionalWriteAttempts, PreconditionFailed)
+}
+
+// GetPositions returns the positions of a bus recorded in an hour, or NoPositionsFound if none were.
+func (m *MinIOStorageManager) GetPositions(busID string, hour time.Time) (positions *bytes.Buffer, err error) {
+	data, _, err := m.readHistoryObject(positionObjectName(busID, hour))
+	if errors.Is(err, KeyNotFound) {
+		return nil, NoPositionsFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// readHistoryObject downloads an object along with the ETag of the version read, which is stat'ed
+// first so it is never newer than the data.
+func (m *MinIOStorageManager) readHistoryObject(objectName string) ([]byte, string, error) {
+	info, err := m.client.StatObject(m.ctx, m.historyBucketName, objectName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	log.Debugf("Retrieving %s from %s", objectName, m.historyBucketName)
+	r, err := m.client.GetObject(m.ctx, m.historyBucketName, objectName)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func(r io.ReadCloser) {
+		if closeErr := r.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}(r)
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, info.ETag, nil
+}
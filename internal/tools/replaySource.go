@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRecordedFrameSize bounds the length of a line in a recording
+const maxRecordedFrameSize = 16 << 20
+
+// ReplaySource plays back frames recorded by a FileRecorder, keeping the time between them
+// divided by the speed. A speed of zero or less replays them as fast as possible. The timestamps
+// of the locations are moved forward by the time between their frame being received and being
+// replayed, so they are not taken for stale ones, nor put ahead of the clock at other speeds.
+type ReplaySource struct {
+	// path is a recording file, or a directory whose recording files are played in name order
+	path  string
+	speed float64
+
+	// Loop starts the recording over once it has been played
+	Loop bool
+}
+
+func NewReplaySource(path string, speed float64) *ReplaySource {
+	return &ReplaySource{path: path, speed: speed}
+}
+
+func (s *ReplaySource) Name() string {
+	return "replay " + s.path
+}
+
+func (s *ReplaySource) Run(ctx context.Context, store *LocationStore) error {
+	files, err := recordingFiles(s.path)
+	if err != nil {
+		return err
+	}
+
+	for {
+		var pass replayPass
+		for _, file := range files {
+			if err = s.play(ctx, file, &pass, store); err != nil {
+				return err
+			}
+		}
+		if !s.Loop {
+			log.Infof("Finished replaying %s", s.path)
+			return nil
+		}
+	}
+}
+
+// replayPass is the progress of one pass over a recording
+type replayPass struct {
+	// previous is when the last frame replayed was received
+	previous time.Time
+}
+
+// play replays the frames of a recording file.
+func (s *ReplaySource) play(ctx context.Context, name string, pass *replayPass, store *LocationStore) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxRecordedFrameSize)
+	for scanner.Scan() {
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			log.Warnf("Skipping invalid recorded frame in %s: %v", name, err)
+			continue
+		}
+
+		if s.speed > 0 && !pass.previous.IsZero() && frame.ReceivedAt.After(pass.previous) {
+			wait := time.Duration(float64(frame.ReceivedAt.Sub(pass.previous)) / s.speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		pass.previous = frame.ReceivedAt
+
+		locations, err := ParseFrames(frame.Data)
+		if err != nil {
+			log.Debugf("Skipping parse (likely not location data or empty frame): %v", err)
+			continue
+		}
+		shift := time.Since(frame.ReceivedAt)
+		for i := range locations {
+			locations[i].Timestamp = locations[i].Timestamp.Add(shift)
+		}
+		store.Update(locations)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read recording %s: %w", name, err)
+	}
+	return nil
+}
+
+// recordingFiles returns the recording at path, or the recordings in it if it is a directory.
+func recordingFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", path)
+	}
+	slices.Sort(files)
+	return files, nil
+}
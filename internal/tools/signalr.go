@@ -0,0 +1,313 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/coder/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordSeparator terminates every message of the SignalR JSON hub protocol
+const recordSeparator = "\x1e"
+
+// SignalR hub protocol message types
+const (
+	signalRInvocation = 1
+	signalRPing       = 6
+	signalRClose      = 7
+)
+
+const (
+	defaultSignalRPingInterval   = 15 * time.Second
+	defaultSignalRServerTimeout  = 30 * time.Second
+	defaultSignalRReconnectDelay = 5 * time.Second
+	signalRReadLimit             = 4 << 20
+	// maxNegotiateRedirects bounds how many times negotiation follows a redirect to another server
+	maxNegotiateRedirects = 10
+)
+
+// ServerClosedConnection is returned when the hub closes the connection and does not allow
+// reconnecting.
+var ServerClosedConnection = errors.New("SignalR hub closed the connection")
+
+// SignalRSource subscribes to a SignalR hub over a websocket and reads bus locations from the
+// invocations the hub sends. Dropped connections are reconnected after a delay.
+type SignalRSource struct {
+	hubURL string
+	client *http.Client
+	// subscriptions are hub methods invoked, without arguments, once connected
+	subscriptions []string
+
+	// PingInterval is how often the client pings the hub to keep the connection open
+	PingInterval time.Duration
+	// ServerTimeout is how long to wait for any message from the hub before reconnecting
+	ServerTimeout time.Duration
+	// ReconnectDelay is how long to wait before reconnecting after the connection drops
+	ReconnectDelay time.Duration
+	// Recorder, if set, keeps every frame received from the hub
+	Recorder FrameRecorder
+}
+
+func NewSignalRSource(hubURL string, subscriptions ...string) *SignalRSource {
+	return &SignalRSource{
+		hubURL:         hubURL,
+		client:         http.DefaultClient,
+		subscriptions:  subscriptions,
+		PingInterval:   defaultSignalRPingInterval,
+		ServerTimeout:  defaultSignalRServerTimeout,
+		ReconnectDelay: defaultSignalRReconnectDelay,
+	}
+}
+
+func (s *SignalRSource) Name() string {
+	return "SignalR " + s.hubURL
+}
+
+func (s *SignalRSource) Run(ctx context.Context, store *LocationStore) error {
+	for {
+		err := s.connect(ctx, store)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ServerClosedConnection) {
+			return err
+		}
+		log.Warnf("SignalR connection to %s lost, reconnecting in %s: %v", s.hubURL, s.ReconnectDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.ReconnectDelay):
+		}
+	}
+}
+
+type negotiateTransport struct {
+	Transport       string   `json:"transport"`
+	TransferFormats []string `json:"transferFormats"`
+}
+
+type negotiateResponse struct {
+	ConnectionID        string               `json:"connectionId"`
+	ConnectionToken     string               `json:"connectionToken"`
+	NegotiateVersion    int                  `json:"negotiateVersion"`
+	AvailableTransports []negotiateTransport `json:"availableTransports"`
+	// URL and AccessToken redirect the client to another server
+	URL         string `json:"url"`
+	AccessToken string `json:"accessToken"`
+	Error       string `json:"error"`
+}
+
+// negotiate asks the hub for a connection, following redirects, and returns the URL to open the
+// websocket on along with the access token to send, if any.
+func (s *SignalRSource) negotiate(ctx context.Context) (connectURL string, accessToken string, err error) {
+	hubURL := s.hubURL
+	for range maxNegotiateRedirects {
+		u, err := url.Parse(hubURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid hub URL %q: %w", hubURL, err)
+		}
+		// the hub URL may carry a query, such as an access key, which is kept
+		negotiateURL := u.JoinPath("negotiate")
+		query := negotiateURL.Query()
+		query.Set("negotiateVersion", "1")
+		negotiateURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, negotiateURL.String(), nil)
+		if err != nil {
+			return "", "", err
+		}
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to negotiate: %w", err)
+		}
+		var negotiated negotiateResponse
+		err = json.NewDecoder(resp.Body).Decode(&negotiated)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", "", fmt.Errorf("failed to negotiate: %s", resp.Status)
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode negotiate response: %w", err)
+		}
+		if negotiated.Error != "" {
+			return "", "", fmt.Errorf("failed to negotiate: %s", negotiated.Error)
+		}
+
+		if negotiated.URL != "" {
+			hubURL, accessToken = negotiated.URL, negotiated.AccessToken
+			continue
+		}
+
+		supported := slices.ContainsFunc(negotiated.AvailableTransports, func(t negotiateTransport) bool {
+			return t.Transport == "WebSockets" && slices.Contains(t.TransferFormats, "Text")
+		})
+		if !supported {
+			return "", "", errors.New("hub does not support text over websockets")
+		}
+
+		id := negotiated.ConnectionID
+		if negotiated.NegotiateVersion >= 1 {
+			id = negotiated.ConnectionToken
+		}
+		query = u.Query()
+		query.Set("id", id)
+		u.RawQuery = query.Encode()
+		return u.String(), accessToken, nil
+	}
+	return "", "", fmt.Errorf("failed to negotiate: more than %d redirects", maxNegotiateRedirects)
+}
+
+// connect opens a connection to the hub and records the locations it receives until the
+// connection fails or the context is cancelled.
+func (s *SignalRSource) connect(ctx context.Context, store *LocationStore) error {
+	connectURL, accessToken, err := s.negotiate(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := &websocket.DialOptions{HTTPClient: s.client}
+	if accessToken != "" {
+		opts.HTTPHeader = http.Header{"Authorization": []string{"Bearer " + accessToken}}
+	}
+	conn, _, err := websocket.Dial(ctx, connectURL, opts)
+	if err != nil {
+		return fmt.Errorf("failed to open websocket: %w", err)
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(signalRReadLimit)
+
+	frames := &signalRReader{conn: conn, timeout: s.ServerTimeout}
+	if err := s.handshake(ctx, conn, frames); err != nil {
+		return err
+	}
+	log.Infof("Connected to SignalR hub %s", s.hubURL)
+
+	for _, target := range s.subscriptions {
+		invocation := fmt.Sprintf(`{"type":%d,"target":%q,"arguments":[]}`, signalRInvocation, target)
+		if err := conn.Write(ctx, websocket.MessageText, []byte(invocation+recordSeparator)); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", target, err)
+		}
+	}
+
+	pingCtx, stopPinging := context.WithCancel(ctx)
+	defer stopPinging()
+	go s.ping(pingCtx, conn)
+
+	for {
+		frame, err := frames.next(ctx)
+		if err != nil {
+			return err
+		}
+		recordFrame(s.Recorder, s.Name(), string(frame))
+
+		var message struct {
+			Type           int    `json:"type"`
+			Error          string `json:"error"`
+			AllowReconnect bool   `json:"allowReconnect"`
+		}
+		if err := json.Unmarshal(frame, &message); err != nil {
+			log.Debugf("Error parsing SignalR message: %v. Message: %s", err, frame)
+			continue
+		}
+
+		switch message.Type {
+		case signalRInvocation:
+			locations, err := ParseBusLocations(string(frame))
+			if err != nil {
+				log.Debugf("Error parsing SignalR message: %v. Message: %s", err, frame)
+				continue
+			}
+			store.Update(locations)
+		case signalRClose:
+			conn.Close(websocket.StatusNormalClosure, "")
+			if !message.AllowReconnect {
+				return fmt.Errorf("%w: %s", ServerClosedConnection, message.Error)
+			}
+			return fmt.Errorf("hub closed the connection: %s", message.Error)
+		}
+	}
+}
+
+// handshake selects the JSON hub protocol and waits for the hub to accept it.
+func (s *SignalRSource) handshake(ctx context.Context, conn *websocket.Conn, frames *signalRReader) error {
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`{"protocol":"json","version":1}`+recordSeparator)); err != nil {
+		return fmt.Errorf("failed to send handshake: %w", err)
+	}
+
+	frame, err := frames.next(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read handshake response: %w", err)
+	}
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(frame, &response); err != nil {
+		return fmt.Errorf("invalid handshake response %q: %w", frame, err)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("hub rejected handshake: %s", response.Error)
+	}
+	return nil
+}
+
+// ping keeps the connection open until the context is cancelled.
+func (s *SignalRSource) ping(ctx context.Context, conn *websocket.Conn) {
+	ticker := time.NewTicker(s.PingInterval)
+	defer ticker.Stop()
+
+	ping := []byte(fmt.Sprintf(`{"type":%d}`, signalRPing) + recordSeparator)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := conn.Write(ctx, websocket.MessageText, ping); err != nil {
+				log.Debugf("Failed to ping SignalR hub: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// signalRReader splits the websocket messages from a hub into frames. A message may hold several
+// frames, and a frame may be split across messages.
+type signalRReader struct {
+	conn    *websocket.Conn
+	timeout time.Duration
+	pending []byte
+}
+
+// next returns the next frame, failing if the hub sends nothing within the timeout.
+func (r *signalRReader) next(ctx context.Context) ([]byte, error) {
+	for {
+		if i := bytes.Index(r.pending, []byte(recordSeparator)); i >= 0 {
+			frame := r.pending[:i]
+			r.pending = r.pending[i+len(recordSeparator):]
+			if len(bytes.TrimSpace(frame)) == 0 {
+				continue
+			}
+			return frame, nil
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		_, data, err := r.conn.Read(readCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from hub: %w", err)
+		}
+		r.pending = append(r.pending, data...)
+	}
+}
@@ -0,0 +1,72 @@
+// Package fixtures provides test data shared between the test packages.
+package fixtures
+
+import (
+	"archive/zip"
+	"bytes"
+	"maps"
+	"slices"
+)
+
+// GTFSFiles returns the files of a small but complete GTFS feed: two routes between Douglas,
+// Onchan and Ramsey, running every day, with one trip running past midnight.
+// The returned map may be modified freely.
+func GTFSFiles() map[string]string {
+	return map[string]string{
+		"agency.txt": "agency_id,agency_name,agency_url,agency_timezone\n" +
+			"BV,Bus Vannin,https://www.iombusandrail.im,Europe/Isle_of_Man\n",
+		"stops.txt": "stop_id,stop_code,stop_name,stop_lat,stop_lon,location_type,parent_station\n" +
+			"DGLS,,Douglas Bus Station,54.1466,-4.4793,1,\n" +
+			"DGLS1,1001,Douglas Bus Station Stand 1,54.1467,-4.4794,0,DGLS\n" +
+			"ONCH,1002,Onchan Village,54.1733,-4.4527,0,\n" +
+			"RMSY,1003,Ramsey Bus Station,54.3219,-4.3846,0,\n",
+		"routes.txt": "route_id,agency_id,route_short_name,route_long_name,route_type\n" +
+			"1,BV,1,Douglas - Onchan,3\n" +
+			"3,BV,3,Douglas - Ramsey,3\n",
+		"calendar.txt": "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n" +
+			"DAILY,1,1,1,1,1,1,1,20250101,20301231\n",
+		"calendar_dates.txt": "service_id,date,exception_type\n" +
+			"DAILY,20251225,2\n",
+		"shapes.txt": "shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence\n" +
+			"S3,54.1467,-4.4794,1\n" +
+			"S3,54.1733,-4.4527,2\n" +
+			"S3,54.3219,-4.3846,3\n",
+		"trips.txt": "route_id,service_id,trip_id,trip_headsign,direction_id,shape_id\n" +
+			"1,DAILY,T1,Onchan,0,\n" +
+			"3,DAILY,T3,Ramsey,0,S3\n" +
+			"3,DAILY,T3N,Ramsey,0,S3\n",
+		"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+			"T1,08:00:00,08:00:00,DGLS1,1\n" +
+			"T1,08:12:00,08:12:00,ONCH,2\n" +
+			"T3,09:00:00,09:00:00,DGLS1,1\n" +
+			"T3,,,ONCH,2\n" +
+			"T3,09:45:00,09:45:00,RMSY,3\n" +
+			"T3N,23:30:00,23:30:00,DGLS1,1\n" +
+			"T3N,23:45:00,23:46:00,ONCH,2\n" +
+			"T3N,24:15:00,24:15:00,RMSY,3\n",
+	}
+}
+
+// Zip packs files into a zip archive, in name order so the output is deterministic.
+func Zip(files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		f, err := w.Create(name)
+		if err != nil {
+			panic(err)
+		}
+		if _, err = f.Write([]byte(files[name])); err != nil {
+			panic(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// GTFSZip returns the feed of GTFSFiles as a zip archive.
+func GTFSZip() []byte {
+	return Zip(GTFSFiles())
+}
@@ -0,0 +1,19 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+// ScheduleStore returns a schedule store with the feed of files loaded as version "v1".
+func ScheduleStore(t *testing.T, files map[string]string) *schedule.Store {
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("GetLatestGTFSVersionID").Return("v1", nil)
+	mockSM.On("GetLatestSchedule").Return(Zip(files), "v1", nil)
+	ss := schedule.NewStore(mockSM)
+	require.NoError(t, ss.Load())
+	return ss
+}
@@ -0,0 +1,182 @@
+package gtfs_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func parse(t *testing.T, files map[string]string) *gtfs.Feed {
+	data := fixtures.Zip(files)
+	feed, err := gtfs.Parse(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return feed
+}
+
+func TestParse(t *testing.T) {
+	t.Run("entities and references", func(t *testing.T) {
+		feed := parse(t, fixtures.GTFSFiles())
+
+		assert.Len(t, feed.Agencies, 1)
+		assert.Len(t, feed.Stops, 4)
+		assert.Len(t, feed.Routes, 2)
+		assert.Len(t, feed.Trips, 3)
+
+		station := feed.Stops["DGLS"]
+		stand := feed.Stops["DGLS1"]
+		assert.Equal(t, gtfs.LocationStation, station.LocationType)
+		assert.Same(t, station, stand.Parent)
+		assert.Equal(t, []*gtfs.Stop{stand}, station.Children)
+		assert.InDelta(t, 54.1467, stand.Lat, 1e-9)
+
+		route := feed.Routes["3"]
+		assert.Equal(t, "Bus Vannin", route.Agency.Name)
+		assert.Equal(t, "3", route.Name())
+		require.Len(t, route.Trips, 2)
+		assert.Equal(t, "T3", route.Trips[0].ID)
+		assert.Equal(t, "T3N", route.Trips[1].ID)
+
+		trip := feed.Trips["T3N"]
+		assert.Same(t, route, trip.Route)
+		assert.Same(t, feed.Shapes["S3"], trip.Shape)
+		assert.Len(t, trip.Shape.Points, 3)
+		require.Len(t, trip.StopTimes, 3)
+		assert.Equal(t, 24*3600+15*60, trip.StopTimes[2].Arrival)
+		assert.Equal(t, 23*3600+46*60, trip.StopTimes[1].Departure)
+	})
+
+	t.Run("stop times by stop are ordered by departure", func(t *testing.T) {
+		feed := parse(t, fixtures.GTFSFiles())
+
+		var tripIDs []string
+		for _, stopTime := range feed.StopTimesByStop["ONCH"] {
+			tripIDs = append(tripIDs, stopTime.Trip.ID)
+		}
+		assert.Equal(t, []string{"T1", "T3", "T3N"}, tripIDs)
+	})
+
+	t.Run("untimed stops are interpolated", func(t *testing.T) {
+		feed := parse(t, fixtures.GTFSFiles())
+
+		stopTimes := feed.Trips["T3"].StopTimes
+		assert.False(t, stopTimes[0].Interpolated)
+		assert.True(t, stopTimes[1].Interpolated)
+		assert.Equal(t, "09:22:30", gtfs.FormatTime(stopTimes[1].Arrival))
+		assert.Equal(t, stopTimes[1].Arrival, stopTimes[1].Departure)
+	})
+
+	t.Run("untimed stops are interpolated by distance", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["stop_times.txt"] = "trip_id,arrival_time,departure_time,stop_id,stop_sequence,shape_dist_traveled\n" +
+			"T1,08:00:00,08:00:00,DGLS1,1,0\n" +
+			"T1,08:20:00,08:20:00,RMSY,3,20000\n" +
+			"T1,,,ONCH,2,5000\n" +
+			"T3,09:00:00,09:00:00,DGLS1,1,\n" +
+			"T3N,23:30:00,23:30:00,DGLS1,1,\n"
+
+		feed := parse(t, files)
+
+		stopTimes := feed.Trips["T1"].StopTimes
+		assert.Equal(t, "ONCH", stopTimes[1].Stop.ID)
+		assert.Equal(t, "08:05:00", gtfs.FormatTime(stopTimes[1].Arrival))
+	})
+
+	t.Run("service calendar", func(t *testing.T) {
+		feed := parse(t, fixtures.GTFSFiles())
+		service := feed.Services["DAILY"]
+		douglas, err := time.LoadLocation("Europe/Isle_of_Man")
+		require.NoError(t, err)
+
+		assert.True(t, service.ActiveOn(time.Date(2025, 12, 24, 12, 0, 0, 0, douglas)))
+		assert.False(t, service.ActiveOn(time.Date(2025, 12, 25, 12, 0, 0, 0, douglas)))
+		assert.False(t, service.ActiveOn(time.Date(2024, 6, 1, 12, 0, 0, 0, douglas)))
+	})
+
+	t.Run("broken reference", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["trips.txt"] += "9,DAILY,T9,Nowhere,0,\n"
+		data := fixtures.Zip(files)
+
+		_, err := gtfs.Parse(bytes.NewReader(data), int64(len(data)))
+
+		assert.EqualError(t, err, `trips.txt:5: route_id "9" does not exist`)
+	})
+}
+
+// feedVariations returns variations of the fixture feed that exercise the optional parts of GTFS
+// and the edge cases of validation, some of which Validate rejects.
+func feedVariations() map[string]map[string]string {
+	variations := make(map[string]map[string]string)
+	variation := func(name string, modify func(files map[string]string)) {
+		files := fixtures.GTFSFiles()
+		modify(files)
+		variations[name] = files
+	}
+
+	variation("fixture", func(map[string]string) {})
+	variation("calendar_dates without calendar", func(files map[string]string) {
+		delete(files, "calendar.txt")
+	})
+	variation("calendar without calendar_dates", func(files map[string]string) {
+		delete(files, "calendar_dates.txt")
+	})
+	variation("without shapes", func(files map[string]string) {
+		delete(files, "shapes.txt")
+		files["trips.txt"] = strings.ReplaceAll(files["trips.txt"], ",S3\n", ",\n")
+	})
+	variation("unused entities", func(files map[string]string) {
+		files["routes.txt"] += "5,BV,5,Douglas - Port Erin,3\n"
+		files["stops.txt"] += "PRTE,1004,Port Erin,54.0850,-4.7500,0,\n"
+	})
+	variation("trip without stop times", func(files map[string]string) {
+		files["trips.txt"] += "3,DAILY,T3E,Ramsey,0,S3\n"
+	})
+	variation("agency without agency_id", func(files map[string]string) {
+		files["agency.txt"] = "agency_name,agency_url,agency_timezone\n" +
+			"Bus Vannin,https://www.iombusandrail.im,Europe/Isle_of_Man\n"
+		files["routes.txt"] = strings.ReplaceAll(files["routes.txt"], ",BV,", ",,")
+	})
+	variation("blank agency_id with a single agency", func(files map[string]string) {
+		files["routes.txt"] = strings.ReplaceAll(files["routes.txt"], ",BV,", ",,")
+	})
+	variation("blank agency_id with several agencies", func(files map[string]string) {
+		files["agency.txt"] += "IOMR,Isle of Man Railways,https://www.iombusandrail.im,Europe/Isle_of_Man\n"
+		files["routes.txt"] = strings.ReplaceAll(files["routes.txt"], ",BV,", ",,")
+	})
+	variation("blank first agency_id with several agencies", func(files map[string]string) {
+		files["agency.txt"] = strings.Replace(files["agency.txt"], "\nBV,", "\n,", 1) +
+			"IOMR,Isle of Man Railways,https://www.iombusandrail.im,Europe/Isle_of_Man\n"
+		files["routes.txt"] = strings.ReplaceAll(files["routes.txt"], ",BV,", ",,")
+	})
+	variation("agency.txt without agencies", func(files map[string]string) {
+		files["agency.txt"] = "agency_id,agency_name,agency_url,agency_timezone\n"
+		files["routes.txt"] = strings.ReplaceAll(files["routes.txt"], ",BV,", ",,")
+	})
+	variation("broken references", func(files map[string]string) {
+		files["trips.txt"] += "2,WEEKDAY,T2,Douglas,1,S9\n"
+	})
+	return variations
+}
+
+// TestValidatedFeedsParse keeps validation and parsing in step: a feed accepted on upload must be
+// loadable as the schedule.
+func TestValidatedFeedsParse(t *testing.T) {
+	accepted := 0
+	for name, files := range feedVariations() {
+		data := fixtures.Zip(files)
+		if !gtfs.Validate(bytes.NewReader(data), int64(len(data))).Valid() {
+			continue
+		}
+		accepted++
+
+		_, err := gtfs.Parse(bytes.NewReader(data), int64(len(data)))
+		assert.NoError(t, err, name)
+	}
+	assert.Greater(t, accepted, 1)
+}
@@ -0,0 +1,209 @@
+package gtfs_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfs"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func validate(files map[string]string) *gtfs.ValidationReport {
+	data := fixtures.Zip(files)
+	return gtfs.Validate(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid feed", func(t *testing.T) {
+		report := validate(fixtures.GTFSFiles())
+
+		assert.True(t, report.Valid(), "unexpected errors: %v", report.Errors)
+		assert.Empty(t, report.Warnings)
+	})
+
+	t.Run("not a zip archive", func(t *testing.T) {
+		data := []byte("fake-zip-content")
+		report := gtfs.Validate(bytes.NewReader(data), int64(len(data)))
+
+		assert.False(t, report.Valid())
+		require.Len(t, report.Errors, 1)
+		assert.Contains(t, report.Errors[0].Message, "not a valid zip archive")
+	})
+
+	t.Run("missing required files", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		delete(files, "stops.txt")
+		delete(files, "calendar.txt")
+		delete(files, "calendar_dates.txt")
+
+		report := validate(files)
+
+		assert.Contains(t, report.Errors, gtfs.Issue{File: "stops.txt", Message: "required file is missing"})
+		assert.Contains(t, report.Errors, gtfs.Issue{File: "calendar.txt", Message: "either calendar.txt or calendar_dates.txt is required"})
+	})
+
+	t.Run("calendar_dates without calendar", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		delete(files, "calendar.txt")
+
+		report := validate(files)
+
+		assert.True(t, report.Valid(), "unexpected errors: %v", report.Errors)
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["routes.txt"] = "route_id,agency_id,route_short_name\n1,BV,1\n3,BV,3\n"
+
+		report := validate(files)
+
+		assert.Contains(t, report.Errors, gtfs.Issue{File: "routes.txt", Line: 1, Field: "route_type", Message: "required column route_type is missing"})
+	})
+
+	t.Run("broken references", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["trips.txt"] += "2,WEEKDAY,T2,Douglas,1,S9\n"
+		files["stop_times.txt"] += "T2,10:00:00,10:00:00,PORT,1\n" +
+			"T4,10:00:00,10:00:00,DGLS,1\n"
+
+		report := validate(files)
+
+		assert.Equal(t, []gtfs.Issue{
+			{File: "trips.txt", Line: 5, Field: "route_id", Message: `route_id "2" does not exist in routes.txt`},
+			{File: "trips.txt", Line: 5, Field: "service_id", Message: `service_id "WEEKDAY" does not exist in calendar.txt or calendar_dates.txt`},
+			{File: "trips.txt", Line: 5, Field: "shape_id", Message: `shape_id "S9" does not exist in shapes.txt`},
+			{File: "stop_times.txt", Line: 10, Field: "stop_id", Message: `stop_id "PORT" does not exist in stops.txt`},
+			{File: "stop_times.txt", Line: 11, Field: "trip_id", Message: `trip_id "T4" does not exist in trips.txt`},
+			{File: "stop_times.txt", Line: 11, Field: "stop_id", Message: `stop_id "DGLS" is not a stop or platform (location_type 1)`},
+		}, report.Errors)
+	})
+
+	t.Run("agency.txt without agencies", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["agency.txt"] = "agency_id,agency_name,agency_url,agency_timezone\n"
+		files["routes.txt"] = "route_id,agency_id,route_short_name,route_long_name,route_type\n" +
+			"1,,1,Douglas - Onchan,3\n" +
+			"3,,3,Douglas - Ramsey,3\n"
+
+		report := validate(files)
+
+		assert.Equal(t, []gtfs.Issue{
+			{File: "agency.txt", Message: "at least one agency is required"},
+			{File: "routes.txt", Line: 2, Field: "agency_id", Message: `agency_id "" does not exist in agency.txt`},
+			{File: "routes.txt", Line: 3, Field: "agency_id", Message: `agency_id "" does not exist in agency.txt`},
+		}, report.Errors)
+	})
+
+	t.Run("blank agency_id", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["routes.txt"] = "route_id,agency_id,route_short_name,route_long_name,route_type\n" +
+			"1,,1,Douglas - Onchan,3\n" +
+			"3,BV,3,Douglas - Ramsey,3\n"
+
+		// a single agency may be left out
+		report := validate(files)
+		assert.True(t, report.Valid(), "unexpected errors: %v", report.Errors)
+
+		files["agency.txt"] += "IOMR,Isle of Man Railways,https://www.iombusandrail.im,Europe/Isle_of_Man\n"
+		report = validate(files)
+		assert.Equal(t, []gtfs.Issue{
+			{File: "routes.txt", Line: 2, Field: "agency_id", Message: "agency_id is required when there is more than one agency"},
+		}, report.Errors)
+	})
+
+	t.Run("blank agency_id before another agency", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["agency.txt"] = "agency_id,agency_name,agency_url,agency_timezone\n" +
+			",Bus Vannin,https://www.iombusandrail.im,Europe/Isle_of_Man\n" +
+			"IOMR,Isle of Man Railways,https://www.iombusandrail.im,Europe/Isle_of_Man\n"
+		files["routes.txt"] = "route_id,agency_id,route_short_name,route_long_name,route_type\n" +
+			"1,,1,Douglas - Onchan,3\n" +
+			"3,IOMR,3,Douglas - Ramsey,3\n"
+
+		report := validate(files)
+
+		assert.Equal(t, []gtfs.Issue{
+			{File: "agency.txt", Line: 2, Field: "agency_id", Message: "agency_id is required when there is more than one agency"},
+			{File: "routes.txt", Line: 2, Field: "agency_id", Message: "agency_id is required when there is more than one agency"},
+		}, report.Errors)
+	})
+
+	t.Run("malformed values", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["stops.txt"] += "BAD,,Nowhere,95.0,-4.4,0,\n"
+		files["stop_times.txt"] += "T1,8:5:00,08:20:00,RMSY,3\n"
+
+		report := validate(files)
+
+		assert.Contains(t, report.Errors, gtfs.Issue{File: "stops.txt", Line: 6, Field: "stop_lat", Message: `invalid coordinate stop_lat "95.0"`})
+		require.Len(t, report.Errors, 2)
+		assert.Equal(t, "stop_times.txt", report.Errors[1].File)
+		assert.Equal(t, "arrival_time", report.Errors[1].Field)
+	})
+
+	t.Run("stop times out of order", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["stop_times.txt"] = "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+			"T1,08:12:00,08:12:00,ONCH,2\n" +
+			"T1,08:00:00,08:00:00,DGLS1,1\n" +
+			"T3,09:00:00,09:00:00,DGLS1,1\n" +
+			"T3,08:30:00,08:30:00,ONCH,2\n" +
+			"T3,,,RMSY,3\n" +
+			"T3N,23:30:00,23:30:00,DGLS1,1\n" +
+			"T3N,24:15:00,24:15:00,RMSY,1\n"
+
+		report := validate(files)
+
+		// unsorted rows are fine as long as the sequence is consistent
+		assert.Equal(t, []gtfs.Issue{
+			{File: "stop_times.txt", Line: 6, Field: "arrival_time", Message: `the last stop of trip "T3" must have an arrival or departure time`},
+			{File: "stop_times.txt", Line: 5, Field: "arrival_time", Message: `time travels backwards along trip "T3"`},
+			{File: "stop_times.txt", Line: 8, Field: "stop_sequence", Message: `duplicate stop_sequence 1 in trip "T3N"`},
+		}, report.Errors)
+	})
+
+	t.Run("unused entities are warnings", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["routes.txt"] += "5,BV,5,Douglas - Port Erin,3\n"
+		files["stops.txt"] += "PRTE,1004,Port Erin,54.0850,-4.7500,0,\n"
+
+		report := validate(files)
+
+		assert.True(t, report.Valid(), "unexpected errors: %v", report.Errors)
+		assert.Equal(t, []gtfs.Issue{
+			{File: "routes.txt", Field: "route_id", Message: `route "5" has no trips`},
+			{File: "stops.txt", Field: "stop_id", Message: `stop "PRTE" is not served by any trip`},
+		}, report.Warnings)
+	})
+
+	t.Run("issues are capped", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		for i := range 150 {
+			files["stop_times.txt"] += fmt.Sprintf("T1,09:00:00,09:00:00,MISSING%d,%d\n", i, i+3)
+		}
+
+		report := validate(files)
+
+		assert.Equal(t, 150, report.ErrorCount)
+		assert.Len(t, report.Errors, 100)
+	})
+}
+
+func TestParseTime(t *testing.T) {
+	seconds, err := gtfs.ParseTime("25:30:15")
+	require.NoError(t, err)
+	assert.Equal(t, 25*3600+30*60+15, seconds)
+	assert.Equal(t, "25:30:15", gtfs.FormatTime(seconds))
+
+	seconds, err = gtfs.ParseTime("8:05:00")
+	require.NoError(t, err)
+	assert.Equal(t, "08:05:00", gtfs.FormatTime(seconds))
+
+	for _, invalid := range []string{"", "08:05", "08:5:00", "08:60:00", "aa:bb:cc", "-1:00:00"} {
+		_, err = gtfs.ParseTime(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,149 @@
+package gtfsrt_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func postedMessage(posted time.Time, text string) tools.MessageLog {
+	return tools.MessageLog{Timestamp: posted.UTC().Format(time.RFC3339Nano), Message: text}
+}
+
+func TestAlerts(t *testing.T) {
+	sched := fixtures.ScheduleStore(t, fixtures.GTFSFiles()).Current()
+	now := time.Date(2026, 1, 13, 12, 0, 0, 0, time.UTC)
+
+	t.Run("active messages", func(t *testing.T) {
+		feed := gtfsrt.Alerts([]tools.MessageLog{
+			postedMessage(now.Add(-25*time.Hour), "Expired"),
+			postedMessage(now.Add(-time.Hour), "Route 3 is diverted away from Onchan Village.\nUse stop 1003 instead."),
+			postedMessage(now.Add(time.Hour), "Not posted yet"),
+		}, sched, now)
+
+		require.Len(t, feed.Entity, 1)
+		alert := feed.Entity[0].Alert
+		require.NotNil(t, alert)
+		assert.Equal(t, []gtfsrt.TimeRange{{
+			Start: uint64(now.Add(-time.Hour).Unix()),
+			End:   uint64(now.Add(23 * time.Hour).Unix()),
+		}}, alert.ActivePeriod)
+		assert.Equal(t, "Route 3 is diverted away from Onchan Village.", alert.HeaderText.Translation[0].Text)
+		assert.Equal(t, "en", alert.HeaderText.Translation[0].Language)
+		assert.Equal(t, "Route 3 is diverted away from Onchan Village.\nUse stop 1003 instead.", alert.DescriptionText.Translation[0].Text)
+		assert.Equal(t, []gtfsrt.EntitySelector{{RouteID: "3"}, {StopID: "ONCH"}, {StopID: "RMSY"}}, alert.InformedEntity)
+	})
+
+	t.Run("lists of routes", func(t *testing.T) {
+		feed := gtfsrt.Alerts([]tools.MessageLog{
+			postedMessage(now, "Services 01 and 3 are delayed"),
+		}, sched, now)
+
+		assert.Equal(t, []gtfsrt.EntitySelector{{RouteID: "1"}, {RouteID: "3"}}, feed.Entity[0].Alert.InformedEntity)
+	})
+
+	t.Run("messages naming nothing inform the agency", func(t *testing.T) {
+		feed := gtfsrt.Alerts([]tools.MessageLog{
+			postedMessage(now, "Expect delays due to the weather"),
+		}, sched, now)
+
+		assert.Equal(t, []gtfsrt.EntitySelector{{AgencyID: "BV"}}, feed.Entity[0].Alert.InformedEntity)
+	})
+
+	t.Run("messages naming nothing inform every route of an agency without an ID", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["agency.txt"] = "agency_name,agency_url,agency_timezone\n" +
+			"Bus Vannin,https://www.iombusandrail.im,Europe/Isle_of_Man\n"
+		files["routes.txt"] = "route_id,route_short_name,route_long_name,route_type\n" +
+			"1,1,Douglas - Onchan,3\n" +
+			"3,3,Douglas - Ramsey,3\n"
+		sched := fixtures.ScheduleStore(t, files).Current()
+		require.NotNil(t, sched)
+
+		feed := gtfsrt.Alerts([]tools.MessageLog{
+			postedMessage(now, "Expect delays due to the weather"),
+		}, sched, now)
+
+		assert.Equal(t, []gtfsrt.EntitySelector{{RouteID: "1"}, {RouteID: "3"}}, feed.Entity[0].Alert.InformedEntity)
+	})
+
+	t.Run("long headers are cut short", func(t *testing.T) {
+		text := strings.Repeat("All services are running with delays ", 4)
+		feed := gtfsrt.Alerts([]tools.MessageLog{postedMessage(now, text)}, sched, now)
+
+		header := feed.Entity[0].Alert.HeaderText.Translation[0].Text
+		assert.LessOrEqual(t, len([]rune(header)), 80)
+		require.True(t, strings.HasSuffix(header, "…"), header)
+		// cut at the end of a word
+		kept := strings.TrimSuffix(header, "…")
+		assert.True(t, strings.HasPrefix(text, kept+" "), header)
+	})
+
+	t.Run("protocol buffer encoding", func(t *testing.T) {
+		feed := gtfsrt.Alerts([]tools.MessageLog{postedMessage(now, "Route 3 is delayed")}, sched, now)
+
+		m := decode(t, feed.Marshal())
+		entity := decode(t, m[2][0].([]byte))
+		alert := entity.message(t, 5)
+
+		period := alert.message(t, 1)
+		assert.Equal(t, []any{uint64(now.Unix())}, period[1])
+		assert.Equal(t, []any{uint64(now.Add(24 * time.Hour).Unix())}, period[2])
+		assert.Equal(t, "3", alert.message(t, 5).string(2))
+		header := alert.message(t, 10).message(t, 1)
+		assert.Equal(t, "Route 3 is delayed", header.string(1))
+		assert.Equal(t, "en", header.string(2))
+		assert.Equal(t, "Route 3 is delayed", alert.message(t, 11).message(t, 1).string(1))
+	})
+
+	t.Run("structured messages", func(t *testing.T) {
+		message := postedMessage(now.Add(-48*time.Hour), "Buses diverted")
+		message.Severity, message.Cause, message.Effect = "WARNING", "CONSTRUCTION", "DETOUR"
+		message.RouteIDs, message.StopIDs = []string{"1"}, []string{"ONCH"}
+		message.ActiveFrom = now.Add(-48 * time.Hour).Format(time.RFC3339)
+		message.ActiveUntil = now.Add(time.Hour).Format(time.RFC3339)
+
+		feed := gtfsrt.Alerts([]tools.MessageLog{message}, sched, now)
+
+		require.Len(t, feed.Entity, 1)
+		alert := feed.Entity[0].Alert
+		assert.Equal(t, []gtfsrt.TimeRange{{
+			Start: uint64(now.Add(-48 * time.Hour).Unix()),
+			End:   uint64(now.Add(time.Hour).Unix()),
+		}}, alert.ActivePeriod)
+		assert.Equal(t, []gtfsrt.EntitySelector{{RouteID: "1"}, {StopID: "ONCH"}}, alert.InformedEntity)
+
+		cause, _ := gtfsrt.ParseCause("CONSTRUCTION")
+		effect, _ := gtfsrt.ParseEffect("DETOUR")
+		severity, _ := gtfsrt.ParseSeverityLevel("WARNING")
+		assert.Equal(t, cause, alert.Cause)
+		assert.Equal(t, effect, alert.Effect)
+		assert.Equal(t, severity, alert.SeverityLevel)
+
+		encoded := decode(t, decode(t, feed.Marshal())[2][0].([]byte)).message(t, 5)
+		assert.Equal(t, []any{uint64(10)}, encoded[6])
+		assert.Equal(t, []any{uint64(4)}, encoded[7])
+		assert.Equal(t, []any{uint64(3)}, encoded[14])
+	})
+
+	t.Run("active windows", func(t *testing.T) {
+		expired := postedMessage(now.Add(-2*time.Hour), "Expired early")
+		expired.ActiveUntil = now.Add(-time.Hour).Format(time.RFC3339)
+		upcoming := postedMessage(now.Add(-time.Hour), "Upcoming")
+		upcoming.ActiveFrom = now.Add(2 * time.Hour).Format(time.RFC3339)
+
+		feed := gtfsrt.Alerts([]tools.MessageLog{expired, upcoming}, sched, now)
+
+		require.Len(t, feed.Entity, 1)
+		assert.Equal(t, []gtfsrt.TimeRange{{
+			Start: uint64(now.Add(2 * time.Hour).Unix()),
+			End:   uint64(now.Add(26 * time.Hour).Unix()),
+		}}, feed.Entity[0].Alert.ActivePeriod)
+	})
+}
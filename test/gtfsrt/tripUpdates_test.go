@@ -0,0 +1,85 @@
+package gtfsrt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestTripUpdates(t *testing.T) {
+	sched := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	now := time.Date(2026, 1, 13, 9, 2, 0, 0, schedule.Location)
+	vehicles := realtime.NewTracker(sched).Vehicles([]tools.BusLocation{
+		{BusID: "42", DepartureTime: "0900", RouteNumber: "3", Latitude: 54.1467, Longitude: -4.4794, Timestamp: now},
+		{BusID: "43", DepartureTime: "1000", RouteNumber: "5", Latitude: 54.1733, Longitude: -4.4527, Timestamp: now},
+	})
+
+	feed := gtfsrt.TripUpdates(vehicles, now)
+
+	t.Run("feed", func(t *testing.T) {
+		require.Len(t, feed.Entity, 1)
+		assert.Equal(t, "T3-20260113", feed.Entity[0].ID)
+		update := feed.Entity[0].TripUpdate
+		require.NotNil(t, update)
+		assert.Equal(t, "T3", update.Trip.TripID)
+		assert.Equal(t, "42", update.Vehicle.ID)
+		require.NotNil(t, update.Delay)
+		assert.Equal(t, int32(120), *update.Delay)
+
+		require.Len(t, update.StopTimeUpdate, 3)
+		first := update.StopTimeUpdate[0]
+		assert.Equal(t, "DGLS1", first.StopID)
+		assert.Nil(t, first.Arrival)
+		require.NotNil(t, first.Departure)
+		assert.Equal(t, int32(120), *first.Departure.Delay)
+		assert.Equal(t, now.Unix(), first.Departure.Time)
+
+		last := update.StopTimeUpdate[2]
+		assert.Equal(t, "RMSY", last.StopID)
+		assert.Equal(t, uint32(3), *last.StopSequence)
+		require.NotNil(t, last.Arrival)
+		assert.Equal(t, int32(120), *last.Arrival.Delay)
+		assert.Nil(t, last.Departure)
+	})
+
+	t.Run("protocol buffer encoding", func(t *testing.T) {
+		m := decode(t, feed.Marshal())
+
+		require.Len(t, m[2], 1)
+		entity := decode(t, m[2][0].([]byte))
+		assert.Equal(t, "T3-20260113", entity.string(1))
+
+		update := entity.message(t, 3)
+		assert.Equal(t, "T3", update.message(t, 1).string(1))
+		assert.Equal(t, "42", update.message(t, 3).string(1))
+		assert.Equal(t, []any{uint64(now.Unix())}, update[4])
+		assert.Equal(t, []any{uint64(120)}, update[5])
+
+		require.Len(t, update[2], 3)
+		first := decode(t, update[2][0].([]byte))
+		assert.Equal(t, []any{uint64(1)}, first[1])
+		assert.Equal(t, "DGLS1", first.string(4))
+		assert.NotContains(t, first, protowire.Number(2))
+		departure := first.message(t, 3)
+		assert.Equal(t, []any{uint64(120)}, departure[1])
+		assert.Equal(t, []any{uint64(now.Unix())}, departure[2])
+	})
+
+	t.Run("negative delays", func(t *testing.T) {
+		early := gtfsrt.TripUpdates(realtime.NewTracker(sched).Vehicles([]tools.BusLocation{
+			{BusID: "42", DepartureTime: "0900", RouteNumber: "3", Latitude: 54.1733, Longitude: -4.4527, Timestamp: now.Add(18 * time.Minute)},
+		}), now)
+
+		update := decode(t, decode(t, early.Marshal())[2][0].([]byte)).message(t, 3)
+		// int32 fields encode negative values as their 64-bit two's complement
+		assert.Equal(t, int32(-150), int32(update[5][0].(uint64)))
+	})
+}
@@ -0,0 +1,119 @@
+package gtfsrt_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// message is a decoded protocol buffer message, holding the values of each field in order.
+// Varint and fixed32 fields decode to uint64 and length-delimited ones to []byte.
+type message map[protowire.Number][]any
+
+func decode(t *testing.T, b []byte) message {
+	t.Helper()
+	m := make(message)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0, "invalid tag")
+		b = b[n:]
+
+		var value any
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			value = uint64(v)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		require.GreaterOrEqual(t, n, 0, "invalid value of field %d", num)
+		b = b[n:]
+		m[num] = append(m[num], value)
+	}
+	return m
+}
+
+func (m message) message(t *testing.T, num protowire.Number) message {
+	t.Helper()
+	require.Len(t, m[num], 1, "field %d", num)
+	return decode(t, m[num][0].([]byte))
+}
+
+func (m message) string(num protowire.Number) string {
+	if len(m[num]) == 0 {
+		return ""
+	}
+	return string(m[num][0].([]byte))
+}
+
+func (m message) float(num protowire.Number) float32 {
+	return math.Float32frombits(uint32(m[num][0].(uint64)))
+}
+
+func TestVehiclePositions(t *testing.T) {
+	sched := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	now := time.Date(2026, 1, 13, 9, 2, 0, 0, schedule.Location)
+	vehicles := realtime.NewTracker(sched).Vehicles([]tools.BusLocation{
+		{BusID: "42", DepartureTime: "0900", RouteNumber: "3", Latitude: 54.1467, Longitude: -4.4794, Timestamp: now},
+		{BusID: "43", DepartureTime: "1000", RouteNumber: "5", Latitude: 54.1733, Longitude: -4.4527, Timestamp: now},
+	})
+
+	feed := gtfsrt.VehiclePositions(vehicles, now)
+
+	t.Run("feed", func(t *testing.T) {
+		require.Len(t, feed.Entity, 2)
+		trip := feed.Entity[0].Vehicle.Trip
+		require.NotNil(t, trip)
+		assert.Equal(t, "T3", trip.TripID)
+		assert.Equal(t, "3", trip.RouteID)
+		assert.Equal(t, "09:00:00", trip.StartTime)
+		assert.Equal(t, "20260113", trip.StartDate)
+		assert.Nil(t, feed.Entity[1].Vehicle.Trip)
+	})
+
+	t.Run("protocol buffer encoding", func(t *testing.T) {
+		m := decode(t, feed.Marshal())
+
+		header := m.message(t, 1)
+		assert.Equal(t, gtfsrt.Version, header.string(1))
+		assert.Equal(t, []any{uint64(0)}, header[2])
+		assert.Equal(t, []any{uint64(now.Unix())}, header[3])
+
+		require.Len(t, m[2], 2)
+		entity := decode(t, m[2][0].([]byte))
+		assert.Equal(t, "42", entity.string(1))
+
+		vehicle := entity.message(t, 4)
+		assert.Equal(t, []any{uint64(now.Unix())}, vehicle[5])
+		assert.Equal(t, "42", vehicle.message(t, 8).string(1))
+
+		position := vehicle.message(t, 2)
+		assert.InDelta(t, 54.1467, position.float(1), 1e-4)
+		assert.InDelta(t, -4.4794, position.float(2), 1e-4)
+
+		trip := vehicle.message(t, 1)
+		assert.Equal(t, "T3", trip.string(1))
+		assert.Equal(t, "09:00:00", trip.string(2))
+		assert.Equal(t, "20260113", trip.string(3))
+		assert.Equal(t, []any{uint64(0)}, trip[4])
+		assert.Equal(t, "3", trip.string(5))
+		assert.Equal(t, []any{uint64(0)}, trip[6])
+
+		unmatched := decode(t, m[2][1].([]byte)).message(t, 4)
+		assert.NotContains(t, unmatched, protowire.Number(1))
+	})
+}
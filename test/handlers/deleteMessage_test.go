@@ -0,0 +1,55 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func TestRetractMessage(t *testing.T) {
+	original := tools.MessageLog{ID: "a", Timestamp: "2026-01-13T08:00:00Z", Message: "Route 3 diverted"}
+
+	t.Run("tombstone", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original)
+
+		rr := serveMessage("DELETE", handlers.RetractMessage(mockSM), "/messages/a", nil)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		var resp api.PutMessageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "a", resp.ID)
+
+		require.Len(t, *appended, 1)
+		tombstone := (*appended)[0]
+		assert.Equal(t, "a", tombstone.ID)
+		assert.Equal(t, original.Timestamp, tombstone.Timestamp)
+		assert.NotEmpty(t, tombstone.RetractedAt)
+		assert.Empty(t, tools.EffectiveMessages(append([]tools.MessageLog{original}, tombstone)))
+	})
+
+	t.Run("already retracted", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original, tools.NewTombstone(original))
+
+		rr := serveMessage("DELETE", handlers.RetractMessage(mockSM), "/messages/a", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, *appended)
+	})
+
+	t.Run("no message log", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestLog").Return((*bytes.Buffer)(nil), tools.NoMessageLogFound)
+
+		rr := serveMessage("DELETE", handlers.RetractMessage(mockSM), "/messages/a", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
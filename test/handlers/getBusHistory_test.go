@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func serveBusHistory(sm *mocks.ObjectStorageManagerMock, target string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/locations/{busID}/history", handlers.GetBusHistory(sm))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+	return rr
+}
+
+func TestGetBusHistory(t *testing.T) {
+	hour := time.Date(2026, 1, 13, 9, 0, 0, 0, time.UTC)
+	stored := "{\"t\":\"2026-01-13T09:10:00Z\",\"lat\":54.1467,\"lon\":-4.4794,\"r\":\"3\",\"d\":\"outbound\",\"dep\":\"0900\"}\n" +
+		"{\"t\":\"2026-01-13T09:50:00Z\",\"lat\":54.1733,\"lon\":-4.4527,\"r\":\"3\"}\n"
+
+	t.Run("track", func(t *testing.T) {
+		sm := new(mocks.ObjectStorageManagerMock)
+		sm.On("GetPositions", "42", hour).Return(bytes.NewBufferString(stored), nil)
+		sm.On("GetPositions", "42", mock.Anything).Return((*bytes.Buffer)(nil), tools.NoPositionsFound)
+
+		rr := serveBusHistory(sm, "/locations/42/history?from=2026-01-13T08:30:00Z&to=2026-01-13T09:30:00Z")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response api.GetBusHistoryResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "42", response.BusID)
+		assert.Equal(t, hour.Add(-30*time.Minute), response.From)
+		require.Len(t, response.Positions, 1)
+		assert.Equal(t, hour.Add(10*time.Minute), response.Positions[0].Timestamp)
+		assert.Equal(t, 54.1467, response.Positions[0].Latitude)
+		assert.Equal(t, "outbound", response.Positions[0].Direction)
+		// positions are named like the other location payloads
+		var raw struct {
+			Positions []map[string]any `json:"positions"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+		assert.Equal(t, map[string]any{
+			"timestamp":      "2026-01-13T09:10:00Z",
+			"latitude":       54.1467,
+			"longitude":      -4.4794,
+			"route_number":   "3",
+			"direction":      "outbound",
+			"departure_time": "0900",
+		}, raw.Positions[0])
+		sm.AssertNumberOfCalls(t, "GetPositions", 2)
+	})
+
+	t.Run("defaults to the last hour", func(t *testing.T) {
+		sm := new(mocks.ObjectStorageManagerMock)
+		sm.On("GetPositions", "42", mock.Anything).Return((*bytes.Buffer)(nil), tools.NoPositionsFound)
+
+		rr := serveBusHistory(sm, "/locations/42/history")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response api.GetBusHistoryResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, time.Hour, response.To.Sub(response.From))
+		assert.WithinDuration(t, time.Now(), response.To, time.Minute)
+		assert.NotNil(t, response.Positions)
+		assert.Empty(t, response.Positions)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, target := range []string{
+			"/locations/42/history?from=yesterday",
+			"/locations/42/history?to=13-01-2026",
+			"/locations/42/history?from=2026-01-13T10:00:00Z&to=2026-01-13T09:00:00Z",
+			"/locations/42/history?from=2026-01-01T00:00:00Z&to=2026-01-13T09:00:00Z",
+		} {
+			rr := serveBusHistory(new(mocks.ObjectStorageManagerMock), target)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		sm := new(mocks.ObjectStorageManagerMock)
+		sm.On("GetPositions", "42", mock.Anything).Return((*bytes.Buffer)(nil), errors.New("storage unavailable"))
+
+		rr := serveBusHistory(sm, "/locations/42/history")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
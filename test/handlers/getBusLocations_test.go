@@ -5,24 +5,99 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/transitIOM/projectMercury/api"
 	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
 )
 
 func TestGetBusLocations(t *testing.T) {
-	req := httptest.NewRequest("GET", "/locations/", nil)
-	rr := httptest.NewRecorder()
+	t.Run("no buses", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/locations/", nil)
+		rr := httptest.NewRecorder()
 
-	handlers.GetBusLocations(rr, req)
+		ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+		handlers.GetBusLocations(realtime.NewTracker(ss), tools.NewLocationStore(tools.DefaultLocationExpiry).Buses)(rr, req)
 
-	assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, http.StatusOK, rr.Code)
 
-	var response api.GetBusLocationsResponse
-	err := json.Unmarshal(rr.Body.Bytes(), &response)
-	assert.NoError(t, err)
-	assert.Equal(t, http.StatusOK, response.Code)
-	// tools.GetAllBuses() returns an empty slice by default in tests
-	assert.Equal(t, "[]", response.Locations)
+		var response api.GetBusLocationsResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.Code)
+		// an empty location store tracks no buses
+		assert.Equal(t, "[]", response.Locations)
+	})
+
+	today := schedule.ServiceDay(time.Now())
+	buses := func() []tools.BusLocation {
+		return []tools.BusLocation{
+			{
+				BusID:         "42",
+				DepartureTime: "0900",
+				RouteNumber:   "3",
+				Direction:     "outbound",
+				Latitude:      54.1467,
+				Longitude:     -4.4794,
+				Timestamp:     today.Add(9*time.Hour + time.Minute),
+			},
+			{
+				BusID:         "43",
+				DepartureTime: "1000",
+				RouteNumber:   "5",
+				Latitude:      54.1467,
+				Longitude:     -4.4794,
+				Timestamp:     today.Add(9*time.Hour + time.Minute),
+			},
+		}
+	}
+
+	t.Run("matched buses", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/locations/", nil)
+		rr := httptest.NewRecorder()
+
+		ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+		handlers.GetBusLocations(realtime.NewTracker(ss), buses)(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response api.GetBusLocationsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		var tracked []api.TrackedBus
+		require.NoError(t, json.Unmarshal([]byte(response.Locations), &tracked))
+		require.Len(t, tracked, 2)
+		assert.Equal(t, "42", tracked[0].BusID)
+		assert.Equal(t, "3", tracked[0].RouteID)
+		assert.Equal(t, "T3", tracked[0].TripID)
+		assert.Greater(t, tracked[0].MatchConfidence, 0.5)
+		require.NotNil(t, tracked[0].DelaySeconds)
+		assert.Equal(t, 60, *tracked[0].DelaySeconds)
+		assert.Equal(t, "43", tracked[1].BusID)
+		assert.Empty(t, tracked[1].RouteID)
+		assert.Empty(t, tracked[1].TripID)
+		assert.Zero(t, tracked[1].MatchConfidence)
+		assert.Nil(t, tracked[1].DelaySeconds)
+	})
+
+	t.Run("no schedule loaded", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/locations/", nil)
+		rr := httptest.NewRecorder()
+
+		ss := schedule.NewStore(new(mocks.ObjectStorageManagerMock))
+		handlers.GetBusLocations(realtime.NewTracker(ss), buses)(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response api.GetBusLocationsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		var tracked []api.TrackedBus
+		require.NoError(t, json.Unmarshal([]byte(response.Locations), &tracked))
+		require.Len(t, tracked, 2)
+		assert.Empty(t, tracked[0].TripID)
+	})
 }
@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func noBuses() []tools.BusLocation {
+	return nil
+}
+
+func TestGetDepartures(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	const pattern = "/stops/{stopID}/departures"
+
+	t.Run("departures", func(t *testing.T) {
+		rr := serveSchedule(pattern, handlers.GetDepartures(ss, realtime.NewTracker(ss), noBuses), "/stops/DGLS1/departures?from=2026-01-13T08:30:00Z&limit=2")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.GetDeparturesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "v1", resp.VersionID)
+		assert.Equal(t, "DGLS1", resp.Stop.StopID)
+		require.Len(t, resp.Departures, 2)
+		assert.Equal(t, api.Departure{
+			TripID:         "T3",
+			RouteID:        "3",
+			RouteShortName: "3",
+			Headsign:       "Ramsey",
+			StopID:         "DGLS1",
+			ServiceDate:    "20260113",
+			ScheduledTime:  time.Date(2026, 1, 13, 9, 0, 0, 0, time.UTC),
+			Status:         "scheduled",
+			EstimatedTime:  time.Date(2026, 1, 13, 9, 0, 0, 0, time.UTC),
+		}, resp.Departures[0])
+		assert.Equal(t, "T3N", resp.Departures[1].TripID)
+	})
+
+	t.Run("live departure", func(t *testing.T) {
+		today := schedule.ServiceDay(time.Now())
+		buses := func() []tools.BusLocation {
+			return []tools.BusLocation{{
+				BusID:         "42",
+				DepartureTime: "09:00",
+				RouteNumber:   "3",
+				Latitude:      54.1467,
+				Longitude:     -4.4794,
+				Timestamp:     today.Add(9*time.Hour + 5*time.Minute),
+			}}
+		}
+		target := "/stops/DGLS1/departures?limit=1&from=" + url.QueryEscape(today.Add(8*time.Hour+30*time.Minute).Format(time.RFC3339))
+
+		rr := serveSchedule(pattern, handlers.GetDepartures(ss, realtime.NewTracker(ss), buses), target)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.GetDeparturesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Departures, 1)
+		assert.Equal(t, "T3", resp.Departures[0].TripID)
+		assert.Equal(t, "live", resp.Departures[0].Status)
+		assert.Equal(t, "42", resp.Departures[0].BusID)
+		require.NotNil(t, resp.Departures[0].DelaySeconds)
+		assert.Equal(t, 300, *resp.Departures[0].DelaySeconds)
+	})
+
+	t.Run("invalid from", func(t *testing.T) {
+		rr := serveSchedule(pattern, handlers.GetDepartures(ss, realtime.NewTracker(ss), noBuses), "/stops/DGLS1/departures?from=tomorrow")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		rr := serveSchedule(pattern, handlers.GetDepartures(ss, realtime.NewTracker(ss), noBuses), "/stops/DGLS1/departures?limit=0")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unknown stop", func(t *testing.T) {
+		rr := serveSchedule(pattern, handlers.GetDepartures(ss, realtime.NewTracker(ss), noBuses), "/stops/PORT/departures")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
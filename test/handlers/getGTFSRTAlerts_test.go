@@ -0,0 +1,61 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func TestGetGTFSRTAlerts(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+
+	t.Run("alerts", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		posted := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+		mockSM.On("GetLatestLogTail", 101).Return(bytes.NewBufferString(`{"timestamp":"`+posted+`","message":"Route 3 is delayed"}`+"\n"), nil)
+
+		rr := httptest.NewRecorder()
+		handlers.GetGTFSRTAlerts(mockSM, ss)(rr, httptest.NewRequest("GET", "/gtfs-rt/alerts?format=json", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var feed gtfsrt.FeedMessage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feed))
+		require.Len(t, feed.Entity, 1)
+		assert.Equal(t, posted, feed.Entity[0].ID)
+		assert.Equal(t, []gtfsrt.EntitySelector{{RouteID: "3"}}, feed.Entity[0].Alert.InformedEntity)
+		mockSM.AssertExpectations(t)
+	})
+
+	t.Run("no message log", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestLogTail", 101).Return((*bytes.Buffer)(nil), tools.NoMessageLogFound)
+
+		rr := httptest.NewRecorder()
+		handlers.GetGTFSRTAlerts(mockSM, ss)(rr, httptest.NewRequest("GET", "/gtfs-rt/alerts", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-protobuf", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestLogTail", 101).Return((*bytes.Buffer)(nil), errors.New("storage unavailable"))
+
+		rr := httptest.NewRecorder()
+		handlers.GetGTFSRTAlerts(mockSM, ss)(rr, httptest.NewRequest("GET", "/gtfs-rt/alerts", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
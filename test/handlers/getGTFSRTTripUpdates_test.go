@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/gtfsrt"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func TestGetGTFSRTTripUpdates(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	today := schedule.ServiceDay(time.Now())
+	buses := func() []tools.BusLocation {
+		return []tools.BusLocation{{
+			BusID:         "42",
+			DepartureTime: "0900",
+			RouteNumber:   "3",
+			Latitude:      54.1467,
+			Longitude:     -4.4794,
+			Timestamp:     today.Add(9*time.Hour + time.Minute),
+		}}
+	}
+	handler := handlers.GetGTFSRTTripUpdates(realtime.NewTracker(ss), buses)
+
+	t.Run("protocol buffer", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", "/gtfs-rt/trip-updates", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-protobuf", rr.Header().Get("Content-Type"))
+		assert.NotEmpty(t, rr.Body.Bytes())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", "/gtfs-rt/trip-updates?format=json", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var feed gtfsrt.FeedMessage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feed))
+		require.Len(t, feed.Entity, 1)
+		require.NotNil(t, feed.Entity[0].TripUpdate)
+		assert.Equal(t, "T3", feed.Entity[0].TripUpdate.Trip.TripID)
+		assert.Len(t, feed.Entity[0].TripUpdate.StopTimeUpdate, 3)
+	})
+}
@@ -3,21 +3,27 @@ package handlers_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/transitIOM/projectMercury/api"
 	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/tools"
 	"github.com/transitIOM/projectMercury/test/mocks"
 )
 
 func TestGetMessages(t *testing.T) {
 	mockSM := new(mocks.ObjectStorageManagerMock)
-	logContent := `{"timestamp":"...","message":"hello"}`
+	logContent := `{"timestamp":"...","message":"hello"}` + "\n"
 	buffer := bytes.NewBufferString(logContent)
-	mockSM.On("GetLatestLog").Return(buffer, nil)
+	mockSM.On("GetLatestLogTail", 4).Return(buffer, nil)
 	mockSM.On("GetLatestMessageVersionID").Return("m123", nil)
 
 	req := httptest.NewRequest("GET", "/messages/", nil)
@@ -33,6 +39,165 @@ func TestGetMessages(t *testing.T) {
 	assert.Equal(t, "m123", resp.VersionID)
 }
 
+func TestGetMessagesEffectiveState(t *testing.T) {
+	now := time.Now().UTC()
+	entry := func(m tools.MessageLog) string {
+		b, err := json.Marshal(m)
+		require.NoError(t, err)
+		return string(b) + "\n"
+	}
+	posted := func(id, text string) tools.MessageLog {
+		return tools.MessageLog{ID: id, Timestamp: now.Add(-time.Hour).Format(time.RFC3339Nano), Message: text}
+	}
+
+	first, second, third := posted("a", "Route 3 divreted"), posted("b", "Route 1 delayed"), posted("c", "Snow")
+	expired := posted("d", "Road closed")
+	expired.ActiveUntil = now.Add(-time.Minute).Format(time.RFC3339)
+	revision := tools.NewRevision(first, tools.MessageLog{Message: "Route 3 diverted"})
+
+	older := entry(first) + entry(second)
+	newer := entry(third) + entry(expired) + entry(revision) + entry(tools.NewTombstone(third))
+
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("GetLatestMessageVersionID").Return("m123", nil)
+	// the last two messages posted within the tail are retracted or expired, so more is read
+	mockSM.On("GetLatestLogTail", 3).Return(bytes.NewBufferString(newer), nil)
+	mockSM.On("GetLatestLogTail", 6).Return(bytes.NewBufferString(older+newer), nil)
+	mockSM.On("GetLatestLogTail", 12).Return(bytes.NewBufferString(older+newer), nil)
+
+	rr := httptest.NewRecorder()
+	handlers.GetMessages(mockSM).ServeHTTP(rr, httptest.NewRequest("GET", "/messages/?n=2", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp api.GetMessagesResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	messages := tools.ParseMessageLog(bytes.NewBufferString(resp.Messages))
+	require.Len(t, messages, 2)
+	assert.Equal(t, "a", messages[0].ID)
+	assert.Equal(t, "Route 3 diverted", messages[0].Message)
+	assert.Equal(t, first.Timestamp, messages[0].Timestamp)
+	assert.NotEmpty(t, messages[0].EditedAt)
+	assert.Equal(t, "b", messages[1].ID)
+}
+
+func TestGetMessagesPages(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Hour)
+	b := bytes.Buffer{}
+	var posted []tools.MessageLog
+	for i := range 5 {
+		message := tools.MessageLog{
+			ID:        fmt.Sprintf("m%d", i+1),
+			Timestamp: start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339Nano),
+			Message:   fmt.Sprintf("Message %d", i+1),
+		}
+		require.NoError(t, json.NewEncoder(&b).Encode(message))
+		posted = append(posted, message)
+	}
+
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("GetLatestMessageVersionID").Return("m123", nil)
+	mockSM.On("GetLatestLogTail", mock.Anything).Return(&b, nil)
+
+	get := func(t *testing.T, query url.Values) (*httptest.ResponseRecorder, []string, string) {
+		rr := httptest.NewRecorder()
+		handlers.GetMessages(mockSM).ServeHTTP(rr, httptest.NewRequest("GET", "/messages/?"+query.Encode(), nil))
+		var resp api.GetMessagesResponse
+		var ids []string
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			for _, message := range tools.ParseMessageLog(bytes.NewBufferString(resp.Messages)) {
+				ids = append(ids, message.ID)
+			}
+		}
+		return rr, ids, resp.NextCursor
+	}
+
+	t.Run("history", func(t *testing.T) {
+		_, ids, cursor := get(t, url.Values{"limit": {"2"}})
+		assert.Equal(t, []string{"m4", "m5"}, ids)
+		require.NotEmpty(t, cursor)
+
+		_, ids, cursor = get(t, url.Values{"limit": {"2"}, "cursor": {cursor}})
+		assert.Equal(t, []string{"m2", "m3"}, ids)
+		require.NotEmpty(t, cursor)
+
+		_, ids, cursor = get(t, url.Values{"limit": {"2"}, "cursor": {cursor}})
+		assert.Equal(t, []string{"m1"}, ids)
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("since a message", func(t *testing.T) {
+		_, ids, cursor := get(t, url.Values{"since": {"m3"}})
+		assert.Equal(t, []string{"m4", "m5"}, ids)
+		assert.Empty(t, cursor)
+
+		_, ids, cursor = get(t, url.Values{"since": {"m1"}, "limit": {"2"}})
+		assert.Equal(t, []string{"m4", "m5"}, ids)
+		require.NotEmpty(t, cursor)
+
+		_, ids, cursor = get(t, url.Values{"since": {"m1"}, "limit": {"2"}, "cursor": {cursor}})
+		assert.Equal(t, []string{"m2", "m3"}, ids)
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("since a time", func(t *testing.T) {
+		_, ids, _ := get(t, url.Values{"since": {posted[3].Timestamp}})
+		assert.Equal(t, []string{"m5"}, ids)
+
+		rr, _, _ := get(t, url.Values{"since": {posted[4].Timestamp}})
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("since an unknown message", func(t *testing.T) {
+		rr, _, _ := get(t, url.Values{"since": {"m9"}})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		rr, _, _ := get(t, url.Values{"cursor": {"not a cursor"}})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetMessagesSinceChanges(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Hour)
+	at := func(minutes int) string {
+		return start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339Nano)
+	}
+	posted := func(id string, minutes int) tools.MessageLog {
+		return tools.MessageLog{ID: id, Timestamp: at(minutes), Message: "Message " + id}
+	}
+
+	first, second, third, fourth := posted("m1", 0), posted("m2", 1), posted("m3", 2), posted("m4", 3)
+	earlyEdit := first
+	earlyEdit.Message, earlyEdit.EditedAt = "Message m1 edited before m3", at(1)
+	lateEdit := first
+	lateEdit.Message, lateEdit.EditedAt = "Message m1 edited after m3", at(4)
+	retraction := tools.MessageLog{ID: "m2", Timestamp: second.Timestamp, RetractedAt: at(5)}
+
+	b := bytes.Buffer{}
+	for _, entry := range []tools.MessageLog{first, second, earlyEdit, third, fourth, lateEdit, retraction} {
+		require.NoError(t, json.NewEncoder(&b).Encode(entry))
+	}
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("GetLatestMessageVersionID").Return("m123", nil)
+	mockSM.On("GetLatestLogTail", mock.Anything).Return(&b, nil)
+
+	rr := httptest.NewRecorder()
+	handlers.GetMessages(mockSM).ServeHTTP(rr, httptest.NewRequest("GET", "/messages/?since=m3", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp api.GetMessagesResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	messages := tools.ParseMessageLog(bytes.NewBufferString(resp.Messages))
+	require.Len(t, messages, 3)
+
+	// the edit and the retraction are after m3, though both messages were posted before it
+	assert.Equal(t, lateEdit, messages[0])
+	assert.Equal(t, retraction, messages[1])
+	assert.Equal(t, fourth, messages[2])
+}
+
 func TestGetMessageLogVersionID(t *testing.T) {
 	mockSM := new(mocks.ObjectStorageManagerMock)
 	mockSM.On("GetLatestMessageVersionID").Return("m123", nil)
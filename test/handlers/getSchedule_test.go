@@ -6,10 +6,13 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/transitIOM/projectMercury/api"
 	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/tools"
 	"github.com/transitIOM/projectMercury/test/mocks"
 )
 
@@ -48,3 +51,64 @@ func TestGetScheduleDownloadURL(t *testing.T) {
 	assert.Equal(t, versionID, resp.VersionID)
 	assert.Equal(t, testURL.String(), resp.DownloadURL)
 }
+
+func TestGetScheduleVersions(t *testing.T) {
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	uploadedAt := time.Date(2026, 1, 13, 9, 30, 0, 0, time.UTC)
+	mockSM.On("ListGTFSVersions").Return([]tools.ObjectInfo{
+		{VersionID: "v2", Size: 20, ETag: "etag2", LastModified: uploadedAt.Add(time.Hour)},
+		{VersionID: "v1", Size: 10, ETag: "etag1", LastModified: uploadedAt},
+	}, nil)
+
+	req := httptest.NewRequest("GET", "/schedule/versions", nil)
+	rr := httptest.NewRecorder()
+
+	handler := handlers.GetScheduleVersions(mockSM)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp api.GetScheduleVersionsResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	assert.Len(t, resp.Versions, 2)
+	assert.Equal(t, "v2", resp.Versions[0].VersionID)
+	assert.Equal(t, api.ScheduleVersion{VersionID: "v1", UploadedAt: uploadedAt, Size: 10, ETag: "etag1"}, resp.Versions[1])
+}
+
+func TestGetScheduleVersionsNoSchedule(t *testing.T) {
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("ListGTFSVersions").Return([]tools.ObjectInfo(nil), tools.NoGTFSScheduleFound)
+
+	req := httptest.NewRequest("GET", "/schedule/versions", nil)
+	rr := httptest.NewRecorder()
+
+	handler := handlers.GetScheduleVersions(mockSM)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestGetScheduleVersionDownloadURL(t *testing.T) {
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	testURL, _ := url.Parse("https://example.com/gtfs.zip?versionId=v1")
+	mockSM.On("GetVersionURL", "v1").Return(testURL, nil)
+	mockSM.On("GetVersionURL", "missing").Return((*url.URL)(nil), tools.GTFSVersionNotFound)
+
+	r := chi.NewRouter()
+	r.Get("/schedule/{versionID}", handlers.GetScheduleVersionDownloadURL(mockSM))
+
+	req := httptest.NewRequest("GET", "/schedule/v1", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp api.GetTimetableResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	assert.Equal(t, "v1", resp.VersionID)
+	assert.Equal(t, testURL.String(), resp.DownloadURL)
+
+	req = httptest.NewRequest("GET", "/schedule/missing", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+// serveSchedule routes a request through a router, so URL parameters are resolved.
+func serveSchedule(pattern string, handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get(pattern, handler)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+	return rr
+}
+
+func TestGetStops(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+
+	rr := serveSchedule("/stops", handlers.GetStops(ss), "/stops")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp api.GetStopsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "v1", resp.VersionID)
+	require.Len(t, resp.Stops, 4)
+	assert.Equal(t, "Douglas Bus Station", resp.Stops[0].Name)
+	assert.Equal(t, "DGLS", resp.Stops[1].ParentStationID)
+}
+
+func TestGetStop(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+
+	t.Run("stop", func(t *testing.T) {
+		rr := serveSchedule("/stops/{stopID}", handlers.GetStop(ss), "/stops/ONCH")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.GetStopResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "Onchan Village", resp.Stop.Name)
+		assert.Empty(t, resp.Children)
+		require.Len(t, resp.Routes, 2)
+		assert.Equal(t, "1", resp.Routes[0].RouteID)
+		assert.Equal(t, "Bus Vannin", resp.Routes[0].Agency)
+	})
+
+	t.Run("station", func(t *testing.T) {
+		rr := serveSchedule("/stops/{stopID}", handlers.GetStop(ss), "/stops/DGLS")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.GetStopResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Children, 1)
+		assert.Equal(t, "DGLS1", resp.Children[0].StopID)
+		assert.Len(t, resp.Routes, 2)
+	})
+
+	t.Run("unknown stop", func(t *testing.T) {
+		rr := serveSchedule("/stops/{stopID}", handlers.GetStop(ss), "/stops/PORT")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("no schedule loaded", func(t *testing.T) {
+		rr := serveSchedule("/stops/{stopID}", handlers.GetStop(schedule.NewStore(new(mocks.ObjectStorageManagerMock))), "/stops/ONCH")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
+
+func TestGetRoutes(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+
+	rr := serveSchedule("/routes", handlers.GetRoutes(ss), "/routes")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp api.GetRoutesResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "v1", resp.VersionID)
+	require.Len(t, resp.Routes, 2)
+	assert.Equal(t, "Douglas - Onchan", resp.Routes[0].LongName)
+}
+
+func TestGetRoute(t *testing.T) {
+	files := fixtures.GTFSFiles()
+	// a short working from Douglas to Onchan and a return trip
+	files["trips.txt"] += "3,DAILY,T3S,Onchan,0,\n" +
+		"3,DAILY,T3R,Douglas,1,\n"
+	files["stop_times.txt"] += "T3S,10:00:00,10:00:00,DGLS1,1\n" +
+		"T3S,10:10:00,10:10:00,ONCH,2\n" +
+		"T3R,11:00:00,11:00:00,RMSY,1\n" +
+		"T3R,11:45:00,11:45:00,DGLS1,2\n"
+	ss := fixtures.ScheduleStore(t, files)
+
+	t.Run("patterns per direction", func(t *testing.T) {
+		rr := serveSchedule("/routes/{routeID}", handlers.GetRoute(ss), "/routes/3")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.GetRouteResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "v1", resp.VersionID)
+		assert.Equal(t, "Douglas - Ramsey", resp.Route.LongName)
+		require.Len(t, resp.Patterns, 2)
+
+		outbound := resp.Patterns[0]
+		assert.Equal(t, 0, outbound.DirectionID)
+		assert.Equal(t, "Ramsey", outbound.Headsign)
+		var stopIDs []string
+		for _, stop := range outbound.Stops {
+			stopIDs = append(stopIDs, stop.StopID)
+		}
+		assert.Equal(t, []string{"DGLS1", "ONCH", "RMSY"}, stopIDs)
+
+		inbound := resp.Patterns[1]
+		assert.Equal(t, 1, inbound.DirectionID)
+		assert.Len(t, inbound.Stops, 2)
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		rr := serveSchedule("/routes/{routeID}", handlers.GetRoute(ss), "/routes/99")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func newRollbackRequest(versionID string) *http.Request {
+	formData := url.Values{}
+	if versionID != "" {
+		formData.Set("versionID", versionID)
+	}
+	req := httptest.NewRequest("POST", "/schedule/rollback", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestRollbackGTFSSchedule(t *testing.T) {
+	t.Run("valid version", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetScheduleVersion", "v1").Return(fixtures.GTFSZip(), nil)
+		mockSM.On("RollbackSchedule", "v1").Return("v3", nil)
+		mockSM.On("GetLatestGTFSVersionID").Return("v3", nil)
+		mockSM.On("GetLatestSchedule").Return(fixtures.GTFSZip(), "v3", nil)
+		ss := schedule.NewStore(mockSM)
+
+		rr := httptest.NewRecorder()
+		handlers.RollbackGTFSSchedule(mockSM, ss).ServeHTTP(rr, newRollbackRequest("v1"))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		var resp api.RollbackScheduleResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Equal(t, "v3", resp.VersionID)
+		assert.Equal(t, "v1", resp.SourceVersionID)
+		assert.Equal(t, "v3", ss.Current().VersionID)
+		mockSM.AssertExpectations(t)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetScheduleVersion", "missing").Return([]byte(nil), tools.GTFSVersionNotFound)
+
+		rr := httptest.NewRecorder()
+		handlers.RollbackGTFSSchedule(mockSM, schedule.NewStore(mockSM)).ServeHTTP(rr, newRollbackRequest("missing"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockSM.AssertNotCalled(t, "RollbackSchedule")
+	})
+
+	t.Run("version that fails validation", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["agency.txt"] = "agency_id,agency_name,agency_url,agency_timezone\n"
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetScheduleVersion", "v0").Return(fixtures.Zip(files), nil)
+
+		rr := httptest.NewRecorder()
+		handlers.RollbackGTFSSchedule(mockSM, schedule.NewStore(mockSM)).ServeHTTP(rr, newRollbackRequest("v0"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var resp api.GTFSValidationErrorResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.NotZero(t, resp.ErrorCount)
+		mockSM.AssertNotCalled(t, "RollbackSchedule")
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+
+		rr := httptest.NewRecorder()
+		handlers.RollbackGTFSSchedule(mockSM, schedule.NewStore(mockSM)).ServeHTTP(rr, newRollbackRequest(""))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockSM.AssertNotCalled(t, "RollbackSchedule")
+	})
+}
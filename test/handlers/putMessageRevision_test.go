@@ -0,0 +1,120 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+// messageLogMock returns a storage mock holding a message log with these entries, which records
+// the entries appended to it.
+func messageLogMock(t *testing.T, entries ...tools.MessageLog) (*mocks.ObjectStorageManagerMock, *[]tools.MessageLog) {
+	b := bytes.Buffer{}
+	for _, entry := range entries {
+		require.NoError(t, json.NewEncoder(&b).Encode(entry))
+	}
+
+	var appended []tools.MessageLog
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("GetLatestLog").Return(&b, nil)
+	mockSM.On("AppendMessage", mock.Anything).Run(func(args mock.Arguments) {
+		appended = append(appended, tools.ParseMessageLog(args.Get(0).(*bytes.Buffer))...)
+	}).Return("m124", nil)
+	return mockSM, &appended
+}
+
+// serveMessage routes a request for a message through a router, so its ID is resolved.
+func serveMessage(method string, handler http.HandlerFunc, target string, formData url.Values) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Method(method, "/messages/{messageID}", handler)
+	req := httptest.NewRequest(method, target, strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestEditMessage(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	original := tools.MessageLog{ID: "a", Timestamp: "2026-01-13T08:00:00Z", Message: "Route 3 divreted", Severity: "WARNING"}
+
+	t.Run("revision", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original)
+
+		rr := serveMessage("PUT", handlers.EditMessage(mockSM, ss), "/messages/a", url.Values{
+			"message":  {"Route 3 diverted"},
+			"routeIDs": {"3"},
+		})
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		var resp api.PutMessageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "a", resp.ID)
+		assert.Equal(t, "m124", resp.VersionID)
+
+		require.Len(t, *appended, 1)
+		revision := (*appended)[0]
+		assert.Equal(t, "a", revision.ID)
+		assert.Equal(t, original.Timestamp, revision.Timestamp)
+		assert.Equal(t, "Route 3 diverted", revision.Message)
+		assert.Equal(t, []string{"3"}, revision.RouteIDs)
+		// fields left out of the form are cleared
+		assert.Empty(t, revision.Severity)
+		assert.NotEmpty(t, revision.EditedAt)
+	})
+
+	t.Run("message from an older release", func(t *testing.T) {
+		legacy := tools.MessageLog{Timestamp: "2025-12-01T08:00:00Z", Message: "Hello"}
+		mockSM, appended := messageLogMock(t, legacy)
+
+		rr := serveMessage("PUT", handlers.EditMessage(mockSM, ss), "/messages/"+legacy.Timestamp, url.Values{"message": {"Hello again"}})
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		require.Len(t, *appended, 1)
+		assert.Equal(t, legacy.Timestamp, (*appended)[0].ID)
+	})
+
+	t.Run("retracted message", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original, tools.NewTombstone(original))
+
+		rr := serveMessage("PUT", handlers.EditMessage(mockSM, ss), "/messages/a", url.Values{"message": {"Route 3 diverted"}})
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, *appended)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original)
+
+		rr := serveMessage("PUT", handlers.EditMessage(mockSM, ss), "/messages/b", url.Values{"message": {"Route 3 diverted"}})
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, *appended)
+	})
+
+	t.Run("invalid revision", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original)
+
+		rr := serveMessage("PUT", handlers.EditMessage(mockSM, ss), "/messages/a", url.Values{
+			"message":  {"Route 3 diverted"},
+			"severity": {"catastrophic"},
+		})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, *appended)
+	})
+}
@@ -1,17 +1,23 @@
 package handlers_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/transitIOM/projectMercury/api"
 	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
 	"github.com/transitIOM/projectMercury/test/mocks"
 )
 
@@ -26,7 +32,7 @@ func TestPutMessage(t *testing.T) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	rr := httptest.NewRecorder()
 
-	handler := handlers.PutMessage(mockSM)
+	handler := handlers.PutMessage(mockSM, schedule.NewStore(mockSM))
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusAccepted, rr.Code)
@@ -35,3 +41,100 @@ func TestPutMessage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, versionID, resp.VersionID)
 }
+
+func newPutMessageRequest(formData url.Values) *http.Request {
+	req := httptest.NewRequest("PUT", "/messages/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestPutStructuredMessage(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	activeUntil := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	t.Run("structured message", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		var stored tools.MessageLog
+		mockSM.On("AppendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			require.NoError(t, json.Unmarshal(args.Get(0).(*bytes.Buffer).Bytes(), &stored))
+		}).Return("m124", nil)
+
+		rr := httptest.NewRecorder()
+		handlers.PutMessage(mockSM, ss).ServeHTTP(rr, newPutMessageRequest(url.Values{
+			"message":     {"Route 3 diverted"},
+			"severity":    {"warning"},
+			"cause":       {"construction"},
+			"effect":      {"DETOUR"},
+			"category":    {"DIVERSION"},
+			"routeIDs":    {"3, 1", "3"},
+			"stopIDs":     {"ONCH"},
+			"activeUntil": {activeUntil},
+		}))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, "Route 3 diverted", stored.Message)
+		assert.Equal(t, "WARNING", stored.Severity)
+		assert.Equal(t, "CONSTRUCTION", stored.Cause)
+		assert.Equal(t, "DETOUR", stored.Effect)
+		assert.Equal(t, "diversion", stored.Category)
+		assert.Equal(t, []string{"3", "1"}, stored.RouteIDs)
+		assert.Equal(t, []string{"ONCH"}, stored.StopIDs)
+		assert.Empty(t, stored.ActiveFrom)
+		assert.Equal(t, activeUntil, stored.ActiveUntil)
+	})
+
+	invalid := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{"invalid severity", "severity", "catastrophic"},
+		{"invalid cause", "cause", "aliens"},
+		{"invalid effect", "effect", "teleport"},
+		{"invalid category", "category", "gossip"},
+		{"unknown route", "routeIDs", "99"},
+		{"unknown stop", "stopIDs", "PORT"},
+		{"invalid activeFrom", "activeFrom", "tomorrow"},
+		{"expired", "activeUntil", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSM := new(mocks.ObjectStorageManagerMock)
+
+			rr := httptest.NewRecorder()
+			handlers.PutMessage(mockSM, ss).ServeHTTP(rr, newPutMessageRequest(url.Values{
+				"message": {"Route 3 diverted"},
+				tt.field:  {tt.value},
+			}))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			mockSM.AssertNotCalled(t, "AppendMessage", mock.Anything)
+		})
+	}
+
+	t.Run("window ending before it starts", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+
+		rr := httptest.NewRecorder()
+		handlers.PutMessage(mockSM, ss).ServeHTTP(rr, newPutMessageRequest(url.Values{
+			"message":     {"Route 3 diverted"},
+			"activeFrom":  {time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)},
+			"activeUntil": {activeUntil},
+		}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("scoped message without a schedule", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+
+		rr := httptest.NewRecorder()
+		handlers.PutMessage(mockSM, schedule.NewStore(mockSM)).ServeHTTP(rr, newPutMessageRequest(url.Values{
+			"message":  {"Route 3 diverted"},
+			"routeIDs": {"3"},
+		}))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		mockSM.AssertNotCalled(t, "AppendMessage", mock.Anything)
+	})
+}
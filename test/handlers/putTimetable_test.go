@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -10,7 +11,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/transitIOM/projectMercury/api"
 	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
 	"github.com/transitIOM/projectMercury/test/mocks"
 )
 
@@ -18,7 +23,10 @@ func TestPutGTFSSchedule(t *testing.T) {
 	t.Run("valid zip file", func(t *testing.T) {
 		mockSM := new(mocks.ObjectStorageManagerMock)
 		versionID := "test-version-123"
-		mockSM.On("PutSchedule", mock.Anything, mock.AnythingOfType("int64")).Return(versionID, nil)
+		mockSM.On("PutSchedule", mock.Anything, mock.AnythingOfType("int64"), "").Return(versionID, nil)
+		mockSM.On("GetLatestGTFSVersionID").Return(versionID, nil)
+		mockSM.On("GetLatestSchedule").Return(fixtures.GTFSZip(), versionID, nil)
+		ss := schedule.NewStore(mockSM)
 
 		body := &bytes.Buffer{}
 		writer := multipart.NewWriter(body)
@@ -26,21 +34,79 @@ func TestPutGTFSSchedule(t *testing.T) {
 		h.Set("Content-Disposition", `form-data; name="GTFSSchedule"; filename="schedule.zip"`)
 		h.Set("Content-Type", "application/zip")
 		part, _ := writer.CreatePart(h)
-		part.Write([]byte("fake-zip-content"))
+		part.Write(fixtures.GTFSZip())
 		writer.Close()
 
 		req := httptest.NewRequest("PUT", "/schedule/", body)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
 		rr := httptest.NewRecorder()
 
-		handler := handlers.PutGTFSSchedule(mockSM)
+		handler := handlers.PutGTFSSchedule(mockSM, ss)
 		handler.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusAccepted, rr.Code)
 		assert.Contains(t, rr.Body.String(), versionID)
+		assert.Equal(t, versionID, ss.Current().VersionID)
 		mockSM.AssertExpectations(t)
 	})
 
+	t.Run("stale expected version", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("PutSchedule", mock.Anything, mock.AnythingOfType("int64"), "old-version").Return("", tools.GTFSVersionConflict)
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="GTFSSchedule"; filename="schedule.zip"`)
+		h.Set("Content-Type", "application/zip")
+		part, _ := writer.CreatePart(h)
+		part.Write(fixtures.GTFSZip())
+		writer.Close()
+
+		req := httptest.NewRequest("PUT", "/schedule/", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("If-Match", `"old-version"`)
+		rr := httptest.NewRecorder()
+
+		handler := handlers.PutGTFSSchedule(mockSM, schedule.NewStore(mockSM))
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		mockSM.AssertExpectations(t)
+	})
+
+	t.Run("invalid GTFS feed", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+
+		files := fixtures.GTFSFiles()
+		delete(files, "agency.txt")
+		files["trips.txt"] += "9,DAILY,T9,Nowhere,0,\n"
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="GTFSSchedule"; filename="schedule.zip"`)
+		h.Set("Content-Type", "application/zip")
+		part, _ := writer.CreatePart(h)
+		part.Write(fixtures.Zip(files))
+		writer.Close()
+
+		req := httptest.NewRequest("PUT", "/schedule/", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+
+		handler := handlers.PutGTFSSchedule(mockSM, schedule.NewStore(mockSM))
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var response api.GTFSValidationErrorResponse
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, 2, response.ErrorCount)
+		assert.Contains(t, response.Errors, api.ValidationIssue{File: "agency.txt", Message: "required file is missing"})
+		assert.Contains(t, response.Errors, api.ValidationIssue{File: "trips.txt", Line: 5, Field: "route_id", Message: `route_id "9" does not exist in routes.txt`})
+		mockSM.AssertNotCalled(t, "PutSchedule", mock.Anything, mock.Anything, mock.Anything)
+	})
+
 	t.Run("invalid file type", func(t *testing.T) {
 		mockSM := new(mocks.ObjectStorageManagerMock)
 
@@ -69,7 +135,7 @@ func TestPutGTFSSchedule(t *testing.T) {
 		req.Header.Set("Content-Type", writer.FormDataContentType())
 		rr := httptest.NewRecorder()
 
-		handler := handlers.PutGTFSSchedule(mockSM)
+		handler := handlers.PutGTFSSchedule(mockSM, schedule.NewStore(mockSM))
 		handler.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
@@ -87,7 +153,7 @@ func TestPutGTFSSchedule(t *testing.T) {
 		req.Header.Set("Content-Type", writer.FormDataContentType())
 		rr := httptest.NewRecorder()
 
-		handler := handlers.PutGTFSSchedule(mockSM)
+		handler := handlers.PutGTFSSchedule(mockSM, schedule.NewStore(mockSM))
 		handler.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
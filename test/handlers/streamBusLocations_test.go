@@ -0,0 +1,216 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/api"
+	"github.com/transitIOM/projectMercury/internal/handlers"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+type streamEvent struct {
+	id    string
+	event string
+	data  api.LocationEvent
+}
+
+type eventStream struct {
+	t      *testing.T
+	events chan streamEvent
+}
+
+// openStream connects to the location stream, which is closed when the test ends.
+func openStream(t *testing.T, server *httptest.Server, target string, lastEventID string) (*http.Response, *eventStream) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+target, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	stream := &eventStream{t: t, events: make(chan streamEvent, 100)}
+	if resp.StatusCode != http.StatusOK {
+		return resp, stream
+	}
+	go func() {
+		defer close(stream.events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event streamEvent
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.event = value
+			case "data":
+				if err := json.Unmarshal([]byte(value), &event.data); err != nil {
+					return
+				}
+			case "":
+				if event.event != "" {
+					stream.events <- event
+				}
+				event = streamEvent{}
+			}
+		}
+	}()
+	return resp, stream
+}
+
+func (s *eventStream) next() streamEvent {
+	s.t.Helper()
+	select {
+	case event, ok := <-s.events:
+		require.True(s.t, ok, "stream was closed")
+		return event
+	case <-time.After(time.Second):
+		s.t.Fatal("no event was received")
+		return streamEvent{}
+	}
+}
+
+func (s *eventStream) none() {
+	s.t.Helper()
+	select {
+	case event := <-s.events:
+		s.t.Fatalf("unexpected %s event for bus %s", event.event, event.data.BusID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStreamBusLocations(t *testing.T) {
+	at := time.Date(2026, 1, 13, 9, 1, 0, 0, time.UTC)
+	bus := func(busID string, route string, lat float64, lon float64) tools.BusLocation {
+		return tools.BusLocation{BusID: busID, RouteNumber: route, Latitude: lat, Longitude: lon, Timestamp: at}
+	}
+
+	setup := func(t *testing.T, ctx context.Context) (*httptest.Server, *tools.LocationStore) {
+		ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+		store := tools.NewLocationStore(time.Minute)
+		feed := tools.NewLocationFeed(tools.DefaultLocationFeedSize)
+		store.OnChange(feed.Publish)
+
+		r := chi.NewRouter()
+		handlers.Handler(ctx, r, new(mocks.ObjectStorageManagerMock), ss, realtime.NewTracker(ss), store, feed)
+		server := httptest.NewServer(r)
+		t.Cleanup(server.Close)
+		return server, store
+	}
+
+	t.Run("snapshot then changes", func(t *testing.T) {
+		server, store := setup(t, context.Background())
+		store.Update([]tools.BusLocation{bus("42", "3", 54.1467, -4.4794)})
+
+		resp, stream := openStream(t, server, "/v1/locations/stream", "")
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		event := stream.next()
+		assert.Equal(t, "added", event.event)
+		assert.Equal(t, "42", event.data.BusID)
+		assert.Equal(t, "3", event.data.RouteNumber)
+		assert.Equal(t, at, event.data.LastSeen)
+		assert.NotEmpty(t, event.id)
+
+		store.Update([]tools.BusLocation{bus("42", "3", 54.15, -4.48), bus("43", "5", 54.2, -4.5)})
+		event = stream.next()
+		assert.Equal(t, "updated", event.event)
+		assert.Equal(t, 54.15, event.data.Latitude)
+		event = stream.next()
+		assert.Equal(t, "added", event.event)
+		assert.Equal(t, "43", event.data.BusID)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		server, store := setup(t, context.Background())
+		store.Update([]tools.BusLocation{bus("42", "3", 54.1467, -4.4794), bus("43", "5", 54.1467, -4.4794)})
+
+		_, stream := openStream(t, server, "/v1/locations/stream?route=3,11&bbox=-4.6,54.0,-4.3,54.3", "")
+		event := stream.next()
+		assert.Equal(t, "added", event.event)
+		assert.Equal(t, "42", event.data.BusID)
+		stream.none()
+
+		// out of the bounding box
+		store.Update([]tools.BusLocation{bus("42", "3", 54.4, -4.4794)})
+		event = stream.next()
+		assert.Equal(t, "removed", event.event)
+		assert.Equal(t, "42", event.data.BusID)
+
+		// not on the routes
+		store.Update([]tools.BusLocation{bus("43", "5", 54.15, -4.48)})
+		stream.none()
+
+		store.Update([]tools.BusLocation{bus("42", "3", 54.1467, -4.4794)})
+		event = stream.next()
+		assert.Equal(t, "added", event.event)
+		assert.Equal(t, "42", event.data.BusID)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		server, store := setup(t, context.Background())
+		store.Update([]tools.BusLocation{bus("42", "3", 54.1467, -4.4794)})
+
+		_, first := openStream(t, server, "/v1/locations/stream", "")
+		last := first.next()
+
+		store.Update([]tools.BusLocation{bus("42", "3", 54.15, -4.48), bus("43", "5", 54.2, -4.5)})
+
+		_, stream := openStream(t, server, "/v1/locations/stream", last.id)
+		event := stream.next()
+		assert.Equal(t, "updated", event.event)
+		assert.Equal(t, "42", event.data.BusID)
+		event = stream.next()
+		assert.Equal(t, "added", event.event)
+		assert.Equal(t, "43", event.data.BusID)
+		stream.none()
+
+		// a client that cannot set the header
+		_, stream = openStream(t, server, "/v1/locations/stream?lastEventId="+event.id, "")
+		stream.none()
+	})
+
+	t.Run("ends when the server shuts down", func(t *testing.T) {
+		ctx, shutdown := context.WithCancel(context.Background())
+		server, store := setup(t, ctx)
+		store.Update([]tools.BusLocation{bus("42", "3", 54.1467, -4.4794)})
+
+		_, stream := openStream(t, server, "/v1/locations/stream", "")
+		stream.next()
+		shutdown()
+
+		select {
+		case _, open := <-stream.events:
+			assert.False(t, open, "stream sent an event after the server shut down")
+		case <-time.After(time.Second):
+			t.Fatal("stream was not closed when the server shut down")
+		}
+	})
+
+	t.Run("invalid bbox", func(t *testing.T) {
+		server, _ := setup(t, context.Background())
+		for _, bbox := range []string{"1,2,3", "a,b,c,d", "-4.3,54.0,-4.6,54.3"} {
+			resp, _ := openStream(t, server, "/v1/locations/stream?bbox="+bbox, "")
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, bbox)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package v2_test
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	v2 "github.com/transitIOM/projectMercury/internal/handlers/v2"
+	"github.com/transitIOM/projectMercury/internal/middleware"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+const testKey = "test-api-key"
+
+// useTestKey makes testKey the API key for the duration of a test.
+func useTestKey(t *testing.T) {
+	originalHash := middleware.ExpectedHash
+	h := crypto.SHA256.New()
+	h.Write([]byte(testKey))
+	middleware.ExpectedHash = hex.EncodeToString(h.Sum(nil))
+	t.Cleanup(func() { middleware.ExpectedHash = originalHash })
+}
+
+// serve routes a request through the v2 router, authenticated if key is set.
+func serve(router chi.Router, method, target, key string, body any) *httptest.ResponseRecorder {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		encoded, _ := json.Marshal(b)
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// messageLogMock returns a storage mock holding a message log with these entries, which records
+// the entries appended to it.
+func messageLogMock(t *testing.T, entries ...tools.MessageLog) (*mocks.ObjectStorageManagerMock, *[]tools.MessageLog) {
+	b := bytes.Buffer{}
+	for _, entry := range entries {
+		require.NoError(t, json.NewEncoder(&b).Encode(entry))
+	}
+
+	var appended []tools.MessageLog
+	mockSM := new(mocks.ObjectStorageManagerMock)
+	mockSM.On("GetLatestLog").Return(&b, nil)
+	mockSM.On("GetLatestLogTail", mock.Anything).Return(&b, nil)
+	mockSM.On("GetLatestMessageVersionID").Return("m123", nil)
+	mockSM.On("AppendMessage", mock.Anything).Run(func(args mock.Arguments) {
+		appended = append(appended, tools.ParseMessageLog(args.Get(0).(*bytes.Buffer))...)
+	}).Return("m124", nil)
+	return mockSM, &appended
+}
+
+func newRouter(sm tools.ObjectStorageManager, ss *schedule.Store) chi.Router {
+	return v2.Router(sm, ss, realtime.NewTracker(ss), tools.NewLocationStore(tools.DefaultLocationExpiry).Buses)
+}
+
+func decodeError(t *testing.T, rr *httptest.ResponseRecorder) api.Error {
+	var resp api.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), rr.Body.String())
+	assert.Equal(t, rr.Code, resp.Error.Status)
+	return resp.Error
+}
+
+func TestRouterErrors(t *testing.T) {
+	useTestKey(t)
+	mockSM, _ := messageLogMock(t)
+	router := newRouter(mockSM, schedule.NewStore(mockSM))
+
+	t.Run("unknown path", func(t *testing.T) {
+		rr := serve(router, "GET", "/nowhere", "", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "notFound", decodeError(t, rr).Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rr := serve(router, "PATCH", "/messages", "", nil)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+		assert.Equal(t, "methodNotAllowed", decodeError(t, rr).Code)
+	})
+
+	t.Run("missing API key", func(t *testing.T) {
+		rr := serve(router, "POST", "/messages", "", api.MessageRequest{Message: "Hello"})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, "unauthorized", decodeError(t, rr).Code)
+	})
+
+	t.Run("invalid API key", func(t *testing.T) {
+		rr := serve(router, "POST", "/messages", "wrong-key", api.MessageRequest{Message: "Hello"})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, "unauthorized", decodeError(t, rr).Code)
+	})
+}
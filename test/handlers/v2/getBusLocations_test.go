@@ -0,0 +1,58 @@
+package v2_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	v2 "github.com/transitIOM/projectMercury/internal/handlers/v2"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func TestGetBusLocations(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+
+	t.Run("no buses", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		v2.GetBusLocations(realtime.NewTracker(ss), tools.NewLocationStore(tools.DefaultLocationExpiry).Buses)(rr, httptest.NewRequest("GET", "/locations", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"data":[],"meta":{}}`, rr.Body.String())
+	})
+
+	t.Run("matched buses", func(t *testing.T) {
+		seen := schedule.ServiceDay(time.Now()).Add(9*time.Hour + time.Minute)
+		buses := func() []tools.BusLocation {
+			return []tools.BusLocation{
+				{BusID: "42", DepartureTime: "0900", RouteNumber: "3", Direction: "outbound", Latitude: 54.1467, Longitude: -4.4794, Timestamp: seen},
+				{BusID: "43", DepartureTime: "1000", RouteNumber: "5", Latitude: 54.1467, Longitude: -4.4794, Timestamp: seen},
+			}
+		}
+
+		rr := httptest.NewRecorder()
+		v2.GetBusLocations(realtime.NewTracker(ss), buses)(rr, httptest.NewRequest("GET", "/locations", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.VehiclesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 2)
+		assert.Equal(t, "42", resp.Data[0].BusID)
+		assert.Equal(t, 54.1467, resp.Data[0].Lat)
+		require.NotNil(t, resp.Data[0].LastSeen)
+		assert.True(t, seen.Equal(*resp.Data[0].LastSeen))
+		assert.Equal(t, "3", resp.Data[0].RouteID)
+		assert.Equal(t, "T3", resp.Data[0].TripID)
+		require.NotNil(t, resp.Data[0].DelaySeconds)
+		assert.Equal(t, 60, *resp.Data[0].DelaySeconds)
+		assert.Empty(t, resp.Data[1].TripID)
+		assert.Nil(t, resp.Data[1].DelaySeconds)
+	})
+}
@@ -0,0 +1,211 @@
+package v2_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	api "github.com/transitIOM/projectMercury/api/v2"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func TestGetMessages(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	var entries []tools.MessageLog
+	for i := range 3 {
+		entries = append(entries, tools.MessageLog{
+			ID:        fmt.Sprintf("m%d", i+1),
+			Timestamp: start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339Nano),
+			Message:   fmt.Sprintf("Message %d", i+1),
+		})
+	}
+	entries[2].RouteIDs = []string{"3"}
+	entries[2].ActiveUntil = start.Add(48 * time.Hour).Format(time.RFC3339)
+	mockSM, _ := messageLogMock(t, entries...)
+	router := newRouter(mockSM, schedule.NewStore(mockSM))
+
+	t.Run("typed messages", func(t *testing.T) {
+		rr := serve(router, "GET", "/messages?limit=2", "", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.MessagesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 2)
+		assert.Equal(t, "m2", resp.Data[0].ID)
+		assert.Equal(t, "m3", resp.Data[1].ID)
+		assert.Equal(t, "Message 3", resp.Data[1].Message)
+		assert.True(t, start.Add(2*time.Minute).Equal(resp.Data[1].PostedAt))
+		assert.Equal(t, []string{"3"}, resp.Data[1].RouteIDs)
+		require.NotNil(t, resp.Data[1].ActiveUntil)
+		assert.True(t, start.Add(48*time.Hour).Equal(*resp.Data[1].ActiveUntil))
+		assert.Nil(t, resp.Data[1].EditedAt)
+		assert.Equal(t, "m123", resp.Meta.VersionID)
+		require.NotEmpty(t, resp.Meta.NextCursor)
+
+		rr = serve(router, "GET", "/messages?limit=2&cursor="+url.QueryEscape(resp.Meta.NextCursor), "", nil)
+		var older api.MessagesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &older))
+		require.Len(t, older.Data, 1)
+		assert.Equal(t, "m1", older.Data[0].ID)
+		assert.Empty(t, older.Meta.NextCursor)
+	})
+
+	t.Run("no new messages", func(t *testing.T) {
+		rr := serve(router, "GET", "/messages?since=m3", "", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"data":[],"meta":{"versionID":"m123"}}`, rr.Body.String())
+	})
+
+	t.Run("retracted since", func(t *testing.T) {
+		retraction := tools.MessageLog{ID: "m1", Timestamp: entries[0].Timestamp, RetractedAt: start.Add(3 * time.Minute).Format(time.RFC3339Nano)}
+		mockSM, _ := messageLogMock(t, append(entries, retraction)...)
+
+		rr := serve(newRouter(mockSM, schedule.NewStore(mockSM)), "GET", "/messages?since=m3", "", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.MessagesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, "m1", resp.Data[0].ID)
+		require.NotNil(t, resp.Data[0].RetractedAt)
+		assert.True(t, start.Add(3*time.Minute).Equal(*resp.Data[0].RetractedAt))
+		assert.Empty(t, resp.Data[0].Message)
+	})
+
+	t.Run("no message log", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestMessageVersionID").Return("", tools.NoMessageLogFound)
+
+		rr := serve(newRouter(mockSM, schedule.NewStore(mockSM)), "GET", "/messages", "", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"data":[],"meta":{}}`, rr.Body.String())
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		rr := serve(router, "GET", "/messages?limit=500", "", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "badRequest", decodeError(t, rr).Code)
+	})
+
+	t.Run("single message", func(t *testing.T) {
+		rr := serve(router, "GET", "/messages/m2", "", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.MessageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "Message 2", resp.Data.Message)
+
+		rr = serve(router, "GET", "/messages/m9", "", nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "notFound", decodeError(t, rr).Code)
+	})
+}
+
+func TestWriteMessages(t *testing.T) {
+	useTestKey(t)
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	original := tools.MessageLog{ID: "a", Timestamp: "2026-01-13T08:00:00Z", Message: "Route 3 divreted"}
+
+	t.Run("post", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t)
+
+		rr := serve(newRouter(mockSM, ss), "POST", "/messages", testKey, api.MessageRequest{
+			Message:  "Route 3 diverted",
+			Severity: "warning",
+			RouteIDs: []string{"3", "3"},
+		})
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var resp api.MessageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Data.ID)
+		assert.Equal(t, "WARNING", resp.Data.Severity)
+		assert.Equal(t, []string{"3"}, resp.Data.RouteIDs)
+		assert.Equal(t, "m124", resp.Meta.VersionID)
+		require.Len(t, *appended, 1)
+		assert.Equal(t, resp.Data.ID, (*appended)[0].ID)
+	})
+
+	invalid := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{"malformed body", `{"message": `, http.StatusBadRequest},
+		{"unknown field", `{"message": "Hello", "priority": 1}`, http.StatusBadRequest},
+		{"missing message", api.MessageRequest{Severity: "INFO"}, http.StatusBadRequest},
+		{"invalid severity", api.MessageRequest{Message: "Hello", Severity: "catastrophic"}, http.StatusBadRequest},
+		{"unknown route", api.MessageRequest{Message: "Hello", RouteIDs: []string{"99"}}, http.StatusBadRequest},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSM, appended := messageLogMock(t)
+
+			rr := serve(newRouter(mockSM, ss), "POST", "/messages", testKey, tt.body)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, "badRequest", decodeError(t, rr).Code)
+			assert.Empty(t, *appended)
+		})
+	}
+
+	t.Run("scoped message without a schedule", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t)
+
+		rr := serve(newRouter(mockSM, schedule.NewStore(mockSM)), "POST", "/messages", testKey, api.MessageRequest{
+			Message:  "Route 3 diverted",
+			RouteIDs: []string{"3"},
+		})
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "serviceUnavailable", decodeError(t, rr).Code)
+		assert.Empty(t, *appended)
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original)
+
+		rr := serve(newRouter(mockSM, ss), "PUT", "/messages/a", testKey, api.MessageRequest{Message: "Route 3 diverted"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.MessageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "a", resp.Data.ID)
+		assert.Equal(t, "Route 3 diverted", resp.Data.Message)
+		assert.True(t, resp.Data.PostedAt.Equal(original.Time()))
+		assert.NotNil(t, resp.Data.EditedAt)
+		require.Len(t, *appended, 1)
+		assert.NotEmpty(t, (*appended)[0].EditedAt)
+	})
+
+	t.Run("retract", func(t *testing.T) {
+		mockSM, appended := messageLogMock(t, original)
+		router := newRouter(mockSM, ss)
+
+		rr := serve(router, "DELETE", "/messages/a", testKey, nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp api.MessageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "a", resp.Data.ID)
+		assert.Equal(t, original.Message, resp.Data.Message)
+		assert.NotNil(t, resp.Data.RetractedAt)
+		require.Len(t, *appended, 1)
+		assert.NotEmpty(t, (*appended)[0].RetractedAt)
+
+		rr = serve(router, "DELETE", "/messages/b", testKey, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+}
@@ -0,0 +1,168 @@
+package history_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/history"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// memoryHistory keeps positions in memory, keyed by bus and hour.
+type memoryHistory struct {
+	mutex     sync.Mutex
+	positions map[string]*bytes.Buffer
+	appends   int
+	err       error
+}
+
+func newMemoryHistory() *memoryHistory {
+	return &memoryHistory{positions: make(map[string]*bytes.Buffer)}
+}
+
+func key(busID string, hour time.Time) string {
+	return busID + "/" + hour.UTC().Format(time.RFC3339)
+}
+
+func (m *memoryHistory) AppendPositions(busID string, hour time.Time, positions *bytes.Buffer) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.appends++
+	if m.positions[key(busID, hour)] == nil {
+		m.positions[key(busID, hour)] = &bytes.Buffer{}
+	}
+	m.positions[key(busID, hour)].Write(positions.Bytes())
+	return nil
+}
+
+func (m *memoryHistory) GetPositions(busID string, hour time.Time) (*bytes.Buffer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	positions, ok := m.positions[key(busID, hour)]
+	if !ok {
+		return nil, tools.NoPositionsFound
+	}
+	return bytes.NewBuffer(bytes.Clone(positions.Bytes())), nil
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 1, 13, hour, minute, 0, 0, time.UTC)
+}
+
+func fix(busID string, timestamp time.Time, lat float64) tools.BusLocation {
+	return tools.BusLocation{BusID: busID, RouteNumber: "3", Direction: "outbound", DepartureTime: "0900", Latitude: lat, Longitude: -4.47, Timestamp: timestamp}
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("stores new fixes once", func(t *testing.T) {
+		hs := newMemoryHistory()
+		recorder := history.NewRecorder(hs)
+
+		recorder.Record([]tools.BusLocation{fix("1", at(8, 58), 54.1), fix("2", at(8, 59), 54.2)})
+		// the same fix of bus 2 received again
+		recorder.Record([]tools.BusLocation{fix("1", at(9, 1), 54.3), fix("2", at(8, 59), 54.2)})
+		recorder.Flush()
+
+		// bus 1 crossed into a new hour, so it is stored in two partitions
+		assert.Equal(t, 3, hs.appends)
+		track, err := history.Track(hs, "1", at(8, 0), at(10, 0))
+		require.NoError(t, err)
+		require.Len(t, track, 2)
+		assert.Equal(t, at(8, 58), track[0].Time)
+		assert.Equal(t, 54.3, track[1].Latitude)
+		assert.Equal(t, "3", track[1].RouteNumber)
+
+		track, err = history.Track(hs, "2", at(8, 0), at(10, 0))
+		require.NoError(t, err)
+		assert.Len(t, track, 1)
+
+		// nothing new to store
+		recorder.Flush()
+		assert.Equal(t, 3, hs.appends)
+	})
+
+	t.Run("drops fixes that cannot be stored", func(t *testing.T) {
+		hs := newMemoryHistory()
+		hs.err = errors.New("storage unavailable")
+		recorder := history.NewRecorder(hs)
+
+		recorder.Record([]tools.BusLocation{fix("1", at(9, 0), 54.1)})
+		recorder.Flush()
+
+		hs.err = nil
+		recorder.Flush()
+		assert.Zero(t, hs.appends)
+	})
+
+	t.Run("flushes when stopped", func(t *testing.T) {
+		hs := newMemoryHistory()
+		recorder := history.NewRecorder(hs)
+		recorder.Record([]tools.BusLocation{fix("1", at(9, 0), 54.1)})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			recorder.Run(ctx, time.Hour)
+			close(done)
+		}()
+		cancel()
+		<-done
+
+		assert.Equal(t, 1, hs.appends)
+	})
+}
+
+func TestTrack(t *testing.T) {
+	hs := newMemoryHistory()
+	recorder := history.NewRecorder(hs)
+	recorder.Record([]tools.BusLocation{fix("1", at(8, 30), 54.1)})
+	recorder.Record([]tools.BusLocation{fix("1", at(9, 10), 54.2)})
+	recorder.Record([]tools.BusLocation{fix("1", at(9, 40), 54.3)})
+	recorder.Flush()
+	// a second replica storing the same fix
+	replica := history.NewRecorder(hs)
+	replica.Record([]tools.BusLocation{fix("1", at(9, 10), 54.2)})
+	replica.Flush()
+
+	t.Run("within the range", func(t *testing.T) {
+		track, err := history.Track(hs, "1", at(9, 0), at(9, 40))
+		require.NoError(t, err)
+		require.Len(t, track, 2)
+		assert.Equal(t, at(9, 10), track[0].Time)
+		assert.Equal(t, at(9, 40), track[1].Time)
+	})
+
+	t.Run("unknown bus", func(t *testing.T) {
+		track, err := history.Track(hs, "2", at(8, 0), at(10, 0))
+		require.NoError(t, err)
+		assert.Empty(t, track)
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		_, err := history.Track(hs, "1", at(10, 0), at(9, 0))
+		assert.ErrorIs(t, err, history.InvalidRange)
+
+		_, err = history.Track(hs, "1", at(9, 0), at(9, 0).Add(history.MaxTrackRange+time.Hour))
+		assert.ErrorIs(t, err, history.InvalidRange)
+	})
+
+	t.Run("storage errors", func(t *testing.T) {
+		failing := newMemoryHistory()
+		failing.err = errors.New("storage unavailable")
+		_, err := history.Track(failing, "1", at(9, 0), at(10, 0))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, history.InvalidRange)
+	})
+}
@@ -0,0 +1,60 @@
+package messages_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/messages"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func TestList(t *testing.T) {
+	now := time.Now().UTC()
+	var entries []string
+	for i, text := range []string{"Route 3 diverted", "Route 1 delayed", "Snow"} {
+		b, err := json.Marshal(tools.MessageLog{
+			ID:        string(rune('a' + i)),
+			Timestamp: now.Add(time.Duration(i-3) * time.Minute).Format(time.RFC3339Nano),
+			Message:   text,
+		})
+		require.NoError(t, err)
+		entries = append(entries, string(b)+"\n")
+	}
+	logContent := strings.Join(entries, "")
+
+	t.Run("limit below one returns a page of one", func(t *testing.T) {
+		for _, limit := range []int{0, -1} {
+			mockSM := new(mocks.ObjectStorageManagerMock)
+			mockSM.On("GetLatestLogTail", 2).Return(bytes.NewBufferString(logContent), nil)
+
+			page, err := messages.List(mockSM, messages.Query{Limit: limit}, now)
+
+			require.NoError(t, err, limit)
+			require.Len(t, page.Messages, 1, limit)
+			assert.Equal(t, "Snow", page.Messages[0].Message, limit)
+			assert.NotEmpty(t, page.NextCursor, limit)
+			mockSM.AssertExpectations(t)
+		}
+	})
+
+	t.Run("invalid lines do not end the log early", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		// the tail of two lines holds a single valid message
+		mockSM.On("GetLatestLogTail", 2).Return(bytes.NewBufferString("not json\n"+entries[2]), nil)
+		mockSM.On("GetLatestLogTail", 4).Return(bytes.NewBufferString(entries[0]+entries[1]+"not json\n"+entries[2]), nil)
+
+		page, err := messages.List(mockSM, messages.Query{Since: "a", Limit: 1}, now)
+
+		require.NoError(t, err)
+		require.Len(t, page.Messages, 1)
+		assert.Equal(t, "Snow", page.Messages[0].Message)
+		assert.NotEmpty(t, page.NextCursor)
+		mockSM.AssertExpectations(t)
+	})
+}
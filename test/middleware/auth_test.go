@@ -19,6 +19,9 @@ func TestAPIKeyAuth(t *testing.T) {
 	defer func() { middleware.ExpectedHash = originalHash }()
 
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if middleware.APIKeyID(r.Context()) == "" {
+			t.Error("authenticated request is missing the API key ID")
+		}
 		w.WriteHeader(http.StatusOK)
 	})
 
@@ -62,3 +65,29 @@ func TestAPIKeyAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIKeyAuthWith(t *testing.T) {
+	originalHash := middleware.ExpectedHash
+	middleware.ExpectedHash = "not-a-real-hash"
+	defer func() { middleware.ExpectedHash = originalHash }()
+
+	var unauthorizedErr error
+	handlerToTest := middleware.APIKeyAuthWith(func(w http.ResponseWriter, err error) {
+		unauthorizedErr = err
+		w.WriteHeader(http.StatusTeapot)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unauthenticated request reached the handler")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", "wrong-key")
+	rr := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTeapot {
+		t.Errorf("got status %d, want %d", rr.Code, http.StatusTeapot)
+	}
+	if unauthorizedErr == nil {
+		t.Error("unauthorized handler was not given an error")
+	}
+}
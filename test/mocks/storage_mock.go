@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"io"
 	"net/url"
+	"time"
 
 	"github.com/stretchr/testify/mock"
+	"github.com/transitIOM/projectMercury/internal/tools"
 )
 
 type ObjectStorageManagerMock struct {
@@ -22,8 +24,34 @@ func (m *ObjectStorageManagerMock) GetLatestURL() (*url.URL, string, error) {
 	return args.Get(0).(*url.URL), args.String(1), args.Error(2)
 }
 
-func (m *ObjectStorageManagerMock) PutSchedule(reader io.Reader, fileSize int64) (string, error) {
-	args := m.Called(reader, fileSize)
+func (m *ObjectStorageManagerMock) GetLatestSchedule() ([]byte, string, error) {
+	args := m.Called()
+	schedule, _ := args.Get(0).([]byte)
+	return schedule, args.String(1), args.Error(2)
+}
+
+func (m *ObjectStorageManagerMock) GetScheduleVersion(versionID string) ([]byte, error) {
+	args := m.Called(versionID)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *ObjectStorageManagerMock) GetVersionURL(versionID string) (*url.URL, error) {
+	args := m.Called(versionID)
+	return args.Get(0).(*url.URL), args.Error(1)
+}
+
+func (m *ObjectStorageManagerMock) PutSchedule(reader io.Reader, fileSize int64, expectedVersionID string) (string, error) {
+	args := m.Called(reader, fileSize, expectedVersionID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *ObjectStorageManagerMock) ListGTFSVersions() ([]tools.ObjectInfo, error) {
+	args := m.Called()
+	return args.Get(0).([]tools.ObjectInfo), args.Error(1)
+}
+
+func (m *ObjectStorageManagerMock) RollbackSchedule(versionID string) (string, error) {
+	args := m.Called(versionID)
 	return args.String(0), args.Error(1)
 }
 
@@ -37,6 +65,11 @@ func (m *ObjectStorageManagerMock) GetLatestLog() (*bytes.Buffer, error) {
 	return args.Get(0).(*bytes.Buffer), args.Error(1)
 }
 
+func (m *ObjectStorageManagerMock) GetLatestLogTail(n int) (*bytes.Buffer, error) {
+	args := m.Called(n)
+	return args.Get(0).(*bytes.Buffer), args.Error(1)
+}
+
 func (m *ObjectStorageManagerMock) GetLatestMessageVersionID() (string, error) {
 	args := m.Called()
 	return args.String(0), args.Error(1)
@@ -51,3 +84,13 @@ func (m *ObjectStorageManagerMock) Close() error {
 	args := m.Called()
 	return args.Error(0)
 }
+
+func (m *ObjectStorageManagerMock) AppendPositions(busID string, hour time.Time, positions *bytes.Buffer) error {
+	args := m.Called(busID, hour, positions)
+	return args.Error(0)
+}
+
+func (m *ObjectStorageManagerMock) GetPositions(busID string, hour time.Time) (*bytes.Buffer, error) {
+	args := m.Called(busID, hour)
+	return args.Get(0).(*bytes.Buffer), args.Error(1)
+}
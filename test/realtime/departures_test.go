@@ -0,0 +1,81 @@
+package realtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func at(hour, minute, second int) time.Time {
+	return time.Date(2026, 1, 13, hour, minute, second, 0, schedule.Location)
+}
+
+func bus(routeNumber, departureTime string, lat, lon float64, timestamp time.Time) tools.BusLocation {
+	return tools.BusLocation{
+		BusID:         "bus-" + routeNumber + "-" + departureTime,
+		DepartureTime: departureTime,
+		RouteNumber:   routeNumber,
+		Latitude:      lat,
+		Longitude:     lon,
+		Timestamp:     timestamp,
+	}
+}
+
+func TestLiveDepartures(t *testing.T) {
+	sched := fixtures.ScheduleStore(t, fixtures.GTFSFiles()).Current()
+	onchan := sched.Stops["ONCH"]
+
+	t.Run("late bus", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "09:00", 54.1467, -4.4794, at(9, 10, 0)),
+		})
+
+		departures := realtime.LiveDepartures(sched, onchan, at(9, 25, 0), 2, vehicles, at(9, 25, 0))
+
+		require.Len(t, departures, 2)
+		assert.Equal(t, "T3", departures[0].StopTime.Trip.ID)
+		assert.Equal(t, realtime.StatusLive, departures[0].Status)
+		assert.Equal(t, at(9, 32, 30), departures[0].Estimated)
+		assert.Equal(t, at(9, 22, 30), departures[0].Time)
+		assert.Equal(t, "T3N", departures[1].StopTime.Trip.ID)
+		assert.Equal(t, realtime.StatusScheduled, departures[1].Status)
+	})
+
+	t.Run("bus already left the stop", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "09:00", 54.3219, -4.3846, at(9, 20, 0)),
+		})
+
+		departures := realtime.LiveDepartures(sched, onchan, at(9, 15, 0), 1, vehicles, at(9, 20, 0))
+
+		require.Len(t, departures, 1)
+		assert.Equal(t, "T3N", departures[0].StopTime.Trip.ID)
+	})
+
+	t.Run("trip without a tracked bus", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("1", "08:00", 54.1733, -4.4527, at(8, 15, 0)),
+		})
+		require.Len(t, vehicles, 1)
+
+		departures := realtime.LiveDepartures(sched, onchan, at(9, 10, 0), 1, vehicles, at(9, 10, 0))
+
+		require.Len(t, departures, 1)
+		assert.Equal(t, "T3", departures[0].StopTime.Trip.ID)
+		assert.Equal(t, realtime.StatusNoShow, departures[0].Status)
+		assert.Equal(t, at(9, 22, 30), departures[0].Estimated)
+	})
+
+	t.Run("no tracked buses at all", func(t *testing.T) {
+		departures := realtime.LiveDepartures(sched, onchan, at(9, 10, 0), 1, nil, at(9, 10, 0))
+
+		require.Len(t, departures, 1)
+		assert.Equal(t, realtime.StatusScheduled, departures[0].Status)
+	})
+}
@@ -0,0 +1,148 @@
+package realtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func TestMatcher(t *testing.T) {
+	sched := fixtures.ScheduleStore(t, fixtures.GTFSFiles()).Current()
+
+	t.Run("matches route and departure time", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "09:00", 54.1733, -4.4527, at(9, 30, 0)),
+		})
+
+		require.Len(t, vehicles, 1)
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T3", vehicles[0].Trip.ID)
+		assert.Equal(t, "3", vehicles[0].Route.ID)
+		assert.Equal(t, 1, vehicles[0].NextStopIndex)
+		assert.Equal(t, 7*time.Minute+30*time.Second, vehicles[0].Delay)
+		assert.Equal(t, at(0, 0, 0), vehicles[0].ServiceDay)
+		assert.Greater(t, vehicles[0].Confidence, 0.5)
+	})
+
+	t.Run("departure time formats", func(t *testing.T) {
+		for _, departureTime := range []string{"0900", "900", "09:00", "9:00", "09:00:00", "2026-01-13T09:00:00Z", " 0900 "} {
+			vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+				bus("3", departureTime, 54.1467, -4.4794, at(9, 1, 0)),
+			})
+
+			require.Len(t, vehicles, 1)
+			require.NotNil(t, vehicles[0].Trip, departureTime)
+			assert.Equal(t, "T3", vehicles[0].Trip.ID, departureTime)
+		}
+	})
+
+	t.Run("route number variations", func(t *testing.T) {
+		for _, routeNumber := range []string{"3", "03", " 3 "} {
+			vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+				bus(routeNumber, "0900", 54.1467, -4.4794, at(9, 1, 0)),
+			})
+
+			require.NotNil(t, vehicles[0].Trip, routeNumber)
+			assert.Equal(t, "T3", vehicles[0].Trip.ID, routeNumber)
+		}
+	})
+
+	t.Run("departure time a minute off", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "0901", 54.1467, -4.4794, at(9, 1, 0)),
+		})
+
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T3", vehicles[0].Trip.ID)
+	})
+
+	t.Run("trips running past midnight", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "23:30", 54.3219, -4.3846, time.Date(2026, 1, 14, 0, 20, 0, 0, schedule.Location)),
+		})
+
+		require.Len(t, vehicles, 1)
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T3N", vehicles[0].Trip.ID)
+		assert.Equal(t, at(0, 0, 0), vehicles[0].ServiceDay)
+		assert.Equal(t, 5*time.Minute, vehicles[0].Delay)
+	})
+
+	t.Run("direction", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["trips.txt"] += "3,DAILY,T3I,Douglas,1,\n"
+		files["stop_times.txt"] += "T3I,09:00:00,09:00:00,RMSY,1\n" +
+			"T3I,09:22:30,09:22:30,ONCH,2\n" +
+			"T3I,09:45:00,09:45:00,DGLS1,3\n"
+		sched := fixtures.ScheduleStore(t, files).Current()
+
+		// both trips call at Onchan at the same time, so only the direction tells them apart
+		inbound := bus("3", "0900", 54.1733, -4.4527, at(9, 22, 0))
+		inbound.Direction = "Inbound"
+		outbound := inbound
+		outbound.Direction = "outbound"
+
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{inbound, outbound})
+
+		require.Len(t, vehicles, 2)
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T3I", vehicles[0].Trip.ID)
+		require.NotNil(t, vehicles[1].Trip)
+		assert.Equal(t, "T3", vehicles[1].Trip.ID)
+	})
+
+	t.Run("ambiguous match has lower confidence", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["trips.txt"] += "3,DAILY,T3X,Ramsey,0,S3\n"
+		files["stop_times.txt"] += "T3X,09:00:00,09:00:00,DGLS1,1\n" +
+			"T3X,09:45:00,09:45:00,RMSY,2\n"
+		ambiguous := fixtures.ScheduleStore(t, files).Current()
+		b := bus("3", "0900", 54.1467, -4.4794, at(9, 1, 0))
+
+		unique := realtime.NewMatcher().Match(sched, []tools.BusLocation{b})
+		duplicated := realtime.NewMatcher().Match(ambiguous, []tools.BusLocation{b})
+
+		require.NotNil(t, unique[0].Trip)
+		require.NotNil(t, duplicated[0].Trip)
+		assert.Less(t, duplicated[0].Confidence, unique[0].Confidence)
+	})
+
+	t.Run("trips without stop times are skipped", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["trips.txt"] += "3,DAILY,T3E,Ramsey,0,S3\n"
+		sched := fixtures.ScheduleStore(t, files).Current()
+
+		// a trip without stop times departs at 0, so a departure just after midnight is near it
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "0001", 54.1467, -4.4794, at(0, 1, 0)),
+		})
+
+		require.Len(t, vehicles, 1)
+		assert.Nil(t, vehicles[0].Trip)
+		require.NotNil(t, vehicles[0].Route)
+		assert.Equal(t, "3", vehicles[0].Route.ID)
+	})
+
+	t.Run("unmatched buses are kept without a trip", func(t *testing.T) {
+		vehicles := realtime.NewMatcher().Match(sched, []tools.BusLocation{
+			bus("3", "09:10", 54.1733, -4.4527, at(9, 30, 0)),
+			bus("5", "09:00", 54.1733, -4.4527, at(9, 30, 0)),
+			bus("3", "9am", 54.1733, -4.4527, at(9, 30, 0)),
+		})
+
+		require.Len(t, vehicles, 3)
+		for _, v := range vehicles {
+			assert.Nil(t, v.Trip, v.Location.BusID)
+			assert.Zero(t, v.Confidence, v.Location.BusID)
+		}
+		require.NotNil(t, vehicles[0].Route)
+		assert.Equal(t, "3", vehicles[0].Route.ID)
+		assert.Nil(t, vehicles[1].Route)
+	})
+}
@@ -0,0 +1,67 @@
+package realtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func TestPredictions(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+	tomorrow := func(hour, minute int) time.Time {
+		return time.Date(2026, 1, 14, hour, minute, 0, 0, schedule.Location)
+	}
+
+	t.Run("late bus makes up time while waiting at a stop", func(t *testing.T) {
+		vehicles := realtime.NewTracker(ss).Vehicles([]tools.BusLocation{
+			bus("3", "2330", 54.1467, -4.4794, at(23, 32, 0)),
+		})
+		require.NotNil(t, vehicles[0].Trip)
+		require.Equal(t, "T3N", vehicles[0].Trip.ID)
+
+		predictions := vehicles[0].Predictions()
+
+		require.Len(t, predictions, 3)
+		assert.Equal(t, "DGLS1", predictions[0].StopTime.Stop.ID)
+		assert.Equal(t, at(23, 32, 0), predictions[0].Departure)
+		assert.Equal(t, 2*time.Minute, predictions[0].DepartureDelay)
+		assert.Equal(t, at(23, 47, 0), predictions[1].Arrival)
+		assert.Equal(t, 2*time.Minute, predictions[1].ArrivalDelay)
+		assert.Equal(t, at(23, 47, 0), predictions[1].Departure)
+		assert.Equal(t, time.Minute, predictions[1].DepartureDelay)
+		assert.Equal(t, tomorrow(0, 16), predictions[2].Arrival)
+		assert.Equal(t, time.Minute, predictions[2].ArrivalDelay)
+	})
+
+	t.Run("early bus waits for its scheduled departure", func(t *testing.T) {
+		vehicles := realtime.NewTracker(ss).Vehicles([]tools.BusLocation{
+			bus("3", "2330", 54.1733, -4.4527, at(23, 43, 0)),
+		})
+		require.NotNil(t, vehicles[0].Trip)
+
+		predictions := vehicles[0].Predictions()
+
+		require.Len(t, predictions, 2)
+		assert.Equal(t, "ONCH", predictions[0].StopTime.Stop.ID)
+		assert.Equal(t, at(23, 43, 0), predictions[0].Arrival)
+		assert.Equal(t, -2*time.Minute, predictions[0].ArrivalDelay)
+		assert.Equal(t, at(23, 46, 0), predictions[0].Departure)
+		assert.Zero(t, predictions[0].DepartureDelay)
+		assert.Equal(t, tomorrow(0, 15), predictions[1].Arrival)
+		assert.Zero(t, predictions[1].ArrivalDelay)
+	})
+
+	t.Run("unmatched bus", func(t *testing.T) {
+		vehicles := realtime.NewTracker(ss).Vehicles([]tools.BusLocation{
+			bus("5", "2330", 54.1733, -4.4527, at(23, 43, 0)),
+		})
+
+		assert.Nil(t, vehicles[0].Predictions())
+	})
+}
@@ -0,0 +1,92 @@
+package realtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/realtime"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func TestTracker(t *testing.T) {
+	ss := fixtures.ScheduleStore(t, fixtures.GTFSFiles())
+
+	t.Run("delay along the trip's shape", func(t *testing.T) {
+		// halfway between Douglas (09:00) and Onchan (09:22:30)
+		b := bus("3", "0900", 54.1600, -4.46605, at(9, 20, 0))
+
+		vehicles := realtime.NewTracker(ss).Vehicles([]tools.BusLocation{b})
+
+		require.Len(t, vehicles, 1)
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T3", vehicles[0].Trip.ID)
+		assert.InDelta(t, 8*time.Minute+45*time.Second, vehicles[0].Delay, float64(5*time.Second))
+	})
+
+	t.Run("delay along the stops of a trip without a shape", func(t *testing.T) {
+		// halfway between Douglas (08:00) and Onchan (08:12)
+		b := bus("1", "0800", 54.1600, -4.46605, at(8, 4, 0))
+
+		vehicles := realtime.NewTracker(ss).Vehicles([]tools.BusLocation{b})
+
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T1", vehicles[0].Trip.ID)
+		assert.InDelta(t, -2*time.Minute, vehicles[0].Delay, float64(5*time.Second))
+	})
+
+	t.Run("delay far from the shape falls back to the nearest stop", func(t *testing.T) {
+		b := bus("3", "0900", 54.1733, -4.4680, at(9, 30, 0))
+
+		vehicles := realtime.NewTracker(ss).Vehicles([]tools.BusLocation{b})
+
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, 7*time.Minute+30*time.Second, vehicles[0].Delay)
+	})
+
+	t.Run("updated with new locations", func(t *testing.T) {
+		tracker := realtime.NewTracker(ss)
+		b := bus("3", "0900", 54.1467, -4.4794, at(9, 2, 0))
+		tracker.Update([]tools.BusLocation{b})
+
+		vehicles := tracker.Vehicles([]tools.BusLocation{b})
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, 2*time.Minute, vehicles[0].Delay)
+
+		b.Latitude, b.Longitude, b.Timestamp = 54.1733, -4.4527, at(9, 25, 0)
+		tracker.Update([]tools.BusLocation{b})
+
+		vehicles = tracker.Vehicles([]tools.BusLocation{b})
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, 2*time.Minute+30*time.Second, vehicles[0].Delay)
+	})
+
+	t.Run("fed by a location store", func(t *testing.T) {
+		tracker := realtime.NewTracker(ss)
+		store := tools.NewLocationStore(time.Minute)
+		store.OnUpdate(tracker.Update)
+
+		store.Update([]tools.BusLocation{bus("3", "0900", 54.1467, -4.4794, at(9, 2, 0))})
+
+		vehicles := tracker.Vehicles(store.Buses())
+		require.Len(t, vehicles, 1)
+		require.NotNil(t, vehicles[0].Trip)
+		assert.Equal(t, "T3", vehicles[0].Trip.ID)
+		assert.Equal(t, 2*time.Minute, vehicles[0].Delay)
+	})
+
+	t.Run("expired buses are left out", func(t *testing.T) {
+		tracker := realtime.NewTracker(ss)
+		tracker.Update([]tools.BusLocation{
+			bus("3", "0900", 54.1467, -4.4794, at(9, 2, 0)),
+			bus("1", "0800", 54.1467, -4.4794, at(8, 2, 0)),
+		})
+
+		vehicles := tracker.Vehicles([]tools.BusLocation{bus("1", "0800", 54.1467, -4.4794, at(8, 2, 0))})
+
+		require.Len(t, vehicles, 1)
+		assert.Equal(t, "T1", vehicles[0].Trip.ID)
+	})
+}
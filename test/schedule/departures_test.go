@@ -0,0 +1,91 @@
+package schedule_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+)
+
+func loadSchedule(t *testing.T, files map[string]string) *schedule.Schedule {
+	return fixtures.ScheduleStore(t, files).Current()
+}
+
+func departureTimes(departures []schedule.Departure) []string {
+	times := make([]string, len(departures))
+	for i, departure := range departures {
+		times[i] = departure.StopTime.Trip.ID + " " + departure.Time.In(schedule.Location).Format(time.RFC3339)
+	}
+	return times
+}
+
+func TestDepartures(t *testing.T) {
+	sched := loadSchedule(t, fixtures.GTFSFiles())
+
+	t.Run("upcoming departures across days", func(t *testing.T) {
+		// Onchan is the last stop of T1, so it never departs from there
+		from := time.Date(2026, 1, 13, 8, 30, 0, 0, schedule.Location)
+
+		departures := sched.Departures(sched.Stops["ONCH"], from, 4)
+
+		assert.Equal(t, []string{
+			"T3 2026-01-13T09:22:30Z",
+			"T3N 2026-01-13T23:46:00Z",
+			"T3 2026-01-14T09:22:30Z",
+			"T3N 2026-01-14T23:46:00Z",
+		}, departureTimes(departures))
+		assert.Equal(t, "Ramsey", departures[0].Headsign())
+		assert.Equal(t, "20260113", departures[1].ServiceDay.Format("20060102"))
+	})
+
+	t.Run("trips running past midnight", func(t *testing.T) {
+		files := fixtures.GTFSFiles()
+		files["stop_times.txt"] = strings.Replace(files["stop_times.txt"], "T3N,23:45:00,23:46:00,ONCH,2", "T3N,24:05:00,24:05:00,ONCH,2", 1)
+		sched := loadSchedule(t, files)
+		from := time.Date(2026, 1, 14, 0, 0, 0, 0, schedule.Location)
+
+		departures := sched.Departures(sched.Stops["ONCH"], from, 1)
+
+		assert.Equal(t, []string{"T3N 2026-01-14T00:05:00Z"}, departureTimes(departures))
+		assert.Equal(t, "20260113", departures[0].ServiceDay.Format("20060102"))
+	})
+
+	t.Run("removed service dates", func(t *testing.T) {
+		from := time.Date(2025, 12, 25, 0, 0, 0, 0, schedule.Location)
+
+		departures := sched.Departures(sched.Stops["DGLS1"], from, 1)
+
+		assert.Equal(t, []string{"T1 2025-12-26T08:00:00Z"}, departureTimes(departures))
+	})
+
+	t.Run("daylight saving time", func(t *testing.T) {
+		from := time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)
+
+		departures := sched.Departures(sched.Stops["DGLS1"], from, 1)
+
+		assert.Equal(t, []string{"T1 2026-03-29T08:00:00+01:00"}, departureTimes(departures))
+	})
+
+	t.Run("stations include their stops", func(t *testing.T) {
+		from := time.Date(2026, 1, 13, 8, 30, 0, 0, schedule.Location)
+
+		departures := sched.Departures(sched.Stops["DGLS"], from, 2)
+
+		assert.Equal(t, []string{"T3 2026-01-13T09:00:00Z", "T3N 2026-01-13T23:30:00Z"}, departureTimes(departures))
+	})
+
+	t.Run("last stops have no departures", func(t *testing.T) {
+		from := time.Date(2026, 1, 13, 0, 0, 0, 0, schedule.Location)
+
+		assert.Empty(t, sched.Departures(sched.Stops["RMSY"], from, 10))
+	})
+
+	t.Run("no service after the calendar ends", func(t *testing.T) {
+		from := time.Date(2031, 1, 1, 0, 0, 0, 0, schedule.Location)
+
+		assert.Empty(t, sched.Departures(sched.Stops["ONCH"], from, 10))
+	})
+}
@@ -0,0 +1,79 @@
+package schedule_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/schedule"
+	"github.com/transitIOM/projectMercury/internal/tools"
+	"github.com/transitIOM/projectMercury/test/fixtures"
+	"github.com/transitIOM/projectMercury/test/mocks"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("loads the latest schedule", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestGTFSVersionID").Return("v1", nil)
+		mockSM.On("GetLatestSchedule").Return(fixtures.GTFSZip(), "v1", nil).Once()
+		store := schedule.NewStore(mockSM)
+		assert.Nil(t, store.Current())
+
+		require.NoError(t, store.Load())
+
+		current := store.Current()
+		require.NotNil(t, current)
+		assert.Equal(t, "v1", current.VersionID)
+		assert.Len(t, current.Routes, 2)
+
+		// loading the same version again does not download it
+		require.NoError(t, store.Load())
+		assert.Same(t, current, store.Current())
+		mockSM.AssertExpectations(t)
+	})
+
+	t.Run("keeps the current schedule if the new one fails to parse", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestGTFSVersionID").Return("v1", nil).Once()
+		mockSM.On("GetLatestSchedule").Return(fixtures.GTFSZip(), "v1", nil).Once()
+		store := schedule.NewStore(mockSM)
+		require.NoError(t, store.Load())
+
+		mockSM.On("GetLatestGTFSVersionID").Return("v2", nil).Once()
+		mockSM.On("GetLatestSchedule").Return([]byte("fake-zip-content"), "v2", nil).Once()
+
+		assert.Error(t, store.Load())
+		assert.Equal(t, "v1", store.Current().VersionID)
+	})
+
+	t.Run("skips a version that failed to parse until another is published", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestGTFSVersionID").Return("v1", nil).Twice()
+		mockSM.On("GetLatestSchedule").Return([]byte("fake-zip-content"), "v1", nil).Once()
+		store := schedule.NewStore(mockSM)
+
+		assert.Error(t, store.Load())
+		// the broken version is not downloaded again
+		assert.NoError(t, store.Load())
+		assert.Nil(t, store.Current())
+
+		mockSM.On("GetLatestGTFSVersionID").Return("v2", nil).Once()
+		mockSM.On("GetLatestSchedule").Return(fixtures.GTFSZip(), "v2", nil).Once()
+
+		require.NoError(t, store.Load())
+		assert.Equal(t, "v2", store.Current().VersionID)
+		mockSM.AssertExpectations(t)
+	})
+
+	t.Run("no schedule uploaded", func(t *testing.T) {
+		mockSM := new(mocks.ObjectStorageManagerMock)
+		mockSM.On("GetLatestGTFSVersionID").Return("", tools.NoGTFSScheduleFound)
+		store := schedule.NewStore(mockSM)
+
+		err := store.Load()
+
+		assert.True(t, errors.Is(err, tools.NoGTFSScheduleFound))
+		assert.Nil(t, store.Current())
+	})
+}
@@ -0,0 +1,265 @@
+package tools_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+func newFilesystemClient(t *testing.T) *tools.FilesystemClient {
+	publicURL, _ := url.Parse("http://localhost:8090/storage")
+	client, err := tools.NewFilesystemClient(t.TempDir(), publicURL, []byte("test-key"))
+	require.NoError(t, err)
+	return client
+}
+
+func putString(t *testing.T, client *tools.FilesystemClient, bucket, object, content string) tools.UploadInfo {
+	info, err := client.PutObject(context.Background(), bucket, object, strings.NewReader(content), int64(len(content)), tools.PutObjectOptions{ContentType: "text/plain"})
+	require.NoError(t, err)
+	return info
+}
+
+func readObject(t *testing.T, client *tools.FilesystemClient, bucket, object string) string {
+	r, err := client.GetObject(context.Background(), bucket, object)
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFilesystemClientBuckets(t *testing.T) {
+	ctx := context.Background()
+	client := newFilesystemClient(t)
+
+	exists, err := client.BucketExists(ctx, "gtfs")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, client.MakeBucket(ctx, "gtfs", ""))
+	require.NoError(t, client.MakeBucket(ctx, "messages", ""))
+	assert.Error(t, client.MakeBucket(ctx, "gtfs", ""))
+	assert.Error(t, client.MakeBucket(ctx, "../escape", ""))
+
+	exists, err = client.BucketExists(ctx, "gtfs")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	buckets, err := client.ListBuckets(ctx)
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+	assert.Equal(t, "gtfs", buckets[0].Name)
+	assert.Equal(t, "messages", buckets[1].Name)
+}
+
+func TestFilesystemClientVersioning(t *testing.T) {
+	ctx := context.Background()
+	client := newFilesystemClient(t)
+	require.NoError(t, client.MakeBucket(ctx, "gtfs", ""))
+
+	_, err := client.GetObject(ctx, "gtfs", "GTFSSchedule.zip")
+	assert.ErrorIs(t, err, tools.KeyNotFound)
+	_, err = client.GetObject(ctx, "missing", "GTFSSchedule.zip")
+	assert.ErrorIs(t, err, tools.BucketNotFound)
+
+	// without versioning the null version is overwritten
+	first := putString(t, client, "gtfs", "GTFSSchedule.zip", "first")
+	second := putString(t, client, "gtfs", "GTFSSchedule.zip", "second")
+	assert.Equal(t, "null", first.VersionID)
+	assert.Equal(t, "null", second.VersionID)
+
+	require.NoError(t, client.SetBucketVersioning(ctx, "gtfs", true))
+	third := putString(t, client, "gtfs", "GTFSSchedule.zip", "third")
+	fourth := putString(t, client, "gtfs", "GTFSSchedule.zip", "fourth")
+	assert.NotEqual(t, third.VersionID, fourth.VersionID)
+	assert.Equal(t, "fourth", readObject(t, client, "gtfs", "GTFSSchedule.zip"))
+
+	r, err := client.GetObjectVersion(ctx, "gtfs", "GTFSSchedule.zip", third.VersionID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "third", string(data))
+	_, err = client.GetObjectVersion(ctx, "gtfs", "GTFSSchedule.zip", "missing")
+	assert.ErrorIs(t, err, tools.KeyNotFound)
+
+	attrs, err := client.GetObjectAttributes(ctx, "gtfs", "GTFSSchedule.zip")
+	require.NoError(t, err)
+	assert.Equal(t, fourth.VersionID, attrs.VersionID)
+	assert.Equal(t, fourth.ETag, attrs.ETag)
+
+	info, err := client.StatObject(ctx, "gtfs", "GTFSSchedule.zip")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("fourth")), info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+	assert.Equal(t, fourth.VersionID, info.VersionID)
+
+	versions, err := client.ListObjectVersions(ctx, "gtfs", "GTFSSchedule.zip")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, fourth.VersionID, versions[0].VersionID)
+	assert.Equal(t, third.VersionID, versions[1].VersionID)
+	assert.Equal(t, "null", versions[2].VersionID)
+
+	_, err = client.PutObject(ctx, "gtfs", "short.txt", strings.NewReader("abc"), 10, tools.PutObjectOptions{})
+	assert.Error(t, err)
+
+	// conditional writes
+	_, err = client.PutObject(ctx, "gtfs", "GTFSSchedule.zip", strings.NewReader("x"), 1, tools.PutObjectOptions{MatchETag: third.ETag})
+	assert.ErrorIs(t, err, tools.PreconditionFailed)
+	_, err = client.PutObject(ctx, "gtfs", "GTFSSchedule.zip", strings.NewReader("x"), 1, tools.PutObjectOptions{NoneMatchETag: "*"})
+	assert.ErrorIs(t, err, tools.PreconditionFailed)
+	_, err = client.PutObject(ctx, "gtfs", "GTFSSchedule.zip", strings.NewReader("x"), 1, tools.PutObjectOptions{MatchETag: fourth.ETag})
+	assert.NoError(t, err)
+	_, err = client.PutObject(ctx, "gtfs", "new.zip", strings.NewReader("x"), 1, tools.PutObjectOptions{NoneMatchETag: "*"})
+	assert.NoError(t, err)
+}
+
+func TestFilesystemClientDotObjectNames(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	publicURL, _ := url.Parse("http://localhost:8090/storage")
+	client, err := tools.NewFilesystemClient(root, publicURL, []byte("test-key"))
+	require.NoError(t, err)
+	require.NoError(t, client.MakeBucket(ctx, "gtfs", ""))
+
+	putString(t, client, "gtfs", "..", "parent")
+	putString(t, client, "gtfs", ".", "self")
+	putString(t, client, "gtfs", "%2E", "escaped")
+
+	assert.Equal(t, "parent", readObject(t, client, "gtfs", ".."))
+	assert.Equal(t, "self", readObject(t, client, "gtfs", "."))
+	assert.Equal(t, "escaped", readObject(t, client, "gtfs", "%2E"))
+
+	// every object is kept inside the objects directory of its bucket
+	entries, err := os.ReadDir(filepath.Join(root, "gtfs"))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"bucket.json", "objects"}, names)
+	entries, err = os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFilesystemClientPresignedURL(t *testing.T) {
+	ctx := context.Background()
+	client := newFilesystemClient(t)
+	require.NoError(t, client.MakeBucket(ctx, "gtfs", ""))
+	require.NoError(t, client.SetBucketVersioning(ctx, "gtfs", true))
+	putString(t, client, "gtfs", "GTFSSchedule.zip", "schedule")
+
+	reqParams := url.Values{}
+	reqParams.Set("response-content-disposition", "attachment; filename=GTFSSchedule.zip")
+	reqParams.Set("response-content-type", "application/zip")
+	presigned, err := client.PresignedGetObject(ctx, "gtfs", "GTFSSchedule.zip", time.Minute, reqParams)
+	require.NoError(t, err)
+	assert.Equal(t, "/storage/gtfs/GTFSSchedule.zip", presigned.Path)
+
+	// a newer version must not change what an outstanding URL downloads
+	putString(t, client, "gtfs", "GTFSSchedule.zip", "replacement")
+
+	rr := httptest.NewRecorder()
+	client.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, presigned.RequestURI(), nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "schedule", rr.Body.String())
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=GTFSSchedule.zip", rr.Header().Get("Content-Disposition"))
+
+	tampered := *presigned
+	query := tampered.Query()
+	query.Set("response-content-type", "text/html")
+	tampered.RawQuery = query.Encode()
+	rr = httptest.NewRecorder()
+	client.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tampered.RequestURI(), nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	expired, err := client.PresignedGetObject(ctx, "gtfs", "GTFSSchedule.zip", -time.Minute, nil)
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	client.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, expired.RequestURI(), nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	_, err = client.PresignedGetObject(ctx, "gtfs", "missing.zip", time.Minute, nil)
+	assert.ErrorIs(t, err, tools.KeyNotFound)
+}
+
+func TestFilesystemClientWithStorageManager(t *testing.T) {
+	client := newFilesystemClient(t)
+	sm := tools.NewMinIOStorageManager(client, context.Background())
+	require.NoError(t, sm.Initialize())
+
+	_, err := sm.GetLatestGTFSVersionID()
+	assert.ErrorIs(t, err, tools.NoGTFSScheduleFound)
+	_, err = sm.ListGTFSVersions()
+	assert.ErrorIs(t, err, tools.NoGTFSScheduleFound)
+
+	versionID, err := sm.PutSchedule(strings.NewReader("zip"), 3, "")
+	require.NoError(t, err)
+	latest, err := sm.GetLatestGTFSVersionID()
+	require.NoError(t, err)
+	assert.Equal(t, versionID, latest)
+
+	_, err = sm.PutSchedule(strings.NewReader("stale"), 5, "not-the-latest")
+	assert.ErrorIs(t, err, tools.GTFSVersionConflict)
+	_, err = sm.PutSchedule(strings.NewReader("zip2"), 4, versionID)
+	require.NoError(t, err)
+
+	versions, err := sm.ListGTFSVersions()
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, versionID, versions[1].VersionID)
+
+	downloadURL, err := sm.GetVersionURL(versionID)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	client.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, downloadURL.RequestURI(), nil))
+	assert.Equal(t, "zip", rr.Body.String())
+
+	_, err = sm.GetVersionURL("missing")
+	assert.ErrorIs(t, err, tools.GTFSVersionNotFound)
+
+	schedule, err := sm.GetScheduleVersion(versionID)
+	require.NoError(t, err)
+	assert.Equal(t, "zip", string(schedule))
+	_, err = sm.GetScheduleVersion("missing")
+	assert.ErrorIs(t, err, tools.GTFSVersionNotFound)
+
+	rolledBack, err := sm.RollbackSchedule(versionID)
+	require.NoError(t, err)
+	assert.NotEqual(t, versionID, rolledBack)
+	assert.Equal(t, "zip", readObject(t, client, "gtfs", "GTFSSchedule.zip"))
+	schedule, latest, err = sm.GetLatestSchedule()
+	require.NoError(t, err)
+	assert.Equal(t, "zip", string(schedule))
+	assert.Equal(t, rolledBack, latest)
+	versions, err = sm.ListGTFSVersions()
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+
+	_, err = sm.RollbackSchedule("missing")
+	assert.ErrorIs(t, err, tools.GTFSVersionNotFound)
+
+	_, err = sm.AppendMessage(bytes.NewBufferString("{\"message\":\"one\"}\n"))
+	require.NoError(t, err)
+	_, err = sm.AppendMessage(bytes.NewBufferString("{\"message\":\"two\"}\n"))
+	require.NoError(t, err)
+
+	messageLog, err := sm.GetLatestLog()
+	require.NoError(t, err)
+	assert.Equal(t, "{\"message\":\"one\"}\n{\"message\":\"two\"}\n", messageLog.String())
+}
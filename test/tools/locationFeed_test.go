@@ -0,0 +1,167 @@
+package tools_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+func kinds(events []tools.LocationEvent) []tools.LocationChangeKind {
+	kinds := make([]tools.LocationChangeKind, len(events))
+	for i, event := range events {
+		kinds[i] = event.Kind
+	}
+	return kinds
+}
+
+func receive(t *testing.T, sub *tools.LocationSubscription, n int) []tools.LocationEvent {
+	t.Helper()
+	var events []tools.LocationEvent
+	for range n {
+		select {
+		case event, ok := <-sub.Events():
+			require.True(t, ok, "subscription was closed")
+			events = append(events, event)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d events", len(events), n)
+		}
+	}
+	return events
+}
+
+func TestLocationStoreChanges(t *testing.T) {
+	store := tools.NewLocationStore(20 * time.Millisecond)
+	var mutex sync.Mutex
+	var changes []tools.LocationChange
+	changed := make(chan struct{}, 10)
+	store.OnChange(func(c []tools.LocationChange) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		changes = append(changes, c...)
+		changed <- struct{}{}
+	})
+
+	store.Update([]tools.BusLocation{{BusID: "1", Latitude: 54.1}, {BusID: "2", Latitude: 54.2}})
+	// bus 1 moved, bus 2 reported again at the same place
+	store.Update([]tools.BusLocation{{BusID: "1", Latitude: 54.3}, {BusID: "2", Latitude: 54.2}})
+
+	mutex.Lock()
+	require.Len(t, changes, 3)
+	assert.Equal(t, tools.LocationChange{Kind: tools.LocationAdded, Location: tools.BusLocation{BusID: "1", Latitude: 54.1}}, changes[0])
+	assert.Equal(t, tools.LocationAdded, changes[1].Kind)
+	assert.Equal(t, tools.LocationChange{Kind: tools.LocationUpdated, Location: tools.BusLocation{BusID: "1", Latitude: 54.3}}, changes[2])
+	mutex.Unlock()
+
+	// one notification for each update, then one for each bus expiring
+	for range 4 {
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Fatal("buses did not expire")
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, changes, 5)
+	assert.Equal(t, tools.LocationExpired, changes[3].Kind)
+	assert.Equal(t, tools.LocationExpired, changes[4].Kind)
+	assert.ElementsMatch(t, []string{"1", "2"}, []string{changes[3].Location.BusID, changes[4].Location.BusID})
+	assert.Empty(t, store.Buses())
+}
+
+func TestLocationFeed(t *testing.T) {
+	added := func(busID string, lat float64) tools.LocationChange {
+		return tools.LocationChange{Kind: tools.LocationAdded, Location: tools.BusLocation{BusID: busID, Latitude: lat}}
+	}
+	updated := func(busID string, lat float64) tools.LocationChange {
+		return tools.LocationChange{Kind: tools.LocationUpdated, Location: tools.BusLocation{BusID: busID, Latitude: lat}}
+	}
+	expired := func(busID string) tools.LocationChange {
+		return tools.LocationChange{Kind: tools.LocationExpired, Location: tools.BusLocation{BusID: busID}}
+	}
+
+	t.Run("starts from the current buses", func(t *testing.T) {
+		feed := tools.NewLocationFeed(10)
+		feed.Publish([]tools.LocationChange{added("1", 54.1), added("2", 54.2), updated("1", 54.3), expired("2")})
+
+		sub := feed.Subscribe("")
+		defer sub.Close()
+
+		assert.False(t, sub.Resumed)
+		require.Len(t, sub.Backlog, 1)
+		assert.Equal(t, added("1", 54.3), sub.Backlog[0].LocationChange)
+		assert.Equal(t, []string{"1"}, sub.Tracked)
+
+		feed.Publish([]tools.LocationChange{added("3", 54.4)})
+		events := receive(t, sub, 1)
+		assert.Equal(t, added("3", 54.4), events[0].LocationChange)
+		assert.NotEqual(t, sub.Backlog[0].ID, events[0].ID)
+	})
+
+	t.Run("resumes after the last event", func(t *testing.T) {
+		feed := tools.NewLocationFeed(10)
+		first := feed.Subscribe("")
+		defer first.Close()
+		feed.Publish([]tools.LocationChange{added("1", 54.1), added("2", 54.2)})
+		seen := receive(t, first, 2)
+
+		feed.Publish([]tools.LocationChange{updated("1", 54.3), expired("2")})
+
+		sub := feed.Subscribe(seen[1].ID)
+		defer sub.Close()
+		assert.True(t, sub.Resumed)
+		assert.Equal(t, []tools.LocationChangeKind{tools.LocationUpdated, tools.LocationExpired}, kinds(sub.Backlog))
+		assert.Equal(t, []string{"1", "2"}, sub.Tracked)
+
+		// resuming from the start of the feed
+		sub = feed.Subscribe(seen[0].ID[:len(seen[0].ID)-1] + "0")
+		defer sub.Close()
+		assert.True(t, sub.Resumed)
+		assert.Len(t, sub.Backlog, 4)
+		assert.Empty(t, sub.Tracked)
+	})
+
+	t.Run("starts over from unknown events", func(t *testing.T) {
+		feed := tools.NewLocationFeed(2)
+		first := feed.Subscribe("")
+		defer first.Close()
+		feed.Publish([]tools.LocationChange{added("1", 54.1)})
+		seen := receive(t, first, 1)
+		feed.Publish([]tools.LocationChange{updated("1", 54.2), updated("1", 54.25), updated("1", 54.3)})
+
+		for _, lastEventID := range []string{
+			// no longer kept
+			seen[0].ID,
+			// from a feed before a restart
+			"abc-1",
+			// not yet published
+			seen[0].ID + "0",
+			"garbage",
+		} {
+			sub := feed.Subscribe(lastEventID)
+			assert.False(t, sub.Resumed, lastEventID)
+			require.Len(t, sub.Backlog, 1, lastEventID)
+			assert.Equal(t, added("1", 54.3), sub.Backlog[0].LocationChange)
+			sub.Close()
+		}
+	})
+
+	t.Run("drops subscribers that fall behind", func(t *testing.T) {
+		feed := tools.NewLocationFeed(10)
+		sub := feed.Subscribe("")
+		for i := range 1000 {
+			feed.Publish([]tools.LocationChange{updated("1", float64(i))})
+		}
+
+		received := 0
+		for range sub.Events() {
+			received++
+		}
+		assert.Less(t, received, 1000)
+		sub.Close()
+	})
+}
@@ -0,0 +1,136 @@
+package tools_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+// fakeSource records a fixed set of locations, then waits to be cancelled or fails with err.
+type fakeSource struct {
+	name      string
+	locations []tools.BusLocation
+	err       error
+}
+
+func (f fakeSource) Name() string {
+	return f.name
+}
+
+func (f fakeSource) Run(ctx context.Context, store *tools.LocationStore) error {
+	store.Update(f.locations)
+	if f.err != nil {
+		return f.err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func busIDs(locations []tools.BusLocation) []string {
+	ids := make([]string, len(locations))
+	for i, loc := range locations {
+		ids[i] = loc.BusID
+	}
+	return ids
+}
+
+func TestLocationStore(t *testing.T) {
+	t.Run("latest location per bus", func(t *testing.T) {
+		store := tools.NewLocationStore(time.Minute)
+		store.Update([]tools.BusLocation{{BusID: "1", Latitude: 54.1}, {BusID: "2", Latitude: 54.2}})
+		store.Update([]tools.BusLocation{{BusID: "1", Latitude: 54.3}})
+
+		buses := store.Buses()
+		require.Len(t, buses, 2)
+		assert.ElementsMatch(t, []string{"1", "2"}, busIDs(buses))
+		for _, bus := range buses {
+			if bus.BusID == "1" {
+				assert.Equal(t, 54.3, bus.Latitude)
+			}
+		}
+	})
+
+	t.Run("buses expire", func(t *testing.T) {
+		store := tools.NewLocationStore(10 * time.Millisecond)
+		store.Update([]tools.BusLocation{{BusID: "1"}})
+		require.Len(t, store.Buses(), 1)
+
+		assert.Eventually(t, func() bool { return len(store.Buses()) == 0 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("update callback", func(t *testing.T) {
+		store := tools.NewLocationStore(time.Minute)
+		var received []tools.BusLocation
+		store.OnUpdate(func(locations []tools.BusLocation) { received = locations })
+
+		store.Update(nil)
+		assert.Nil(t, received)
+
+		store.Update([]tools.BusLocation{{BusID: "1"}})
+		assert.Equal(t, []string{"1"}, busIDs(received))
+	})
+
+	t.Run("several update callbacks", func(t *testing.T) {
+		store := tools.NewLocationStore(time.Minute)
+		var first, second []tools.BusLocation
+		store.OnUpdate(func(locations []tools.BusLocation) { first = locations })
+		store.OnUpdate(func(locations []tools.BusLocation) { second = locations })
+
+		store.Update([]tools.BusLocation{{BusID: "1"}})
+		assert.Equal(t, []string{"1"}, busIDs(first))
+		assert.Equal(t, []string{"1"}, busIDs(second))
+	})
+}
+
+func TestRunLocationSources(t *testing.T) {
+	store := tools.NewLocationStore(time.Minute)
+	var mutex sync.Mutex
+	var updates int
+	store.OnUpdate(func([]tools.BusLocation) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		updates++
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tools.RunLocationSources(ctx, store,
+			fakeSource{name: "a", locations: []tools.BusLocation{{BusID: "1"}}},
+			fakeSource{name: "b", locations: []tools.BusLocation{{BusID: "2"}}},
+			fakeSource{name: "failing", locations: []tools.BusLocation{{BusID: "3"}}, err: errors.New("disconnected")},
+		)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(store.Buses()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, busIDs(store.Buses()))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sources did not stop after the context was cancelled")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 3, updates)
+}
+
+func TestParseFrames(t *testing.T) {
+	frame := `{"type":1,"target":"updateLocations","arguments":[{"locations":["D1|B1|T1|R1|Dir1|54.1|-4.5|2026-01-11T03:55:00Z|1|E1"]}]}`
+	ping := `{"type":6}`
+
+	locations, err := tools.ParseFrames(ping + "\x1e" + frame + "\x1e" + frame + "\x1e")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"B1", "B1"}, busIDs(locations))
+
+	_, err = tools.ParseFrames(ping + "\x1e")
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package tools_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+func TestEffectiveMessages(t *testing.T) {
+	first := tools.MessageLog{ID: "a", Timestamp: "2026-01-13T08:00:00Z", Message: "Route 3 divreted"}
+	second := tools.MessageLog{ID: "b", Timestamp: "2026-01-13T09:00:00Z", Message: "Route 1 delayed"}
+	legacy := tools.MessageLog{Timestamp: "2025-12-01T08:00:00Z", Message: "Hello"}
+
+	t.Run("revisions and tombstones", func(t *testing.T) {
+		revision := tools.NewRevision(first, tools.MessageLog{Message: "Route 3 diverted"})
+
+		messages := tools.EffectiveMessages([]tools.MessageLog{
+			legacy,
+			first,
+			second,
+			revision,
+			tools.NewTombstone(second),
+			tools.NewRevision(second, tools.MessageLog{Message: "Route 1 on time"}),
+		})
+
+		assert.Equal(t, []tools.MessageLog{legacy, revision}, messages)
+		assert.Equal(t, "a", messages[1].ID)
+		assert.Equal(t, first.Timestamp, messages[1].Timestamp)
+	})
+
+	t.Run("messages from older releases", func(t *testing.T) {
+		revision := tools.NewRevision(legacy, tools.MessageLog{Message: "Hello again"})
+
+		messages := tools.EffectiveMessages([]tools.MessageLog{legacy, revision})
+
+		assert.Equal(t, legacy.Timestamp, revision.ID)
+		assert.Equal(t, []tools.MessageLog{revision}, messages)
+	})
+
+	t.Run("revisions of messages outside the entries", func(t *testing.T) {
+		messages := tools.EffectiveMessages([]tools.MessageLog{
+			tools.NewRevision(first, tools.MessageLog{Message: "Route 3 diverted"}),
+			second,
+		})
+
+		assert.Equal(t, []tools.MessageLog{second}, messages)
+	})
+}
+
+func TestMessageExpired(t *testing.T) {
+	now := time.Date(2026, 1, 13, 12, 0, 0, 0, time.UTC)
+
+	assert.False(t, tools.MessageLog{}.Expired(now))
+	assert.False(t, tools.MessageLog{ActiveUntil: "2026-01-13T12:00:01Z"}.Expired(now))
+	assert.True(t, tools.MessageLog{ActiveUntil: "2026-01-13T12:00:00Z"}.Expired(now))
+}
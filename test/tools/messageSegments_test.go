@@ -0,0 +1,112 @@
+package tools_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+func TestSegmentedMessageLog(t *testing.T) {
+	client := newFilesystemClient(t)
+	sm := tools.NewMinIOStorageManager(client, context.Background())
+	require.NoError(t, sm.Initialize())
+
+	_, err := sm.GetLatestLogTail(3)
+	assert.ErrorIs(t, err, tools.NoMessageLogFound)
+
+	// fill the first segment and spill two messages into a second one
+	var lastVersionID string
+	for i := 1; i <= 502; i++ {
+		versionID, err := sm.AppendMessage(bytes.NewBufferString(fmt.Sprintf("{\"message\":\"%d\"}\n", i)))
+		require.NoError(t, err)
+		assert.NotEqual(t, lastVersionID, versionID)
+		lastVersionID = versionID
+	}
+
+	latest, err := sm.GetLatestMessageVersionID()
+	require.NoError(t, err)
+	assert.Equal(t, lastVersionID, latest)
+
+	full, err := sm.GetLatestLog()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(full.String(), "\n"), "\n")
+	require.Len(t, lines, 502)
+	assert.Equal(t, `{"message":"1"}`, lines[0])
+	assert.Equal(t, `{"message":"502"}`, lines[501])
+
+	// only the newest segment is needed for the last two messages
+	tail, err := sm.GetLatestLogTail(2)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"message\":\"501\"}\n{\"message\":\"502\"}\n", tail.String())
+
+	// more messages than the newest segment holds pulls in the previous one
+	tail, err = sm.GetLatestLogTail(3)
+	require.NoError(t, err)
+	assert.Equal(t, 502, strings.Count(tail.String(), "\n"))
+}
+
+func TestSegmentedMessageLogMigration(t *testing.T) {
+	ctx := context.Background()
+	client := newFilesystemClient(t)
+	require.NoError(t, client.MakeBucket(ctx, "messages", ""))
+	legacy := "{\"message\":\"old 1\"}\n{\"message\":\"old 2\"}"
+	putString(t, client, "messages", "messages.jsonl", legacy)
+
+	sm := tools.NewMinIOStorageManager(client, ctx)
+	require.NoError(t, sm.Initialize())
+
+	_, err := sm.AppendMessage(bytes.NewBufferString("{\"message\":\"new\"}\n"))
+	require.NoError(t, err)
+
+	messageLog, err := sm.GetLatestLog()
+	require.NoError(t, err)
+	assert.Equal(t, legacy+"\n{\"message\":\"new\"}\n", messageLog.String())
+
+	// initializing again must not import the legacy log a second time
+	require.NoError(t, sm.Initialize())
+	messageLog, err = sm.GetLatestLog()
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(messageLog.String(), "\n"))
+}
+
+func TestSegmentedMessageLogConcurrentReplicas(t *testing.T) {
+	client := newFilesystemClient(t)
+	// two managers sharing one storage behave like two Mercury replicas
+	replicas := []*tools.MinIOStorageManager{
+		tools.NewMinIOStorageManager(client, context.Background()),
+		tools.NewMinIOStorageManager(client, context.Background()),
+	}
+	for _, sm := range replicas {
+		require.NoError(t, sm.Initialize())
+	}
+
+	const perReplica = 10
+	var wg sync.WaitGroup
+	for r, sm := range replicas {
+		wg.Add(1)
+		go func(r int, sm *tools.MinIOStorageManager) {
+			defer wg.Done()
+			for i := 0; i < perReplica; i++ {
+				_, err := sm.AppendMessage(bytes.NewBufferString(fmt.Sprintf("{\"message\":\"%d-%d\"}\n", r, i)))
+				assert.NoError(t, err)
+			}
+		}(r, sm)
+	}
+	wg.Wait()
+
+	messageLog, err := replicas[0].GetLatestLog()
+	require.NoError(t, err)
+	for r := range replicas {
+		for i := 0; i < perReplica; i++ {
+			assert.Contains(t, messageLog.String(), fmt.Sprintf("{\"message\":\"%d-%d\"}\n", r, i))
+		}
+	}
+	assert.Equal(t, 2*perReplica, strings.Count(messageLog.String(), "\n"))
+}
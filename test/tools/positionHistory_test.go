@@ -0,0 +1,39 @@
+package tools_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+func TestPositionHistory(t *testing.T) {
+	ctx := context.Background()
+	client := newFilesystemClient(t)
+	sm := tools.NewMinIOStorageManager(client, ctx)
+	require.NoError(t, sm.Initialize())
+
+	hour := time.Date(2026, 1, 13, 8, 0, 0, 0, time.UTC)
+	_, err := sm.GetPositions("123", hour)
+	assert.ErrorIs(t, err, tools.NoPositionsFound)
+
+	require.NoError(t, sm.AppendPositions("123", hour, bytes.NewBufferString(`{"t":"2026-01-13T08:00:00Z"}`)))
+	require.NoError(t, sm.AppendPositions("123", hour, bytes.NewBufferString("{\"t\":\"2026-01-13T08:00:10Z\"}\n")))
+	require.NoError(t, sm.AppendPositions("456", hour, bytes.NewBufferString("{\"t\":\"2026-01-13T08:00:05Z\"}\n")))
+
+	positions, err := sm.GetPositions("123", hour)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"t\":\"2026-01-13T08:00:00Z\"}\n{\"t\":\"2026-01-13T08:00:10Z\"}\n", positions.String())
+
+	_, err = sm.GetPositions("123", hour.Add(time.Hour))
+	assert.ErrorIs(t, err, tools.NoPositionsFound)
+
+	// partitions are rewritten on every append, so their versions are not kept
+	versions, err := client.ListObjectVersions(ctx, "history", "positions/2026-01-13/08/123.jsonl")
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
@@ -0,0 +1,235 @@
+package tools_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+func readRecording(t *testing.T, name string) []tools.Frame {
+	t.Helper()
+	file, err := os.Open(name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var frames []tools.Frame
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var frame tools.Frame
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &frame))
+		frames = append(frames, frame)
+	}
+	require.NoError(t, scanner.Err())
+	return frames
+}
+
+func recordedFrame(at time.Time, busID string) tools.Frame {
+	data := strings.Replace(strings.TrimSuffix(locationFrame, "\x1e"), "B1", busID, 1)
+	return tools.Frame{ReceivedAt: at, Source: "test", Data: data}
+}
+
+func TestFileRecorder(t *testing.T) {
+	t.Run("rotates files", func(t *testing.T) {
+		dir := t.TempDir()
+		// smaller than a frame, so every frame starts a new file
+		recorder, err := tools.NewFileRecorder(dir, 100)
+		require.NoError(t, err)
+
+		start := time.Date(2026, 1, 13, 8, 0, 0, 0, time.UTC)
+		for i := range 3 {
+			recorder.Record(recordedFrame(start.Add(time.Duration(i)*time.Second), "B1"))
+		}
+		require.NoError(t, recorder.Close())
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+		require.NoError(t, err)
+		require.Len(t, files, 3)
+		assert.Equal(t, "frames-20260113T080000.000000000Z.jsonl", filepath.Base(files[0]))
+
+		frames := readRecording(t, files[1])
+		require.Len(t, frames, 1)
+		assert.Equal(t, start.Add(time.Second), frames[0].ReceivedAt)
+		assert.Equal(t, "test", frames[0].Source)
+		assert.Equal(t, recordedFrame(start, "B1").Data, frames[0].Data)
+	})
+
+	t.Run("deletes the oldest files", func(t *testing.T) {
+		dir := t.TempDir()
+		other := filepath.Join(dir, "notes.jsonl")
+		require.NoError(t, os.WriteFile(other, nil, 0o644))
+		recorder, err := tools.NewFileRecorder(dir, 100)
+		require.NoError(t, err)
+		recorder.MaxFiles = 2
+
+		start := time.Date(2026, 1, 13, 8, 0, 0, 0, time.UTC)
+		for i := range 4 {
+			recorder.Record(recordedFrame(start.Add(time.Duration(i)*time.Second), "B1"))
+		}
+		require.NoError(t, recorder.Close())
+
+		files, err := filepath.Glob(filepath.Join(dir, "frames-*.jsonl"))
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		assert.Equal(t, "frames-20260113T080002.000000000Z.jsonl", filepath.Base(files[0]))
+		assert.Equal(t, "frames-20260113T080003.000000000Z.jsonl", filepath.Base(files[1]))
+		assert.FileExists(t, other)
+	})
+
+	t.Run("records frames from a source", func(t *testing.T) {
+		dir := t.TempDir()
+		recorder, err := tools.NewFileRecorder(dir, tools.DefaultRecordingMaxSize)
+		require.NoError(t, err)
+
+		hub, server := newFakeHub(t, nil)
+		hub.serve = func(ctx context.Context, conn *websocket.Conn) {
+			conn.Write(ctx, websocket.MessageText, []byte(locationFrame))
+			hub.record(ctx, conn)
+		}
+		source := tools.NewSignalRSource(server.URL + "/hub")
+		source.Recorder = recorder
+		store := tools.NewLocationStore(time.Minute)
+		stop, _ := runSource(source, store)
+
+		assert.Eventually(t, func() bool { return len(store.Buses()) == 1 }, time.Second, 5*time.Millisecond)
+		stop()
+		require.NoError(t, recorder.Close())
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		frames := readRecording(t, files[0])
+		require.Len(t, frames, 1)
+		assert.Equal(t, strings.TrimSuffix(locationFrame, "\x1e"), frames[0].Data)
+		assert.Equal(t, source.Name(), frames[0].Source)
+		assert.WithinDuration(t, time.Now(), frames[0].ReceivedAt, time.Minute)
+	})
+}
+
+func TestReplaySource(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := tools.NewFileRecorder(dir, 400)
+	require.NoError(t, err)
+	start := time.Date(2026, 1, 13, 8, 0, 0, 0, time.UTC)
+	recorder.Record(recordedFrame(start, "B1"))
+	recorder.Record(tools.Frame{ReceivedAt: start.Add(time.Second), Source: "test", Data: `{"type":6}`})
+	recorder.Record(recordedFrame(start.Add(2*time.Second), "B2"))
+	require.NoError(t, recorder.Close())
+
+	t.Run("replays a directory of recordings", func(t *testing.T) {
+		store := tools.NewLocationStore(time.Minute)
+		var updates [][]string
+		store.OnUpdate(func(locations []tools.BusLocation) { updates = append(updates, busIDs(locations)) })
+
+		err := tools.NewReplaySource(dir, 0).Run(context.Background(), store)
+
+		require.NoError(t, err)
+		assert.Len(t, updates, 2)
+		assert.ElementsMatch(t, []string{"B1", "B2"}, busIDs(store.Buses()))
+	})
+
+	t.Run("keeps the time between frames at the given speed", func(t *testing.T) {
+		began := time.Now()
+		err := tools.NewReplaySource(dir, 20).Run(context.Background(), tools.NewLocationStore(time.Minute))
+
+		require.NoError(t, err)
+		// two seconds of recording at twenty times the speed
+		assert.GreaterOrEqual(t, time.Since(began), 100*time.Millisecond)
+		assert.Less(t, time.Since(began), time.Second)
+	})
+
+	t.Run("moves timestamps to the time of the replay", func(t *testing.T) {
+		dir := t.TempDir()
+		recorder, err := tools.NewFileRecorder(dir, tools.DefaultRecordingMaxSize)
+		require.NoError(t, err)
+		// each location was sent half a minute before its frame was received
+		for i, busID := range []string{"B1", "B2"} {
+			frame := recordedFrame(start.Add(time.Duration(i)*time.Second), busID)
+			sent := frame.ReceivedAt.Add(-30 * time.Second).Format(time.RFC3339)
+			frame.Data = strings.Replace(frame.Data, "2026-01-11T03:55:00Z", sent, 1)
+			recorder.Record(frame)
+		}
+		require.NoError(t, recorder.Close())
+		store := tools.NewLocationStore(time.Minute)
+
+		began := time.Now()
+		require.NoError(t, tools.NewReplaySource(dir, 0).Run(context.Background(), store))
+
+		sent := make(map[string]time.Time)
+		for _, location := range store.Buses() {
+			sent[location.BusID] = location.Timestamp
+		}
+		require.Len(t, sent, 2)
+		for _, busID := range []string{"B1", "B2"} {
+			assert.WithinRange(t, sent[busID], began.Add(-30*time.Second), time.Now().Add(-30*time.Second), busID)
+		}
+	})
+
+	t.Run("keeps timestamps behind the clock at any speed", func(t *testing.T) {
+		dir := t.TempDir()
+		recorder, err := tools.NewFileRecorder(dir, tools.DefaultRecordingMaxSize)
+		require.NoError(t, err)
+		// each location was sent as its frame was received
+		for i, busID := range []string{"B1", "B2", "B3"} {
+			frame := recordedFrame(start.Add(time.Duration(i)*time.Second), busID)
+			frame.Data = strings.Replace(frame.Data, "2026-01-11T03:55:00Z", frame.ReceivedAt.Format(time.RFC3339), 1)
+			recorder.Record(frame)
+		}
+		require.NoError(t, recorder.Close())
+
+		for _, speed := range []float64{0, 10} {
+			store := tools.NewLocationStore(time.Minute)
+			var ahead []string
+			store.OnUpdate(func(locations []tools.BusLocation) {
+				now := time.Now()
+				for _, location := range locations {
+					if location.Timestamp.After(now) {
+						ahead = append(ahead, location.BusID)
+					}
+				}
+			})
+
+			require.NoError(t, tools.NewReplaySource(dir, speed).Run(context.Background(), store))
+
+			assert.Len(t, store.Buses(), 3, speed)
+			assert.Empty(t, ahead, speed)
+		}
+	})
+
+	t.Run("loops until cancelled", func(t *testing.T) {
+		store := tools.NewLocationStore(time.Minute)
+		updates := make(chan struct{}, 10)
+		store.OnUpdate(func([]tools.BusLocation) {
+			select {
+			case updates <- struct{}{}:
+			default:
+			}
+		})
+		source := tools.NewReplaySource(dir, 200)
+		source.Loop = true
+		stop, _ := runSource(source, store)
+
+		for range 5 {
+			select {
+			case <-updates:
+			case <-time.After(time.Second):
+				t.Fatal("recording was not replayed again")
+			}
+		}
+		assert.ErrorIs(t, stop(), context.Canceled)
+	})
+
+	t.Run("missing recording", func(t *testing.T) {
+		err := tools.NewReplaySource(filepath.Join(dir, "missing.jsonl"), 1).Run(context.Background(), tools.NewLocationStore(time.Minute))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,278 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transitIOM/projectMercury/internal/tools"
+)
+
+const locationFrame = `{"type":1,"target":"updateLocations","arguments":[{"locations":["D1|B1|T1|R1|Dir1|54.1|-4.5|2026-01-11T03:55:00Z|1|E1"]}]}` + "\x1e"
+
+// fakeHub is a SignalR hub that accepts the JSON protocol and hands each connection to serve.
+type fakeHub struct {
+	t     *testing.T
+	serve func(ctx context.Context, conn *websocket.Conn)
+
+	mutex        sync.Mutex
+	negotiations int
+	connections  []string
+	received     []string
+	// queries holds the query of every request the hub received
+	queries []url.Values
+}
+
+func newFakeHub(t *testing.T, serve func(ctx context.Context, conn *websocket.Conn)) (*fakeHub, *httptest.Server) {
+	hub := &fakeHub{t: t, serve: serve}
+	server := httptest.NewServer(hub)
+	t.Cleanup(server.Close)
+	return hub, server
+}
+
+func (h *fakeHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mutex.Lock()
+	h.queries = append(h.queries, r.URL.Query())
+	h.mutex.Unlock()
+
+	switch r.URL.Path {
+	case "/hub/negotiate":
+		assert.Equal(h.t, http.MethodPost, r.Method)
+		h.mutex.Lock()
+		h.negotiations++
+		h.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"negotiateVersion":1,"connectionId":"id","connectionToken":"token",` +
+			`"availableTransports":[{"transport":"WebSockets","transferFormats":["Text","Binary"]}]}`))
+	case "/hub":
+		conn, err := websocket.Accept(w, r, nil)
+		if !assert.NoError(h.t, err) {
+			return
+		}
+		defer conn.CloseNow()
+		h.mutex.Lock()
+		h.connections = append(h.connections, r.URL.Query().Get("id"))
+		h.mutex.Unlock()
+
+		ctx := r.Context()
+		_, handshake, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		assert.Equal(h.t, `{"protocol":"json","version":1}`+"\x1e", string(handshake))
+		if conn.Write(ctx, websocket.MessageText, []byte("{}\x1e")) != nil {
+			return
+		}
+		h.serve(ctx, conn)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// record reads and keeps the messages the client sends until the connection closes.
+func (h *fakeHub) record(ctx context.Context, conn *websocket.Conn) {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		h.mutex.Lock()
+		h.received = append(h.received, string(data))
+		h.mutex.Unlock()
+	}
+}
+
+func (h *fakeHub) state() (negotiations int, connections []string, received []string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.negotiations, append([]string(nil), h.connections...), append([]string(nil), h.received...)
+}
+
+// runSource runs a source in the background, returning a function that stops it and returns
+// what Run returned.
+func runSource(source tools.LocationSource, store *tools.LocationStore) (stop func() error, done <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- source.Run(ctx, store) }()
+	return func() error {
+		cancel()
+		return <-errs
+	}, errs
+}
+
+func TestSignalRSource(t *testing.T) {
+	t.Run("receives locations", func(t *testing.T) {
+		hub, server := newFakeHub(t, nil)
+		hub.serve = func(ctx context.Context, conn *websocket.Conn) {
+			// a ping and a location frame in one message, then a frame split across two
+			conn.Write(ctx, websocket.MessageText, []byte(`{"type":6}`+"\x1e"+locationFrame))
+			second := strings.Replace(locationFrame, "B1", "B2", 1)
+			conn.Write(ctx, websocket.MessageText, []byte(second[:20]))
+			conn.Write(ctx, websocket.MessageText, []byte(second[20:]))
+			hub.record(ctx, conn)
+		}
+
+		store := tools.NewLocationStore(time.Minute)
+		stop, _ := runSource(tools.NewSignalRSource(server.URL+"/hub", "SubscribeAll"), store)
+
+		assert.Eventually(t, func() bool { return len(store.Buses()) == 2 }, time.Second, 5*time.Millisecond)
+		assert.ElementsMatch(t, []string{"B1", "B2"}, busIDs(store.Buses()))
+		assert.ErrorIs(t, stop(), context.Canceled)
+
+		_, connections, received := hub.state()
+		assert.Equal(t, []string{"token"}, connections)
+		require.NotEmpty(t, received)
+		var subscription struct {
+			Type   int    `json:"type"`
+			Target string `json:"target"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimSuffix(received[0], "\x1e")), &subscription))
+		assert.Equal(t, 1, subscription.Type)
+		assert.Equal(t, "SubscribeAll", subscription.Target)
+	})
+
+	t.Run("keeps the query of the hub URL", func(t *testing.T) {
+		hub, server := newFakeHub(t, nil)
+		hub.serve = hub.record
+
+		store := tools.NewLocationStore(time.Minute)
+		stop, _ := runSource(tools.NewSignalRSource(server.URL+"/hub?key=1"), store)
+
+		assert.Eventually(t, func() bool {
+			_, connections, _ := hub.state()
+			return len(connections) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.ErrorIs(t, stop(), context.Canceled)
+
+		hub.mutex.Lock()
+		defer hub.mutex.Unlock()
+		require.Len(t, hub.queries, 2)
+		assert.Equal(t, url.Values{"key": {"1"}, "negotiateVersion": {"1"}}, hub.queries[0])
+		assert.Equal(t, url.Values{"key": {"1"}, "id": {"token"}}, hub.queries[1])
+	})
+
+	t.Run("pings the hub", func(t *testing.T) {
+		hub, server := newFakeHub(t, nil)
+		hub.serve = hub.record
+
+		source := tools.NewSignalRSource(server.URL + "/hub")
+		source.PingInterval = 10 * time.Millisecond
+		stop, _ := runSource(source, tools.NewLocationStore(time.Minute))
+		defer stop()
+
+		assert.Eventually(t, func() bool {
+			_, _, received := hub.state()
+			return len(received) >= 2
+		}, time.Second, 5*time.Millisecond)
+		_, _, received := hub.state()
+		assert.Equal(t, `{"type":6}`+"\x1e", received[0])
+	})
+
+	t.Run("reconnects when the connection drops", func(t *testing.T) {
+		var mutex sync.Mutex
+		attempt := 0
+		hub, server := newFakeHub(t, nil)
+		hub.serve = func(ctx context.Context, conn *websocket.Conn) {
+			mutex.Lock()
+			attempt++
+			first := attempt == 1
+			mutex.Unlock()
+			if first {
+				conn.Close(websocket.StatusGoingAway, "restarting")
+				return
+			}
+			conn.Write(ctx, websocket.MessageText, []byte(locationFrame))
+			hub.record(ctx, conn)
+		}
+
+		source := tools.NewSignalRSource(server.URL + "/hub")
+		source.ReconnectDelay = time.Millisecond
+		store := tools.NewLocationStore(time.Minute)
+		stop, _ := runSource(source, store)
+		defer stop()
+
+		assert.Eventually(t, func() bool { return len(store.Buses()) == 1 }, time.Second, 5*time.Millisecond)
+		negotiations, _, _ := hub.state()
+		assert.Equal(t, 2, negotiations)
+	})
+
+	t.Run("reconnects when the hub goes quiet", func(t *testing.T) {
+		hub, server := newFakeHub(t, nil)
+		hub.serve = func(ctx context.Context, conn *websocket.Conn) { <-ctx.Done() }
+
+		source := tools.NewSignalRSource(server.URL + "/hub")
+		source.PingInterval = time.Minute
+		source.ServerTimeout = 20 * time.Millisecond
+		source.ReconnectDelay = time.Millisecond
+		stop, _ := runSource(source, tools.NewLocationStore(time.Minute))
+		defer stop()
+
+		assert.Eventually(t, func() bool {
+			negotiations, _, _ := hub.state()
+			return negotiations >= 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("stops when the hub closes without allowing reconnects", func(t *testing.T) {
+		hub, server := newFakeHub(t, nil)
+		hub.serve = func(ctx context.Context, conn *websocket.Conn) {
+			conn.Write(ctx, websocket.MessageText, []byte(`{"type":7,"error":"shutting down"}`+"\x1e"))
+			hub.record(ctx, conn)
+		}
+
+		source := tools.NewSignalRSource(server.URL + "/hub")
+		source.ReconnectDelay = time.Millisecond
+		_, done := runSource(source, tools.NewLocationStore(time.Minute))
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, tools.ServerClosedConnection)
+			assert.ErrorContains(t, err, "shutting down")
+		case <-time.After(time.Second):
+			t.Fatal("source did not stop")
+		}
+		negotiations, _, _ := hub.state()
+		assert.Equal(t, 1, negotiations)
+	})
+
+	t.Run("rejected handshake", func(t *testing.T) {
+		hub, server := newFakeHub(t, nil)
+		hub.serve = hub.record
+		// the hub answers the handshake with an error instead of accepting it
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/hub" {
+				hub.ServeHTTP(w, r)
+				return
+			}
+			conn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.CloseNow()
+			conn.Read(r.Context())
+			conn.Write(r.Context(), websocket.MessageText, []byte(`{"error":"unsupported protocol"}`+"\x1e"))
+			conn.Read(r.Context())
+		})
+
+		source := tools.NewSignalRSource(server.URL + "/hub")
+		source.ReconnectDelay = time.Millisecond
+		store := tools.NewLocationStore(time.Minute)
+		stop, _ := runSource(source, store)
+
+		assert.Eventually(t, func() bool {
+			negotiations, _, _ := hub.state()
+			return negotiations >= 2
+		}, time.Second, 5*time.Millisecond)
+		assert.ErrorIs(t, stop(), context.Canceled)
+		assert.Empty(t, store.Buses())
+	})
+}